@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ianmuhia/kit/pkg/authzgen"
 	"github.com/urfave/cli/v3"
@@ -17,11 +20,15 @@ func main() {
 		Usage:   "Generate type-safe Go client code from AuthZed permission schemas",
 		Version: "1.0.0",
 		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "schema",
+				Aliases: []string{"s"},
+				Usage:   "Path to an AuthZed schema (.zed) file or a directory of them; repeat for several",
+			},
 			&cli.StringFlag{
-				Name:     "schema",
-				Aliases:  []string{"s"},
-				Usage:    "Path to the AuthZed schema (.zed) file",
-				Required: true,
+				Name:  "dialect",
+				Usage: "Schema language: spicedb (.zed files) or openfga (.fga files)",
+				Value: authzgen.DialectSpiceDB,
 			},
 			&cli.StringFlag{
 				Name:    "output",
@@ -29,35 +36,120 @@ func main() {
 				Usage:   "Output directory for generated code",
 				Value:   ".",
 			},
+			&cli.StringFlag{
+				Name:  "package",
+				Usage: "Package name of the generated code (default: the schema's namespace prefix, or authz)",
+			},
+			&cli.BoolFlag{
+				Name:  "single-file",
+				Usage: "Generate all definitions into definitions.gen.go instead of a file per definition",
+			},
+			&cli.StringSliceFlag{
+				Name:  "middleware",
+				Usage: "Generate permission middleware for these routers (http, chi, echo)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "cache",
+				Usage: "Generate CachedClient, caching permission checks, with these backends (lru, redis)",
+			},
+			&cli.BoolFlag{
+				Name:  "fake",
+				Usage: "Generate Fake, an in-memory Authorizer for tests, with a table-driven test",
+			},
+			&cli.BoolFlag{
+				Name:  "fixtures",
+				Usage: "Generate the fixtures package, building the schema's relationships for integration tests",
+			},
+			&cli.StringFlag{
+				Name:  "seed",
+				Usage: "YAML file of relationships to generate Client.Seed from",
+			},
+			&cli.BoolFlag{
+				Name:  "permissions-doc",
+				Usage: "Generate PERMISSIONS.md, a matrix of each definition's permissions and relations",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Regenerate whenever a schema file changes, until interrupted",
+			},
 			&cli.StringFlag{
 				Name:  "log-level",
 				Usage: "Log level (debug, info, warn, error)",
 				Value: "info",
 			},
 		},
-		Action: func(ctx context.Context, cmd *cli.Command) error {
-			level := slog.LevelInfo
-			switch cmd.String("log-level") {
-			case "debug":
-				level = slog.LevelDebug
-			case "warn":
-				level = slog.LevelWarn
-			case "error":
-				level = slog.LevelError
-			}
+		Commands: []*cli.Command{
+			{
+				Name:  "validate",
+				Usage: "Check the schema's cross-references and report every problem without generating code",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					generator, err := authzgen.NewGenerator(generatorOptions(cmd)...)
+					if err != nil {
+						return fmt.Errorf("failed to create generator: %w", err)
+					}
 
-			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
-			slog.SetDefault(logger)
+					if err := generator.Validate(); err != nil {
+						var verr *authzgen.ValidationError
+						if errors.As(err, &verr) {
+							for _, p := range verr.Problems {
+								fmt.Fprintln(os.Stderr, p)
+							}
+							return cli.Exit(fmt.Sprintf("schema has %d problem(s)", len(verr.Problems)), 1)
+						}
+						return fmt.Errorf("validation failed: %w", err)
+					}
 
-			generator, err := authzgen.NewGenerator(
-				authzgen.WithSchemaFile(cmd.String("schema")),
-				authzgen.WithOutputDir(cmd.String("output")),
-				authzgen.WithLogger(logger),
-			)
+					fmt.Println("Schema is valid")
+					return nil
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "Report the changes between two versions of a schema, failing on breaking changes",
+				ArgsUsage: "<old schema> <new schema>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 2 {
+						return cli.Exit("diff needs the old and the new schema", 2)
+					}
+					old, err := parseSchema(cmd, cmd.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					cur, err := parseSchema(cmd, cmd.Args().Get(1))
+					if err != nil {
+						return err
+					}
+
+					changes := authzgen.Diff(old, cur)
+					breaking := 0
+					for _, c := range changes {
+						fmt.Println(c)
+						if c.Breaking {
+							breaking++
+						}
+					}
+					if len(changes) == 0 {
+						fmt.Println("No changes")
+					}
+					if breaking > 0 {
+						return cli.Exit(fmt.Sprintf("%d breaking change(s)", breaking), 1)
+					}
+					return nil
+				},
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			generator, err := authzgen.NewGenerator(generatorOptions(cmd)...)
 			if err != nil {
 				return fmt.Errorf("failed to create generator: %w", err)
 			}
 
+			if cmd.Bool("watch") {
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return generator.Watch(ctx, os.Stdout)
+			}
+
 			if err := generator.Generate(); err != nil {
 				return fmt.Errorf("code generation failed: %w", err)
 			}
@@ -71,3 +163,63 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// generatorOptions configures the generator and the default logger from the
+// root command's flags.
+func generatorOptions(cmd *cli.Command) []authzgen.Option {
+	level := slog.LevelInfo
+	switch cmd.String("log-level") {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+
+	opts := []authzgen.Option{
+		authzgen.WithOutputDir(cmd.String("output")),
+		authzgen.WithDialect(cmd.String("dialect")),
+		authzgen.WithLogger(logger),
+		authzgen.WithMiddleware(cmd.StringSlice("middleware")...),
+		authzgen.WithCache(cmd.StringSlice("cache")...),
+		authzgen.WithFilePerDefinition(!cmd.Bool("single-file")),
+	}
+	if seed := cmd.String("seed"); seed != "" {
+		opts = append(opts, authzgen.WithSeedFile(seed))
+	}
+	if name := cmd.String("package"); name != "" {
+		opts = append(opts, authzgen.WithPackageName(name))
+	}
+	if cmd.Bool("fake") {
+		opts = append(opts, authzgen.WithFake())
+	}
+	if cmd.Bool("fixtures") {
+		opts = append(opts, authzgen.WithFixtures())
+	}
+	if cmd.Bool("permissions-doc") {
+		opts = append(opts, authzgen.WithPermissionsDoc())
+	}
+	for _, schema := range cmd.StringSlice("schema") {
+		opts = append(opts, authzgen.WithSchemaFile(schema))
+	}
+	return opts
+}
+
+// parseSchema parses the schema at path, a file or a directory, with the
+// root command's dialect and logging flags.
+func parseSchema(cmd *cli.Command, path string) (*authzgen.Schema, error) {
+	opts := append(generatorOptions(cmd), authzgen.WithSchemaFile(path))
+	generator, err := authzgen.NewGenerator(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create generator: %w", err)
+	}
+	schema, err := generator.ParseSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return schema, nil
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ianmuhia/kit/internal/dddgen"
 	"github.com/urfave/cli/v3"
@@ -17,81 +21,545 @@ func main() {
 			"Ian Muhia <https://github.com/Ianmuhia>",
 		},
 		Version: "1.0.0",
+		Commands: []*cli.Command{
+			addCommand(),
+			generateCommand(),
+			listCommand(),
+			newServiceCommand(),
+			removeCommand(),
+		},
+		Flags: append(componentFlags(),
+			jsonFlag(),
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print a unified diff against existing files instead of writing them",
+			},
+			&cli.BoolFlag{
+				Name:  "regenerate",
+				Usage: "Regenerate an existing domain, protecting files edited since the last run",
+			},
+			&cli.StringFlag{
+				Name:  "on-conflict",
+				Usage: "What to do with manually edited files when regenerating: skip, overwrite, merge, or prompt",
+				Value: string(dddgen.ConflictSkip),
+			},
+		),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := domainConfig(cmd)
+			if err != nil {
+				return err
+			}
+			cfg.DryRun = cmd.Bool("dry-run")
+			cfg.Regenerate = cmd.Bool("regenerate")
+			cfg.OnConflict = dddgen.ConflictStrategy(cmd.String("on-conflict"))
+			return runGenerator(cmd, cfg)
+		},
+	}
+
+	if err := cmd.Run(context.Background(), os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addCommand generates new components into an existing domain without
+// touching the files it already has.
+func addCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "add",
+		Usage: "Add components to an existing domain, writing only files it does not have yet",
+		Flags: append(componentFlags(),
+			jsonFlag(),
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the files that would be added instead of writing them",
+			},
+		),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := domainConfig(cmd)
+			if err != nil {
+				return err
+			}
+			cfg.DryRun = cmd.Bool("dry-run")
+			cfg.AddOnly = true
+			return runGenerator(cmd, cfg)
+		},
+	}
+}
+
+// generateCommand generates every domain declared in a project file.
+func generateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "generate",
+		Usage: "Generate all domains declared in a ddd-gen.yaml / ddd-gen.cue project file",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "domain",
-				Aliases:  []string{"d"},
-				Usage:    "Domain name (e.g., 'booking', 'user', 'order')",
-				Required: true,
+				Name:    "config",
+				Aliases: []string{"f"},
+				Usage:   "Path to the project file (default: ddd-gen.yaml, ddd-gen.cue, ... in the current directory)",
 			},
+			&cli.StringFlag{
+				Name:    "templates",
+				Usage:   "Directory of templates overriding the embedded ones; takes precedence over the project file",
+				Sources: cli.EnvVars("DDD_GEN_TEMPLATES"),
+			},
+			&cli.BoolFlag{
+				Name:  "regenerate",
+				Usage: "Regenerate existing domains even when their options did not change",
+			},
+			&cli.StringFlag{
+				Name:  "on-conflict",
+				Usage: "What to do with manually edited files of regenerated domains: skip, overwrite, merge, or prompt (default merge, or onConflict of the project file)",
+			},
+			jsonFlag(),
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			path := cmd.String("config")
+			if path == "" {
+				found, err := dddgen.FindProjectFile(".")
+				if err != nil {
+					return err
+				}
+				path = found
+			}
+
+			project, err := dddgen.LoadProjectConfig(path)
+			if err != nil {
+				return err
+			}
+			if dir := cmd.String("templates"); dir != "" {
+				// Relative to the working directory, not the project file
+				if project.Templates, err = filepath.Abs(dir); err != nil {
+					return err
+				}
+			}
+			if strategy := cmd.String("on-conflict"); strategy != "" {
+				if project.OnConflict, err = dddgen.ParseConflictStrategy(strategy); err != nil {
+					return err
+				}
+			}
+			project.Regenerate = project.Regenerate || cmd.Bool("regenerate")
+
+			if cmd.Bool("json") {
+				results, err := dddgen.GenerateProject(project, nil, os.Stderr)
+				if results == nil {
+					results = []dddgen.DomainResult{}
+				}
+				if encErr := writeJSON(results); encErr != nil {
+					return encErr
+				}
+				return err
+			}
+
+			results, err := dddgen.GenerateProject(project, nil, nil)
+			for _, r := range results {
+				fmt.Printf("  %-12s %s (%s)\n", r.Status, r.Domain, r.Path)
+			}
+			return err
+		},
+	}
+}
+
+// listCommand shows the generated domains under the output directory.
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List the domains generated under the output directory",
+		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Usage:   "Output directory for generated code",
+				Usage:   "Directory holding the generated domains",
 				Value:   "./internal",
 			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			domains, err := dddgen.ListDomains(cmd.String("output"))
+			if err != nil {
+				return err
+			}
+			if len(domains) == 0 {
+				fmt.Printf("No generated domains in %s\n", cmd.String("output"))
+				return nil
+			}
+			for _, d := range domains {
+				fmt.Printf("  %-16s %s (%d files, %d modified, %d missing)\n",
+					d.Name, d.Path, len(d.Files), len(d.Modified), len(d.Missing))
+			}
+			return nil
+		},
+	}
+}
+
+// removeCommand deletes the generated files of a domain, keeping manual edits.
+func removeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
+		Usage:     "Delete the generated files of a domain, keeping files modified by hand",
+		ArgsUsage: "<domain>",
+		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "module",
-				Aliases:  []string{"mod"},
-				Usage:    "Go module path (e.g. github.com/user/project)",
-				Required: true,
-			},
-			&cli.BoolFlag{
-				Name:    "with-tests",
-				Aliases: []string{"t"},
-				Usage:   "Generate test files",
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Directory holding the generated domains",
+				Value:   "./internal",
 			},
-			&cli.BoolFlag{
-				Name:    "with-messaging",
-				Aliases: []string{"m"},
-				Usage:   "Generate messaging adapter (Watermill pub/sub)",
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() != 1 {
+				return fmt.Errorf("usage: ddd-gen remove <domain>")
+			}
+			domainDir := filepath.Join(cmd.String("output"), strings.ToLower(cmd.Args().First()))
+
+			result, err := dddgen.RemoveDomain(domainDir)
+			if result != nil {
+				fmt.Printf("Removed %d generated files from %s\n", len(result.Removed), domainDir)
+				for _, rel := range result.Kept {
+					fmt.Printf("  kept     %s (modified)\n", rel)
+				}
+			}
+			return err
+		},
+	}
+}
+
+// newServiceCommand scaffolds the service the generated domains plug into.
+func newServiceCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "new-service",
+		Usage:     "Scaffold cmd/<name>/main.go, configuration, and an HTTP server with health endpoints and graceful shutdown",
+		ArgsUsage: "<name>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "Module root; a go.mod is created there when none exists",
+				Value: ".",
 			},
-			&cli.BoolFlag{
-				Name:    "with-river",
-				Aliases: []string{"r"},
-				Usage:   "Generate River job queue adapter",
+			&cli.StringFlag{
+				Name:    "module",
+				Aliases: []string{"mod"},
+				Usage:   "Go module path; required when there is no go.mod yet",
 			},
-			&cli.BoolFlag{
-				Name:    "with-cqrs",
-				Aliases: []string{"c"},
-				Usage:   "Generate CQRS components (Watermill commands, events, handlers)",
+			&cli.StringFlag{
+				Name:  "http-router",
+				Usage: "Router the domains register on: huma, chi, echo, gin, or net-http",
+				Value: string(dddgen.RouterHuma),
 			},
 			&cli.BoolFlag{
-				Name:    "with-workflows",
-				Aliases: []string{"w"},
-				Usage:   "Generate Temporal workflow adapter",
+				Name:    "with-messaging",
+				Aliases: []string{"m"},
+				Usage:   "Run a Watermill message router over NATS for CQRS and messaging handlers",
 			},
-			&cli.BoolFlag{
-				Name:  "with-decorators",
-				Usage: "Generate service decorators (permissions, audit, cache, metrics)",
+			&cli.StringFlag{
+				Name:    "templates",
+				Usage:   "Directory of templates overriding the embedded ones by path (e.g. service/main.go.tmpl)",
+				Sources: cli.EnvVars("DDD_GEN_TEMPLATES"),
 			},
 			&cli.BoolFlag{
-				Name:  "all",
-				Usage: "Generate all optional components",
+				Name:  "dry-run",
+				Usage: "Print the files that would be created instead of writing them",
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			cfg := dddgen.Config{
-				DomainName:     cmd.String("domain"),
-				OutputDir:      cmd.String("output"),
-				ModulePath:     cmd.String("module"),
-				WithTests:      cmd.Bool("with-tests") || cmd.Bool("all"),
-				WithMessaging:  cmd.Bool("with-messaging") || cmd.Bool("all"),
-				WithRiver:      cmd.Bool("with-river") || cmd.Bool("all"),
-				WithCQRS:       cmd.Bool("with-cqrs") || cmd.Bool("all"),
-				WithWorkflows:  cmd.Bool("with-workflows") || cmd.Bool("all"),
-				WithDecorators: cmd.Bool("with-decorators") || cmd.Bool("all"),
-			}
-
-			generator, err := dddgen.New(cfg)
+			if cmd.Args().Len() != 1 {
+				return fmt.Errorf("usage: ddd-gen new-service <name>")
+			}
+			name := cmd.Args().First()
+
+			files, err := dddgen.GenerateService(dddgen.ServiceConfig{
+				Name:          name,
+				ModulePath:    cmd.String("module"),
+				Dir:           cmd.String("dir"),
+				HTTPRouter:    dddgen.HTTPRouter(cmd.String("http-router")),
+				WithMessaging: cmd.Bool("with-messaging"),
+				TemplatesDir:  cmd.String("templates"),
+				DryRun:        cmd.Bool("dry-run"),
+			}, nil)
 			if err != nil {
 				return err
 			}
-			return generator.Generate()
+			for _, f := range files {
+				fmt.Printf("  %-8s %s\n", f.Action, f.Path)
+			}
+			if cmd.Bool("dry-run") {
+				return nil
+			}
+			fmt.Println("\nNext steps:")
+			fmt.Println("  1. Generate domains, e.g. ddd-gen -d order --di manual --http-router " + cmd.String("http-router"))
+			fmt.Printf("  2. Register them in cmd/%s/main.go\n", name)
+			fmt.Println("  3. go mod tidy")
+			fmt.Printf("  4. go run ./cmd/%s\n", name)
+			return nil
 		},
 	}
+}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+// jsonFlag switches a generating command to machine-readable output.
+func jsonFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print a JSON report of the generated files to stdout; progress and next steps go to stderr",
+	}
+}
+
+// runGenerator generates the domain described by cfg. With --json the
+// report is printed to stdout, even when generation fails, and everything
+// else goes to stderr.
+func runGenerator(cmd *cli.Command, cfg dddgen.Config) error {
+	generator, err := dddgen.New(cfg)
+	if err != nil {
+		if !cmd.Bool("json") {
+			return err
+		}
+		// An invalid configuration gets a report too, with no files
+		domain := strings.ToLower(cfg.DomainName)
+		report := &dddgen.Report{
+			Domain: domain,
+			Path:   filepath.Join(cfg.OutputDir, domain),
+			DryRun: cfg.DryRun,
+			Files:  []dddgen.FileReport{},
+			Error:  err.Error(),
+		}
+		if encErr := writeJSON(report); encErr != nil {
+			return encErr
+		}
+		return err
 	}
+	if !cmd.Bool("json") {
+		return generator.Generate()
+	}
+
+	err = generator.WithOutput(os.Stderr).Generate()
+	report := generator.Report()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if encErr := writeJSON(report); encErr != nil {
+		return encErr
+	}
+	return err
+}
+
+// writeJSON prints v to stdout as indented JSON.
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// componentFlags are the flags that select the domain and its components,
+// shared by the root command and add.
+func componentFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "domain",
+			Aliases: []string{"d"},
+			Usage:   "Domain name (e.g., 'booking', 'user', 'order'); required unless a subcommand is used",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output directory for generated code",
+			Value:   "./internal",
+		},
+		&cli.StringFlag{
+			Name:    "module",
+			Aliases: []string{"mod"},
+			Usage:   "Go module path (e.g. github.com/user/project); defaults to the module of the nearest go.mod",
+		},
+		&cli.StringFlag{
+			Name:    "spec",
+			Aliases: []string{"s"},
+			Usage:   "Domain spec file (.yaml, .json, .cue) declaring the entity fields",
+		},
+		&cli.BoolFlag{
+			Name:    "with-tests",
+			Aliases: []string{"t"},
+			Usage:   "Generate test files",
+		},
+		&cli.BoolFlag{
+			Name:  "with-integration-tests",
+			Usage: "Generate testcontainers integration tests for the Postgres repository and NATS publisher (build tag 'integration'; requires --with-tests)",
+		},
+		&cli.BoolFlag{
+			Name:    "with-messaging",
+			Aliases: []string{"m"},
+			Usage:   "Generate messaging adapter (Watermill pub/sub)",
+		},
+		&cli.BoolFlag{
+			Name:    "with-river",
+			Aliases: []string{"r"},
+			Usage:   "Generate River job queue adapter",
+		},
+		&cli.BoolFlag{
+			Name:    "with-cqrs",
+			Aliases: []string{"c"},
+			Usage:   "Generate CQRS components (Watermill commands, events, handlers)",
+		},
+		&cli.BoolFlag{
+			Name:    "with-workflows",
+			Aliases: []string{"w"},
+			Usage:   "Generate Temporal workflow adapter, or a saga with --workflow-engine saga",
+		},
+		&cli.BoolFlag{
+			Name:  "with-decorators",
+			Usage: "Generate service decorators (permissions, audit, cache, metrics)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-mocks",
+			Usage: "Generate mocks for the Repository and Service interfaces (implied by --with-tests)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-outbox",
+			Usage: "Write domain events to a transactional outbox and generate its migration and relay worker",
+		},
+		&cli.BoolFlag{
+			Name:  "with-eventsourcing",
+			Usage: "Generate an event-sourced aggregate and Postgres event store with snapshots instead of the CRUD repository",
+		},
+		&cli.BoolFlag{
+			Name:  "with-graphql",
+			Usage: "Generate a GraphQL schema fragment and gqlgen-compatible resolvers",
+		},
+		&cli.BoolFlag{
+			Name:  "with-idempotency",
+			Usage: "Deduplicate create requests by their Idempotency-Key header (service decorator, keys table, HTTP middleware)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-audit-fields",
+			Usage: "Soft-delete with deleted_at/deleted_by columns, a table migration, and a Restore operation",
+		},
+		&cli.BoolFlag{
+			Name:  "with-tenancy",
+			Usage: "Scope the domain by tenant (TenantID column, context-scoped queries, HTTP tenant middleware)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-authz",
+			Usage: "Check SpiceDB permissions generated by authz-codegen before every service method (not implied by --all)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-errorgen",
+			Usage: "Generate the standard domain errors from errors.cue with errorgen (errors_gen.go)",
+		},
+		&cli.BoolFlag{
+			Name:  "with-cache",
+			Usage: "Cache Get results in Redis through a service decorator that invalidates on update and delete",
+		},
+		&cli.StringFlag{
+			Name:  "cache-ttl",
+			Usage: "How long --with-cache keeps an entity, e.g. 10m (overrides the spec; default 5m)",
+		},
+		&cli.StringFlag{
+			Name:  "jobs",
+			Usage: "River jobs to generate, e.g. 'send_email,expire_stale@1h'; '@interval' makes a job periodic (requires --with-river)",
+		},
+		&cli.StringFlag{
+			Name:  "aggregate",
+			Usage: "Aggregate root and its child entities, e.g. 'order:items,payments'; the root defaults --domain",
+		},
+		&cli.StringFlag{
+			Name:  "http-router",
+			Usage: "Framework for the HTTP adapter: huma, chi, echo, gin, or net-http",
+			Value: string(dddgen.RouterHuma),
+		},
+		&cli.StringFlag{
+			Name:  "messaging-backend",
+			Usage: "Transport for the messaging adapter: nats, kafka, or amqp (default nats; requires --with-messaging)",
+		},
+		&cli.StringFlag{
+			Name:  "authz-package",
+			Usage: "Import path of the authz-codegen output (default <module>/internal/authz; requires --with-authz)",
+		},
+		&cli.StringFlag{
+			Name:  "workflow-engine",
+			Usage: "What --with-workflows generates: temporal or saga (default temporal; saga requires --with-cqrs)",
+		},
+		&cli.StringFlag{
+			Name:  "di",
+			Usage: "Generate dependency injection wiring for the domain: wire, fx, or manual",
+		},
+		&cli.StringFlag{
+			Name:  "dev-env",
+			Usage: "Write a task runner file (taskfile or make) and docker-compose.yaml for local development at the module root",
+		},
+		&cli.StringFlag{
+			Name:    "templates",
+			Usage:   "Directory of templates overriding the embedded ones by path (e.g. app/service.go.tmpl)",
+			Sources: cli.EnvVars("DDD_GEN_TEMPLATES"),
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "Generate all optional components",
+		},
+	}
+}
+
+// domainConfig builds the generator config from the component flags.
+func domainConfig(cmd *cli.Command) (dddgen.Config, error) {
+	cfg := dddgen.Config{
+		DomainName:           cmd.String("domain"),
+		OutputDir:            cmd.String("output"),
+		ModulePath:           cmd.String("module"),
+		WithTests:            cmd.Bool("with-tests") || cmd.Bool("all"),
+		WithIntegrationTests: cmd.Bool("with-integration-tests") || cmd.Bool("all"),
+		WithMessaging:        cmd.Bool("with-messaging") || cmd.Bool("all"),
+		WithRiver:            cmd.Bool("with-river") || cmd.Bool("all"),
+		WithCQRS:             cmd.Bool("with-cqrs") || cmd.Bool("all"),
+		WithWorkflows:        cmd.Bool("with-workflows") || cmd.Bool("all"),
+		WithDecorators:       cmd.Bool("with-decorators") || cmd.Bool("all"),
+		WithMocks:            cmd.Bool("with-mocks") || cmd.Bool("all"),
+		WithOutbox:           cmd.Bool("with-outbox") || cmd.Bool("all"),
+		WithEventSourcing:    cmd.Bool("with-eventsourcing"),
+		WithGraphQL:          cmd.Bool("with-graphql") || cmd.Bool("all"),
+		WithIdempotency:      cmd.Bool("with-idempotency") || cmd.Bool("all"),
+		WithAuditFields:      cmd.Bool("with-audit-fields") || cmd.Bool("all"),
+		WithTenancy:          cmd.Bool("with-tenancy") || cmd.Bool("all"),
+		WithAuthz:            cmd.Bool("with-authz"),
+		AuthzPackage:         cmd.String("authz-package"),
+		WithErrorgen:         cmd.Bool("with-errorgen") || cmd.Bool("all"),
+		WithCache:            cmd.Bool("with-cache") || cmd.Bool("all"),
+		HTTPRouter:           dddgen.HTTPRouter(cmd.String("http-router")),
+		MessagingBackend:     dddgen.MessagingBackend(cmd.String("messaging-backend")),
+		WorkflowEngine:       dddgen.WorkflowEngine(cmd.String("workflow-engine")),
+		DI:                   dddgen.DIMode(cmd.String("di")),
+		DevEnv:               dddgen.DevEnv(cmd.String("dev-env")),
+		TemplatesDir:         cmd.String("templates"),
+	}
+
+	if path := cmd.String("spec"); path != "" {
+		spec, err := dddgen.LoadDomainSpec(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Fields = spec.Fields
+		cfg.Children = spec.Children
+		cfg.ValueObjects = spec.ValueObjects
+		cfg.Jobs = spec.Jobs
+		cfg.CacheTTL = spec.CacheTTL
+	}
+	if ttl := cmd.String("cache-ttl"); ttl != "" {
+		cfg.CacheTTL = ttl
+	}
+
+	if list := cmd.String("jobs"); list != "" {
+		jobs, err := dddgen.ParseJobs(list)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Jobs = append(cfg.Jobs, jobs...)
+	}
+
+	if agg := cmd.String("aggregate"); agg != "" {
+		root, children, err := dddgen.ParseAggregate(agg)
+		if err != nil {
+			return cfg, err
+		}
+		if cfg.DomainName == "" {
+			cfg.DomainName = root
+		} else if !strings.EqualFold(cfg.DomainName, root) {
+			return cfg, fmt.Errorf("aggregate root %q does not match domain %q", root, cfg.DomainName)
+		}
+		cfg.Children = dddgen.MergeParts(cfg.Children, children)
+	}
+
+	return cfg, nil
 }
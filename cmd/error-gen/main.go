@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ianmuhia/kit/pkg/errorgen"
 	"github.com/urfave/cli/v3"
@@ -13,13 +15,13 @@ import (
 func main() {
 	cmd := &cli.Command{
 		Name:    "error-gen",
-		Usage:   "Generate strongly-typed error codes from CUE definitions",
+		Usage:   "Generate strongly-typed error codes from CUE, YAML, or JSON definitions",
 		Version: "1.0.0",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "input",
 				Aliases: []string{"i"},
-				Usage:   "Input CUE file or directory",
+				Usage:   "Input file (.cue, .yaml, .yml, or .json) or CUE directory",
 				Value:   "errors.cue",
 			},
 			&cli.StringFlag{
@@ -33,6 +35,51 @@ func main() {
 				Aliases: []string{"t"},
 				Usage:   "Custom error template file (optional)",
 			},
+			&cli.StringFlag{
+				Name:  "docs",
+				Usage: "Also write a catalog of the errors to this file: Markdown, or HTML for .html (optional)",
+			},
+			&cli.StringFlag{
+				Name:  "lang",
+				Usage: "Language of the output file: go or ts (TypeScript error codes and catalog)",
+				Value: errorgen.LanguageGo,
+			},
+			&cli.StringFlag{
+				Name:  "openapi",
+				Usage: "Also write OpenAPI schemas of the error codes and envelope to this YAML file (optional)",
+			},
+			&cli.BoolFlag{
+				Name:  "auto-prefix",
+				Usage: "Prepend the catalog's codePrefix to the codes that lack it instead of rejecting them",
+			},
+			&cli.BoolFlag{
+				Name:  "catalog-handler",
+				Usage: "Generate CatalogHandler, an http.Handler serving the catalog as JSON",
+			},
+			&cli.BoolFlag{
+				Name:  "stack-traces",
+				Usage: "Make the errors capture the stack where they are constructed, exposed by StackTrace()",
+			},
+			&cli.BoolFlag{
+				Name:  "httputil",
+				Usage: "Generate ToHTTPError and WriteHTTP, bridging the errors to pkg/httputil's response envelope",
+			},
+			&cli.BoolFlag{
+				Name:  "tests",
+				Usage: "Also write <output>_test.go asserting errors.Is and errors.As for every error",
+			},
+			&cli.BoolFlag{
+				Name:  "split-by-category",
+				Usage: "Write each category's errors to <category>_<output>, keeping the shared code in the output file",
+			},
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "Exit non-zero, without writing, if the generated files are missing or out of date",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Regenerate whenever the definitions change, until interrupted",
+			},
 			&cli.StringFlag{
 				Name:    "package",
 				Aliases: []string{"p"},
@@ -43,11 +90,36 @@ func main() {
 			opts := []errorgen.GeneratorOption{
 				errorgen.WithInputFile(cmd.String("input")),
 				errorgen.WithOutputFile(cmd.String("output")),
+				errorgen.WithLanguage(cmd.String("lang")),
 			}
 
 			if t := cmd.String("template"); t != "" {
 				opts = append(opts, errorgen.WithTemplateFile(t))
 			}
+			if d := cmd.String("docs"); d != "" {
+				opts = append(opts, errorgen.WithDocsFile(d))
+			}
+			if o := cmd.String("openapi"); o != "" {
+				opts = append(opts, errorgen.WithOpenAPIFile(o))
+			}
+			if cmd.Bool("auto-prefix") {
+				opts = append(opts, errorgen.WithAutoPrefix())
+			}
+			if cmd.Bool("catalog-handler") {
+				opts = append(opts, errorgen.WithCatalogHandler())
+			}
+			if cmd.Bool("stack-traces") {
+				opts = append(opts, errorgen.WithStackTraces())
+			}
+			if cmd.Bool("httputil") {
+				opts = append(opts, errorgen.WithHTTPUtil())
+			}
+			if cmd.Bool("tests") {
+				opts = append(opts, errorgen.WithTests())
+			}
+			if cmd.Bool("split-by-category") {
+				opts = append(opts, errorgen.WithSplitByCategory())
+			}
 			if p := cmd.String("package"); p != "" {
 				opts = append(opts, errorgen.WithPackageName(p))
 			}
@@ -57,6 +129,27 @@ func main() {
 				return fmt.Errorf("failed to create generator: %w", err)
 			}
 
+			if cmd.Bool("check") {
+				stale, err := generator.Check()
+				if err != nil {
+					return fmt.Errorf("failed to generate code: %w", err)
+				}
+				for _, path := range stale {
+					fmt.Fprintf(os.Stderr, "%s is out of date\n", path)
+				}
+				if len(stale) > 0 {
+					return cli.Exit(fmt.Sprintf("%d generated file(s) out of date; run error-gen", len(stale)), 1)
+				}
+				fmt.Println("✓ Generated files are up to date")
+				return nil
+			}
+
+			if cmd.Bool("watch") {
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return generator.Watch(ctx, os.Stdout)
+			}
+
 			if err := generator.Generate(); err != nil {
 				return fmt.Errorf("failed to generate code: %w", err)
 			}
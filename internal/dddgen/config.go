@@ -1,21 +1,340 @@
 package dddgen
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Config holds the configuration for domain generation
 type Config struct {
-	DomainName     string
-	OutputDir      string
-	ModulePath     string // The Go module path (e.g., "github.com/user/project" or "ibnb")
-	WithTests      bool
-	WithMessaging  bool
-	WithRiver      bool
-	WithCQRS       bool
-	WithWorkflows  bool
-	WithDecorators bool
+	DomainName           string
+	OutputDir            string
+	ModulePath           string     // The Go module path; read from the nearest go.mod when empty
+	Fields               []Field    // Entity fields; DefaultFields when empty
+	Children             []PartSpec // Child entities owned by the aggregate root
+	ValueObjects         []PartSpec // Value objects of the domain
+	WithTests            bool
+	WithIntegrationTests bool // Generate testcontainers-based tests behind the integration build tag; needs WithTests
+	WithMessaging        bool
+	WithRiver            bool
+	Jobs                 []JobSpec // River jobs of the domain; the generic placeholder jobs when empty. Needs WithRiver
+	WithCQRS             bool
+	WithWorkflows        bool
+	WithDecorators       bool
+	WithMocks            bool             // Generate mocks/ for the Repository and Service interfaces; implied by WithTests
+	WithOutbox           bool             // Record events in a transactional outbox and generate its relay worker
+	WithEventSourcing    bool             // Event-sourced aggregate and event store instead of the CRUD repository
+	WithGraphQL          bool             // Generate a GraphQL schema fragment and resolvers
+	WithIdempotency      bool             // Deduplicate create requests by their Idempotency-Key header
+	WithAuditFields      bool             // Soft-delete with deleted_at/deleted_by columns and a Restore operation
+	WithTenancy          bool             // Scope every query to the tenant carried by the request context
+	WithAuthz            bool             // Check SpiceDB permissions before every service method
+	AuthzPackage         string           // Import path of the authz-codegen output; <module>/internal/authz when empty
+	WithErrorgen         bool             // Generate the standard domain errors from errors.cue with pkg/errorgen
+	WithCache            bool             // Cache Get results in Redis through a service decorator
+	CacheTTL             string           // Go duration cached entities are kept for; 5m when empty
+	DI                   DIMode           // Dependency injection wiring to generate; none when empty
+	DevEnv               DevEnv           // Task runner file and docker-compose.yaml to write at the module root; none when empty
+	TemplatesDir         string           // Directory whose templates override the embedded ones by path
+	HTTPRouter           HTTPRouter       // Framework targeted by the HTTP adapter; RouterHuma when empty
+	MessagingBackend     MessagingBackend // Watermill transport of the messaging adapter; BackendNATS when empty
+	WorkflowEngine       WorkflowEngine   // What WithWorkflows generates; EngineTemporal when empty
+	DryRun               bool             // Print a diff against existing files instead of writing
+	Regenerate           bool             // Allow generating into an existing domain directory
+	AddOnly              bool             // Only write files the existing domain does not have yet
+	OnConflict           ConflictStrategy // How to treat manually edited files on regeneration
+}
+
+// ConflictStrategy decides what happens to a file that was edited by hand
+// since it was last generated.
+type ConflictStrategy string
+
+const (
+	ConflictSkip      ConflictStrategy = "skip"      // Keep the edited file (default)
+	ConflictOverwrite ConflictStrategy = "overwrite" // Replace it with the generated file
+	ConflictMerge     ConflictStrategy = "merge"     // Three-way merge against the last generated version
+	ConflictPrompt    ConflictStrategy = "prompt"    // Ask for each file
+)
+
+// ParseConflictStrategy validates a strategy name; empty means ConflictSkip.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch ConflictStrategy(s) {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictMerge, ConflictPrompt:
+		return ConflictStrategy(s), nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q (use skip, overwrite, merge, or prompt)", s)
+}
+
+// HTTPRouter selects the framework the generated HTTP adapter is written for.
+type HTTPRouter string
+
+const (
+	RouterHuma    HTTPRouter = "huma"     // Huma operations with generated OpenAPI (default)
+	RouterChi     HTTPRouter = "chi"      // go-chi/chi/v5
+	RouterEcho    HTTPRouter = "echo"     // labstack/echo/v4
+	RouterGin     HTTPRouter = "gin"      // gin-gonic/gin
+	RouterNetHTTP HTTPRouter = "net-http" // Standard library http.ServeMux with method patterns
+)
+
+// ParseHTTPRouter validates a router name; empty means RouterHuma.
+func ParseHTTPRouter(s string) (HTTPRouter, error) {
+	switch HTTPRouter(s) {
+	case "":
+		return RouterHuma, nil
+	case RouterHuma, RouterChi, RouterEcho, RouterGin, RouterNetHTTP:
+		return HTTPRouter(s), nil
+	}
+	return "", fmt.Errorf("unknown HTTP router %q (use huma, chi, echo, gin, or net-http)", s)
+}
+
+// MessagingBackend selects the Watermill transport used by the generated
+// messaging adapter.
+type MessagingBackend string
+
+const (
+	BackendNATS  MessagingBackend = "nats"  // NATS JetStream via watermill-nats (default)
+	BackendKafka MessagingBackend = "kafka" // Kafka via watermill-kafka
+	BackendAMQP  MessagingBackend = "amqp"  // RabbitMQ via watermill-amqp
+)
+
+// ParseMessagingBackend validates a backend name; empty means BackendNATS.
+func ParseMessagingBackend(s string) (MessagingBackend, error) {
+	switch MessagingBackend(s) {
+	case "":
+		return BackendNATS, nil
+	case BackendNATS, BackendKafka, BackendAMQP:
+		return MessagingBackend(s), nil
+	}
+	return "", fmt.Errorf("unknown messaging backend %q (use nats, kafka, or amqp)", s)
+}
+
+// WorkflowEngine selects what the workflows option generates.
+type WorkflowEngine string
+
+const (
+	EngineTemporal WorkflowEngine = "temporal" // Temporal workflows and activities (default)
+	EngineSaga     WorkflowEngine = "saga"     // Watermill process manager driven by the CQRS events
+)
+
+// ParseWorkflowEngine validates an engine name; empty means EngineTemporal.
+func ParseWorkflowEngine(s string) (WorkflowEngine, error) {
+	switch WorkflowEngine(s) {
+	case "":
+		return EngineTemporal, nil
+	case EngineTemporal, EngineSaga:
+		return WorkflowEngine(s), nil
+	}
+	return "", fmt.Errorf("unknown workflow engine %q (use temporal or saga)", s)
+}
+
+// DIMode selects how the generated wiring package assembles the domain.
+type DIMode string
+
+const (
+	DIManual DIMode = "manual" // Plain constructor calls in wiring.New
+	DIWire   DIMode = "wire"   // google/wire provider set and injector
+	DIFx     DIMode = "fx"     // uber-go/fx module
+)
+
+// ParseDIMode validates a DI mode; empty means no wiring is generated.
+func ParseDIMode(s string) (DIMode, error) {
+	switch DIMode(s) {
+	case "", DIManual, DIWire, DIFx:
+		return DIMode(s), nil
+	}
+	return "", fmt.Errorf("unknown DI mode %q (use wire, fx, or manual)", s)
+}
+
+// DevEnv selects the task runner of the local development files written at
+// the module root.
+type DevEnv string
+
+const (
+	DevEnvTaskfile DevEnv = "taskfile" // Taskfile.yml for go-task
+	DevEnvMake     DevEnv = "make"     // Makefile
+)
+
+// ParseDevEnv validates a task runner; empty means no development files are
+// written.
+func ParseDevEnv(s string) (DevEnv, error) {
+	switch DevEnv(s) {
+	case "", DevEnvTaskfile, DevEnvMake:
+		return DevEnv(s), nil
+	}
+	return "", fmt.Errorf("unknown dev env %q (use taskfile or make)", s)
 }
 
 // TemplateData holds data passed to templates
 type TemplateData struct {
-	DomainTitle string // Capitalized for type names
-	DomainLower string // Lowercase for package/file names
-	ModulePath  string // The Go module path for imports
+	DomainTitle       string           // Capitalized for type names
+	DomainLower       string           // Lowercase for package/file names
+	DomainPlural      string           // Plural snake_case ("order_items") for table names, kebab-cased in routes
+	DomainSnake       string           // snake_case ("order_item")
+	DomainKebab       string           // kebab-case ("order-item")
+	DomainCamel       string           // camelCase for variables and JSON names ("orderItem")
+	ModulePath        string           // The Go module path
+	ImportPath        string           // Import path of the generated domain package
+	Fields            []FieldData      // Entity fields resolved from the domain spec
+	FieldImports      []string         // Extra imports needed by field types (uuid, decimal)
+	HTTPRouter        HTTPRouter       // Framework targeted by the HTTP adapter
+	MessagingBackend  MessagingBackend // Transport of the messaging adapter
+	WithMessaging     bool             // Messaging adapter is generated
+	WithGraphQL       bool             // GraphQL resolver is generated
+	WithIdempotency   bool             // Create requests are deduplicated by idempotency key
+	WithAuditFields   bool             // Deletes are soft and record who deleted the entity
+	WithTenancy       bool             // Entities belong to a tenant and queries are scoped to it
+	AuthzImportPath   string           // Import path of the authz-codegen output used by the permission checker
+	WithErrorgen      bool             // Standard errors come from errors_gen.go instead of errors.go
+	CacheTTL          string           // Go expression of the default cache TTL, e.g. "5 * time.Minute"
+	WithOutbox        bool             // Repository writes events to the outbox in its transactions
+	WithEventSourcing bool             // Aggregate is rebuilt from events kept in an event store
+	Jobs              []JobData        // River jobs of the domain
+	Children          []PartData       // Child entities owned by the aggregate root
+	ValueObjects      []PartData       // Value objects, one file each
+}
+
+// HasField reports whether the entity declares a field with the given Go name.
+func (d TemplateData) HasField(name string) bool {
+	return d.Field(name) != nil
+}
+
+// Field returns the entity field with the given Go name, or nil.
+func (d TemplateData) Field(name string) *FieldData {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
+
+// RequiredFields returns the fields marked as required.
+func (d TemplateData) RequiredFields() []FieldData {
+	var required []FieldData
+	for _, f := range d.Fields {
+		if f.Required {
+			required = append(required, f)
+		}
+	}
+	return required
+}
+
+// UsesTime reports whether any entity field is a time.Time.
+func (d TemplateData) UsesTime() bool {
+	for _, f := range d.Fields {
+		if f.BaseType == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPeriodicJobs reports whether some River job runs on an interval.
+func (d TemplateData) HasPeriodicJobs() bool {
+	for _, j := range d.Jobs {
+		if j.Every != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphQLScalars returns the custom scalars used by the GraphQL schema
+// fragment. Time is always needed for the audit timestamps.
+func (d TemplateData) GraphQLScalars() []string {
+	scalars := []string{"Time"}
+	seen := map[string]bool{"Time": true}
+	for _, f := range d.Fields {
+		t := strings.TrimSuffix(f.GraphQLType(), "!")
+		if (t == "UUID" || t == "Decimal") && !seen[t] {
+			seen[t] = true
+			scalars = append(scalars, t)
+		}
+	}
+	return scalars
+}
+
+// FilterFields returns the fields list operations filter on. Active is left
+// out because ListFilters always has an Active filter.
+func (d TemplateData) FilterFields() []FieldData {
+	var filters []FieldData
+	for _, f := range d.Fields {
+		if f.Filter && f.Name != "Active" {
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+// SortFields returns the fields list operations can be sorted by, besides
+// id, created_at, and updated_at.
+func (d TemplateData) SortFields() []FieldData {
+	var sorts []FieldData
+	for _, f := range d.Fields {
+		if f.Sort {
+			sorts = append(sorts, f)
+		}
+	}
+	return sorts
+}
+
+// SortKeys returns the accepted sort_by values of list operations.
+func (d TemplateData) SortKeys() []string {
+	keys := []string{"id", "created_at", "updated_at"}
+	for _, f := range d.SortFields() {
+		keys = append(keys, f.JSON)
+	}
+	return keys
+}
+
+// WithTableMigration reports whether migrations/<domain>.sql, the table of
+// the Postgres repository, is generated. Without audit fields or tenancy the
+// table is left to the application's own migrations.
+func (d TemplateData) WithTableMigration() bool {
+	return d.WithAuditFields || d.WithTenancy
+}
+
+// ColumnList returns the comma-separated database columns of the entity fields.
+func (d TemplateData) ColumnList() string {
+	cols := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		cols[i] = f.Column
+	}
+	return strings.Join(cols, ", ")
+}
+
+// Placeholders returns positional parameters for the entity fields starting
+// at $start (e.g. "$1, $2, $3").
+func (d TemplateData) Placeholders(start int) string {
+	params := make([]string, len(d.Fields))
+	for i := range d.Fields {
+		params[i] = fmt.Sprintf("$%d", start+i)
+	}
+	return strings.Join(params, ", ")
+}
+
+// SetClause returns an UPDATE SET list for the entity fields starting at
+// $start (e.g. "name = $1, active = $2").
+func (d TemplateData) SetClause(start int) string {
+	sets := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		sets[i] = fmt.Sprintf("%s = $%d", f.Column, start+i)
+	}
+	return strings.Join(sets, ", ")
+}
+
+// SearchCondition returns an ILIKE condition over the string columns for use
+// with fmt.Sprintf and a single argument index, e.g.
+// "name ILIKE $%[1]d OR description ILIKE $%[1]d". Empty when there are no
+// string fields.
+func (d TemplateData) SearchCondition() string {
+	var conds []string
+	for _, f := range d.Fields {
+		if f.IsString() {
+			conds = append(conds, f.Column+" ILIKE $%[1]d")
+		}
+	}
+	return strings.Join(conds, " OR ")
 }
@@ -0,0 +1,44 @@
+package dddgen
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// unifiedDiff returns a unified diff turning old into new for path. Missing
+// files (exists == false) are diffed against /dev/null. The result is empty
+// when the contents are identical.
+func unifiedDiff(path string, old []byte, exists bool, new []byte) (string, error) {
+	if exists && string(old) == string(new) {
+		return "", nil
+	}
+
+	label := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	from := "a/" + label
+	if !exists {
+		from = "/dev/null"
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(old)),
+		B:        splitLines(string(new)),
+		FromFile: from,
+		ToFile:   "b/" + label,
+		Context:  3,
+	})
+}
+
+// splitLines splits s into newline-terminated lines. Unlike
+// difflib.SplitLines it does not add an empty line for a trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
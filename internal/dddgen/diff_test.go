@@ -0,0 +1,26 @@
+package dddgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := unifiedDiff("x.go", []byte("a\nb\n"), true, []byte("a\nb\n"))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = unifiedDiff("x.go", []byte("a\nb\n"), true, []byte("a\nc\n"))
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- a/x.go")
+	assert.Contains(t, diff, "+++ b/x.go")
+	assert.Contains(t, diff, "-b\n")
+	assert.Contains(t, diff, "+c\n")
+
+	diff, err = unifiedDiff("x.go", nil, false, []byte("a\n"))
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- /dev/null")
+	assert.Contains(t, diff, "+a\n")
+}
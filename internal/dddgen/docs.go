@@ -0,0 +1,54 @@
+package dddgen
+
+import "github.com/ianmuhia/kit/pkg/codegen"
+
+// docsData is passed to the domain README template. Unlike the code
+// templates it describes every generated component, so the README and its
+// diagram show exactly what exists in the domain.
+type docsData struct {
+	TemplateData
+	WithCQRS       bool
+	WithRiver      bool
+	WithCache      bool
+	WithAuthz      bool
+	WorkflowEngine WorkflowEngine // Empty without --with-workflows
+	DI             DIMode
+	Events         []docsEvent
+	Commands       []string // CQRS commands handled by the domain, e.g. "CreateOrderCommand"
+}
+
+// docsEvent describes a domain event published by the service.
+type docsEvent struct {
+	Const string // Go constant of the event type, e.g. "EventOrderCreated"
+	Type  string // Event type and messaging topic, e.g. "order-created"
+	Go    string // Go type of the payload, e.g. "OrderCreatedEvent"
+}
+
+// docsData collects what the README describes. The events and commands
+// mirror templates/domain/events.go.tmpl and templates/cqrs/wiring.go.tmpl.
+func (g *Generator) docsData() docsData {
+	d := docsData{
+		TemplateData: g.data,
+		WithCQRS:     g.config.WithCQRS,
+		WithRiver:    g.config.WithRiver,
+		WithCache:    g.config.WithCache,
+		WithAuthz:    g.config.WithAuthz,
+		DI:           g.config.DI,
+	}
+	if g.config.WithWorkflows {
+		d.WorkflowEngine = g.config.WorkflowEngine
+	}
+	for _, action := range []string{"Created", "Updated", "Deleted"} {
+		d.Events = append(d.Events, docsEvent{
+			Const: "Event" + g.data.DomainTitle + action,
+			Type:  g.data.DomainLower + "-" + codegen.ToKebabCase(action),
+			Go:    g.data.DomainTitle + action + "Event",
+		})
+	}
+	if g.config.WithCQRS {
+		for _, action := range []string{"Create", "Update", "Delete"} {
+			d.Commands = append(d.Commands, action+g.data.DomainTitle+"Command")
+		}
+	}
+	return d
+}
@@ -0,0 +1,150 @@
+package dddgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DomainInfo describes a generated domain found through its manifest.
+type DomainInfo struct {
+	Name     string   // Domain directory name
+	Path     string   // Domain directory
+	Files    []string // Generated files recorded in the manifest, domain-relative
+	Modified []string // Generated files edited since the last generation
+	Missing  []string // Generated files that no longer exist
+}
+
+// ListDomains returns the generated domains directly under dir, sorted by
+// name. A domain is a directory holding a manifest.
+func ListDomains(dir string) ([]DomainInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var domains []DomainInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		domainDir := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(domainDir, ManifestFile)); err != nil {
+			continue
+		}
+		info, err := InspectDomain(domainDir)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *info)
+	}
+	return domains, nil
+}
+
+// InspectDomain compares the files of a generated domain with its manifest.
+func InspectDomain(domainDir string) (*DomainInfo, error) {
+	if _, err := os.Stat(filepath.Join(domainDir, ManifestFile)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s is not a generated domain (no %s)", domainDir, ManifestFile)
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	manifest, err := loadManifest(domainDir)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DomainInfo{Name: filepath.Base(domainDir), Path: domainDir}
+	for rel := range manifest.Files {
+		info.Files = append(info.Files, rel)
+		current, err := os.ReadFile(filepath.Join(domainDir, filepath.FromSlash(rel)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			info.Missing = append(info.Missing, rel)
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		case manifest.modified(rel, current):
+			info.Modified = append(info.Modified, rel)
+		}
+	}
+	sort.Strings(info.Files)
+	sort.Strings(info.Modified)
+	sort.Strings(info.Missing)
+	return info, nil
+}
+
+// RemoveResult reports what RemoveDomain did.
+type RemoveResult struct {
+	Removed []string // Generated files that were deleted
+	Kept    []string // Generated files left in place because they were modified
+}
+
+// RemoveDomain deletes the generated files of the domain in domainDir.
+// Files edited since the last generation are kept, as is anything the
+// generator did not write. Directories left empty are removed; the manifest
+// is removed with the last generated file and otherwise rewritten to list
+// the kept files.
+func RemoveDomain(domainDir string) (*RemoveResult, error) {
+	info, err := InspectDomain(domainDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadManifest(domainDir)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := make(map[string]bool, len(info.Modified))
+	for _, rel := range info.Modified {
+		modified[rel] = true
+	}
+
+	result := &RemoveResult{}
+	dirs := make(map[string]bool)
+	for _, rel := range info.Files {
+		if modified[rel] {
+			result.Kept = append(result.Kept, rel)
+			continue
+		}
+		path := filepath.Join(domainDir, filepath.FromSlash(rel))
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		delete(manifest.Files, rel)
+		if err == nil {
+			result.Removed = append(result.Removed, rel)
+		}
+		for dir := filepath.Dir(path); len(dir) > len(domainDir); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	if len(manifest.Files) > 0 {
+		if err := manifest.save(domainDir); err != nil {
+			return result, err
+		}
+	} else if err := os.Remove(filepath.Join(domainDir, ManifestFile)); err != nil {
+		return result, fmt.Errorf("failed to remove manifest: %w", err)
+	}
+
+	// Deepest directories first so parents can become empty
+	ordered := make([]string, 0, len(dirs)+1)
+	for dir := range dirs {
+		ordered = append(ordered, dir)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	for _, dir := range append(ordered, domainDir) {
+		removeIfEmpty(dir)
+	}
+	return result, nil
+}
+
+// removeIfEmpty removes dir when it has no entries left.
+func removeIfEmpty(dir string) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+		_ = os.Remove(dir)
+	}
+}
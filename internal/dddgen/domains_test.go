@@ -0,0 +1,79 @@
+package dddgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateDomain(t *testing.T, dir, name string, cfg Config) {
+	t.Helper()
+	cfg.DomainName, cfg.ModulePath, cfg.OutputDir = name, "github.com/x/y", dir
+	g, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+}
+
+func TestListDomains(t *testing.T) {
+	dir := t.TempDir()
+	generateDomain(t, dir, "order", Config{})
+	generateDomain(t, dir, "invoice", Config{WithCQRS: true})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shared"), 0o755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order", "errors.go"), []byte("package order\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "order", "events.go")))
+
+	domains, err := ListDomains(dir)
+	require.NoError(t, err)
+	require.Len(t, domains, 2)
+	assert.Equal(t, "invoice", domains[0].Name)
+	assert.Contains(t, domains[0].Files, "cqrs/wiring.go")
+	assert.Empty(t, domains[0].Modified)
+
+	assert.Equal(t, "order", domains[1].Name)
+	assert.Equal(t, []string{"errors.go"}, domains[1].Modified)
+	assert.Equal(t, []string{"events.go"}, domains[1].Missing)
+}
+
+func TestRemoveDomain(t *testing.T) {
+	dir := t.TempDir()
+	generateDomain(t, dir, "order", Config{})
+	domainDir := filepath.Join(dir, "order")
+
+	result, err := RemoveDomain(domainDir)
+	require.NoError(t, err)
+	assert.Contains(t, result.Removed, "adapters/order_postgres.go")
+	assert.Empty(t, result.Kept)
+	assert.NoDirExists(t, domainDir)
+}
+
+func TestRemoveDomain_keepsModifiedFiles(t *testing.T) {
+	dir := t.TempDir()
+	generateDomain(t, dir, "order", Config{})
+	domainDir := filepath.Join(dir, "order")
+
+	edited := filepath.Join(domainDir, "app", "service.go")
+	require.NoError(t, os.WriteFile(edited, []byte("package app\n\n// edited\n"), 0o644))
+	handwritten := filepath.Join(domainDir, "adapters", "custom.go")
+	require.NoError(t, os.WriteFile(handwritten, []byte("package adapters\n"), 0o644))
+
+	result, err := RemoveDomain(domainDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/service.go"}, result.Kept)
+	assert.FileExists(t, edited)
+	assert.FileExists(t, handwritten)
+	assert.NoFileExists(t, filepath.Join(domainDir, "order.go"))
+
+	// The manifest now only tracks the kept file
+	info, err := InspectDomain(domainDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/service.go"}, info.Files)
+}
+
+func TestRemoveDomain_notGenerated(t *testing.T) {
+	_, err := RemoveDomain(t.TempDir())
+	assert.ErrorContains(t, err, "is not a generated domain")
+}
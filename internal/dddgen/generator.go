@@ -1,60 +1,212 @@
 package dddgen
 
 import (
+	"bufio"
+	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"go/token"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
+	"golang.org/x/tools/imports"
+
 	"github.com/ianmuhia/kit/pkg/codegen"
+	"github.com/ianmuhia/kit/pkg/errorgen"
 )
 
 //go:embed templates/**/*.tmpl
 var Templates embed.FS
 
+// ErrDomainExists is returned by New when the output directory already
+// contains a domain with the requested name.
+var ErrDomainExists = errors.New("domain already exists")
+
+// ErrDomainNotFound is returned by New in AddOnly mode when the output
+// directory has no domain with the requested name.
+var ErrDomainNotFound = errors.New("domain not found")
+
 // Generator handles DDD domain generation
 type Generator struct {
 	config Config
 	data   TemplateData
 	logger *slog.Logger
+	out    io.Writer     // Destination of the dry-run diff, conflict prompts, and next steps
+	in     *bufio.Reader // Source of answers to conflict prompts
+	report *Report       // Set by Generate
 }
 
 // New creates a new Generator instance. Returns an error if the domain name
 // is not a valid Go identifier or if the output directory already contains
-// a domain with that name.
+// a domain with that name, unless Regenerate is set. Existing domains are also
+// allowed in dry-run mode so that the diff against them can be shown. In
+// AddOnly mode the domain must already exist.
 func New(cfg Config) (*Generator, error) {
 	if err := validateDomainName(cfg.DomainName); err != nil {
 		return nil, err
 	}
 
-	modulePath := cfg.ModulePath
-	if modulePath == "" {
-		return nil, fmt.Errorf("module path is required (e.g. github.com/user/project)")
+	domainLower := strings.ToLower(cfg.DomainName)
+	modulePath, importPath, err := resolveImportPath(cfg.ModulePath, cfg.OutputDir, domainLower)
+	if err != nil {
+		return nil, err
 	}
 
-	domainLower := strings.ToLower(cfg.DomainName)
 	domainDir := filepath.Join(cfg.OutputDir, domainLower)
-	if _, err := os.Stat(domainDir); err == nil {
-		return nil, fmt.Errorf("domain %q already exists at %s; delete it first or choose a different name", domainLower, domainDir)
+	info, statErr := os.Stat(domainDir)
+	switch {
+	case statErr == nil && !info.IsDir():
+		return nil, fmt.Errorf("%w: %s is a file, not a domain directory", ErrDomainExists, domainDir)
+	case cfg.AddOnly && statErr != nil:
+		return nil, fmt.Errorf("%w: %q at %s; generate it before adding components", ErrDomainNotFound, domainLower, domainDir)
+	case statErr == nil && !cfg.DryRun && !cfg.Regenerate && !cfg.AddOnly:
+		return nil, fmt.Errorf("%w: %q at %s; run with --regenerate (and --on-conflict to choose how edited files are handled) to update it, ddd-gen add to add components to it, or choose a different name", ErrDomainExists, domainLower, domainDir)
+	}
+
+	specFields := cfg.Fields
+	if len(specFields) == 0 {
+		specFields = DefaultFields
+	}
+	fields, err := buildFieldData(specFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entity fields: %w", err)
+	}
+
+	children, valueObjects, err := buildParts(cfg.Children, cfg.ValueObjects)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate: %w", err)
+	}
+	for _, part := range append(children, valueObjects...) {
+		if part.Title == codegen.Capitalize(cfg.DomainName) {
+			return nil, fmt.Errorf("invalid aggregate: %s is the aggregate root and cannot also be a child or value object", part.Title)
+		}
+		if reservedPartFiles[part.Lower] {
+			return nil, fmt.Errorf("invalid aggregate: %s would overwrite the generated %s.go", part.Title, part.Lower)
+		}
+	}
+
+	if cfg.WithEventSourcing {
+		switch {
+		case cfg.WithOutbox:
+			return nil, fmt.Errorf("--with-outbox cannot be combined with --with-eventsourcing; the event store already records every event")
+		case len(children) > 0:
+			return nil, fmt.Errorf("child entities cannot be combined with --with-eventsourcing; changes to them would not be recorded as events")
+		case cfg.WithAuditFields:
+			return nil, fmt.Errorf("--with-audit-fields cannot be combined with --with-eventsourcing; the deleted event already marks deleted aggregates")
+		case cfg.WithTenancy:
+			return nil, fmt.Errorf("--with-tenancy cannot be combined with --with-eventsourcing; the event store is not scoped by tenant")
+		}
+	}
+
+	if cfg.OnConflict, err = ParseConflictStrategy(string(cfg.OnConflict)); err != nil {
+		return nil, err
+	}
+	if cfg.HTTPRouter, err = ParseHTTPRouter(string(cfg.HTTPRouter)); err != nil {
+		return nil, err
+	}
+	if cfg.WithIntegrationTests && !cfg.WithTests {
+		return nil, fmt.Errorf("--with-integration-tests requires --with-tests")
+	}
+	if cfg.MessagingBackend != "" && !cfg.WithMessaging {
+		return nil, fmt.Errorf("--messaging-backend requires --with-messaging")
+	}
+	if cfg.MessagingBackend, err = ParseMessagingBackend(string(cfg.MessagingBackend)); err != nil {
+		return nil, err
+	}
+	if len(cfg.Jobs) > 0 && !cfg.WithRiver {
+		return nil, fmt.Errorf("--jobs requires --with-river")
+	}
+	jobs, err := buildJobs(domainLower, cfg.Jobs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jobs: %w", err)
+	}
+	cacheTTL, err := cacheTTLExpr(cfg.CacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.WorkflowEngine != "" && !cfg.WithWorkflows {
+		return nil, fmt.Errorf("--workflow-engine requires --with-workflows")
+	}
+	if cfg.WorkflowEngine, err = ParseWorkflowEngine(string(cfg.WorkflowEngine)); err != nil {
+		return nil, err
+	}
+	if cfg.WithWorkflows && cfg.WorkflowEngine == EngineSaga && !cfg.WithCQRS {
+		return nil, fmt.Errorf("--workflow-engine saga requires --with-cqrs; the saga reacts to the CQRS events and sends commands")
+	}
+	if cfg.AuthzPackage != "" && !cfg.WithAuthz {
+		return nil, fmt.Errorf("--authz-package requires --with-authz")
+	}
+	if cfg.WithAuthz && cfg.AuthzPackage == "" {
+		cfg.AuthzPackage = modulePath + "/internal/authz"
+	}
+	if cfg.DI, err = ParseDIMode(string(cfg.DI)); err != nil {
+		return nil, err
+	}
+	if cfg.DevEnv, err = ParseDevEnv(string(cfg.DevEnv)); err != nil {
+		return nil, err
+	}
+	if cfg.TemplatesDir != "" {
+		info, err := os.Stat(cfg.TemplatesDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid templates directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid templates directory: %s is not a directory", cfg.TemplatesDir)
+		}
 	}
 
 	return &Generator{
 		config: cfg,
 		data: TemplateData{
-			DomainTitle: codegen.Capitalize(cfg.DomainName),
-			DomainLower: domainLower,
-			ModulePath:  modulePath,
+			DomainTitle:       codegen.Capitalize(cfg.DomainName),
+			DomainLower:       domainLower,
+			DomainPlural:      codegen.Pluralize(codegen.ToSnakeCase(cfg.DomainName)),
+			DomainSnake:       codegen.ToSnakeCase(cfg.DomainName),
+			DomainKebab:       codegen.ToKebabCase(cfg.DomainName),
+			DomainCamel:       codegen.ToCamelCase(cfg.DomainName),
+			ModulePath:        modulePath,
+			ImportPath:        importPath,
+			Fields:            fields,
+			FieldImports:      fieldImports(fields),
+			HTTPRouter:        cfg.HTTPRouter,
+			MessagingBackend:  cfg.MessagingBackend,
+			WithMessaging:     cfg.WithMessaging,
+			WithGraphQL:       cfg.WithGraphQL,
+			WithIdempotency:   cfg.WithIdempotency,
+			WithAuditFields:   cfg.WithAuditFields,
+			WithTenancy:       cfg.WithTenancy,
+			AuthzImportPath:   cfg.AuthzPackage,
+			WithErrorgen:      cfg.WithErrorgen,
+			CacheTTL:          cacheTTL,
+			WithOutbox:        cfg.WithOutbox,
+			WithEventSourcing: cfg.WithEventSourcing,
+			Jobs:              jobs,
+			Children:          children,
+			ValueObjects:      valueObjects,
 		},
 		logger: slog.Default(),
+		out:    os.Stdout,
+		in:     bufio.NewReader(os.Stdin),
 	}, nil
 }
 
+// reservedPartFiles are file names in the domain package that children and
+// value objects cannot use.
+var reservedPartFiles = map[string]bool{
+	"repository": true, "errors": true, "events": true, "validation": true, "tenant": true,
+}
+
 // validateDomainName ensures the name is a valid Go identifier (letters and digits,
-// starting with a letter).
+// starting with a letter) that can serve as the package name of the domain.
 func validateDomainName(name string) error {
 	if name == "" {
 		return fmt.Errorf("domain name is required")
@@ -67,17 +219,99 @@ func validateDomainName(name string) error {
 			return fmt.Errorf("domain name %q contains invalid character %q (only letters, digits, and underscores allowed)", name, r)
 		}
 	}
+
+	lower := strings.ToLower(name)
+	switch {
+	case token.IsKeyword(lower):
+		return fmt.Errorf("domain name %q is a Go keyword and cannot be a package name", name)
+	case stdlibPackages[lower]:
+		return fmt.Errorf("domain name %q is the name of a standard library package; the generated code could not import both", name)
+	}
+	// The entity is written to <domain>.go, which the go tool would treat as
+	// a test file or restrict to one platform for these suffixes
+	if i := strings.LastIndexByte(lower, '_'); i >= 0 {
+		if suffix := lower[i+1:]; suffix == "test" || knownOS[suffix] || knownArch[suffix] {
+			return fmt.Errorf("domain name %q ends in _%s, which makes %s.go a test or platform-specific file", name, suffix, lower)
+		}
+	}
 	return nil
 }
 
+// stdlibPackages are the names of the standard library packages that the
+// generated code, or the code wiring it up, commonly imports. A domain package
+// with one of these names would clash with the import. Rarely imported
+// packages with names that make sense for a domain (user, mail, image) are
+// left out.
+var stdlibPackages = map[string]bool{
+	"atomic": true, "base64": true, "big": true, "binary": true, "bufio": true, "bytes": true, "cmp": true,
+	"context": true, "crypto": true, "csv": true, "embed": true, "errors": true, "exec": true, "filepath": true,
+	"flag": true, "fmt": true, "fs": true, "hex": true, "http": true, "httptest": true, "httputil": true,
+	"io": true, "iter": true, "json": true, "log": true, "maps": true, "math": true, "net": true, "netip": true,
+	"os": true, "path": true, "rand": true, "reflect": true, "regexp": true, "runtime": true, "signal": true,
+	"slices": true, "slog": true, "sort": true, "sql": true, "strconv": true, "strings": true, "sync": true,
+	"syscall": true, "testing": true, "time": true, "unicode": true, "unsafe": true, "url": true, "utf8": true,
+	"xml": true,
+}
+
+// knownOS and knownArch are the GOOS and GOARCH values the go tool recognizes
+// as file name suffixes.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+		"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+		"plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "arm64": true, "arm64be": true, "armbe": true,
+		"loong64": true, "mips": true, "mips64": true, "mips64le": true, "mips64p32": true, "mips64p32le": true,
+		"mipsle": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true, "s390": true,
+		"s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// Report returns what the last call to Generate did, or nil before the first
+// call. It is filled in as far as Generate got when it fails.
+func (g *Generator) Report() *Report {
+	return g.report
+}
+
 // WithLogger sets a custom logger
 func (g *Generator) WithLogger(logger *slog.Logger) *Generator {
 	g.logger = logger
 	return g
 }
 
-// Generate creates the domain structure and files
+// WithOutput sets the writer that receives the dry-run diff and conflict
+// prompts (default os.Stdout)
+func (g *Generator) WithOutput(w io.Writer) *Generator {
+	g.out = w
+	return g
+}
+
+// WithInput sets the reader used to answer conflict prompts (default os.Stdin)
+func (g *Generator) WithInput(r io.Reader) *Generator {
+	g.in = bufio.NewReader(r)
+	return g
+}
+
+// Generate creates the domain structure and files. In dry-run mode nothing is
+// written; a unified diff against the files on disk is printed instead.
+// When the output directory is part of a module, the generated packages are
+// type-checked and compiler errors are returned.
 func (g *Generator) Generate() error {
+	start := time.Now()
+	g.report = &Report{
+		Domain: g.data.DomainLower,
+		Path:   filepath.Join(g.config.OutputDir, g.data.DomainLower),
+		DryRun: g.config.DryRun,
+		Files:  []FileReport{},
+	}
+	defer func() { g.report.DurationMS = time.Since(start).Milliseconds() }()
+
+	if g.config.DryRun {
+		return g.dryRun()
+	}
+
 	g.logger.Info("generating domain",
 		slog.String("domain", g.data.DomainTitle),
 		slog.String("output", g.config.OutputDir),
@@ -89,16 +323,56 @@ func (g *Generator) Generate() error {
 	}
 
 	// Generate files from templates
-	if err := g.generateFiles(); err != nil {
+	kept, err := g.generateFiles()
+	if err != nil {
 		return fmt.Errorf("failed to generate files: %w", err)
 	}
 
+	switch {
+	case !g.config.WithErrorgen:
+	case slices.Contains(kept, "errors.go"):
+		// errors.go still declares the errors errorgen would generate
+		g.logger.Warn("not running errorgen because errors.go was kept; use --regenerate to switch to errorgen")
+	default:
+		if err := g.generateErrors(); err != nil {
+			return fmt.Errorf("failed to generate errors: %w", err)
+		}
+	}
+
+	if g.config.DevEnv != "" {
+		if err := g.generateDevEnv(); err != nil {
+			return fmt.Errorf("failed to generate development files: %w", err)
+		}
+	}
+
+	// Files kept or merged with manual edits are not what the templates
+	// produce, so their errors would not point at a template bug
+	if g.allFilesGenerated() {
+		if err := g.verify(); err != nil {
+			return err
+		}
+	} else {
+		g.logger.Info("not type-checking the domain because some files were kept or merged")
+	}
+
 	// Print success message
 	g.printSuccess()
 
 	return nil
 }
 
+// allFilesGenerated reports whether every file of the current run now holds
+// the rendered template.
+func (g *Generator) allFilesGenerated() bool {
+	for _, f := range g.report.Files {
+		switch fileAction(f.Action) {
+		case fileSkipped, fileMerged, fileKept:
+			return false
+		}
+	}
+	return true
+}
+
 func (g *Generator) createDirectories() error {
 	basePath := filepath.Join(g.config.OutputDir, g.data.DomainLower)
 	dirs := []string{
@@ -108,7 +382,19 @@ func (g *Generator) createDirectories() error {
 	}
 
 	if g.config.WithCQRS {
-		dirs = append(dirs, filepath.Join(basePath, "cqrs"))
+		dirs = append(dirs, filepath.Join(basePath, "cqrs"), filepath.Join(basePath, "readmodel"))
+	}
+	if g.config.WithWorkflows && g.config.WorkflowEngine == EngineSaga {
+		dirs = append(dirs, filepath.Join(basePath, "saga"))
+	}
+	if g.withMocks() {
+		dirs = append(dirs, filepath.Join(basePath, "mocks"))
+	}
+	if g.config.DI != "" {
+		dirs = append(dirs, filepath.Join(basePath, "wiring"))
+	}
+	if g.config.WithOutbox || g.config.WithEventSourcing || g.config.WithCQRS || g.config.WithIdempotency || g.data.WithTableMigration() {
+		dirs = append(dirs, filepath.Join(basePath, "migrations"))
 	}
 
 	g.logger.Info("creating directories", slog.Int("count", len(dirs)))
@@ -122,87 +408,499 @@ func (g *Generator) createDirectories() error {
 	return nil
 }
 
-func (g *Generator) generateFiles() error {
+// generateFiles renders and writes every file of the domain. It returns the
+// domain-relative paths of the existing files kept in AddOnly mode.
+func (g *Generator) generateFiles() ([]string, error) {
 	files := g.getFileMapping()
+	domainDir := filepath.Join(g.config.OutputDir, g.data.DomainLower)
+
+	manifest, err := loadManifest(domainDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]string, 0, len(files))
+	for outputPath := range files {
+		outputs = append(outputs, outputPath)
+	}
+	sort.Strings(outputs)
 
 	g.logger.Info("generating files", slog.Int("count", len(files)))
-	for tmplPath, outputPath := range files {
-		if err := g.generateFile(tmplPath, outputPath); err != nil {
-			return fmt.Errorf("failed to generate %s: %w", outputPath, err)
+	var kept []string
+	for _, outputPath := range outputs {
+		file := files[outputPath]
+		rel, _ := filepath.Rel(domainDir, outputPath)
+		rel = filepath.ToSlash(rel)
+
+		action, err := g.generateFile(file, outputPath, rel, manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		g.record(outputPath, action)
+		if action == fileKept {
+			kept = append(kept, rel)
 		}
-		relPath, _ := filepath.Rel(g.config.OutputDir, outputPath)
 		g.logger.Debug("generated file",
-			slog.String("template", tmplPath),
-			slog.String("output", relPath),
+			slog.String("template", file.template),
+			slog.String("output", rel),
+			slog.String("action", string(action)),
 		)
 	}
 
+	// Components added to a domain are not all of its options
+	if !g.config.AddOnly {
+		manifest.Options = optionsFingerprint(g.config)
+	}
+	return kept, manifest.save(domainDir)
+}
+
+// optionsChanged reports whether the existing domain was last generated with
+// other options than g's, according to its manifest. Domains without a
+// recorded fingerprint count as changed.
+func (g *Generator) optionsChanged() (bool, error) {
+	manifest, err := loadManifest(filepath.Join(g.config.OutputDir, g.data.DomainLower))
+	if err != nil {
+		return false, err
+	}
+	return manifest.Options != optionsFingerprint(g.config), nil
+}
+
+// generateErrors runs errorgen on the domain's errors.cue and writes
+// errors_gen.go next to it. The file is regenerated on every run; edits
+// belong in errors.cue.
+func (g *Generator) generateErrors() error {
+	domainDir := filepath.Join(g.config.OutputDir, g.data.DomainLower)
+	outputPath := filepath.Join(domainDir, "errors_gen.go")
+
+	gen, err := errorgen.NewGenerator(
+		errorgen.WithInputFile(filepath.Join(domainDir, "errors.cue")),
+		errorgen.WithOutputFile(outputPath),
+		errorgen.WithPackageName("domain"),
+	)
+	if err != nil {
+		return err
+	}
+	if err := gen.Generate(); err != nil {
+		return err
+	}
+
+	// errorgen output is not gofmt'ed
+	src, err := os.ReadFile(outputPath)
+	if err != nil {
+		return err
+	}
+	formatted, err := formatGo(outputPath, "errors.cue", src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, formatted, 0644); err != nil {
+		return err
+	}
+	g.record(outputPath, fileWritten)
+
+	g.logger.Debug("generated file", slog.String("template", "errors.cue"), slog.String("output", "errors_gen.go"))
 	return nil
 }
 
-func (g *Generator) getFileMapping() map[string]string {
+// withMocks reports whether mocks are generated. The generated service tests
+// use them, so they are implied by WithTests.
+func (g *Generator) withMocks() bool {
+	return g.config.WithMocks || g.config.WithTests
+}
+
+// outputFile is a file the generator writes: the template it is rendered
+// from and the data passed to the template (g.data when nil).
+type outputFile struct {
+	template string
+	data     any
+}
+
+// getFileMapping returns the files to generate keyed by output path.
+func (g *Generator) getFileMapping() map[string]outputFile {
 	basePath := filepath.Join(g.config.OutputDir, g.data.DomainLower)
+	files := make(map[string]outputFile)
+	add := func(tmplPath string, elem ...string) {
+		files[filepath.Join(append([]string{basePath}, elem...)...)] = outputFile{template: tmplPath}
+	}
 
-	files := map[string]string{
-		"templates/domain/entity.go.tmpl":     filepath.Join(basePath, g.data.DomainLower+".go"),
-		"templates/domain/repository.go.tmpl": filepath.Join(basePath, "repository.go"),
-		"templates/domain/errors.go.tmpl":     filepath.Join(basePath, "errors.go"),
-		"templates/domain/events.go.tmpl":     filepath.Join(basePath, "events.go"),
-		"templates/domain/validation.go.tmpl": filepath.Join(basePath, "validation.go"),
-		"templates/app/service.go.tmpl":       filepath.Join(basePath, "app", "service.go"),
-		"templates/adapters/http.go.tmpl":     filepath.Join(basePath, "adapters", g.data.DomainLower+"_http.go"),
-		"templates/adapters/postgres.go.tmpl": filepath.Join(basePath, "adapters", g.data.DomainLower+"_postgres.go"),
+	add("templates/domain/entity.go.tmpl", g.data.DomainLower+".go")
+	add("templates/domain/repository.go.tmpl", "repository.go")
+	add("templates/domain/errors.go.tmpl", "errors.go")
+	add("templates/domain/errors.cue.tmpl", "errors.cue")
+	add("templates/domain/events.go.tmpl", "events.go")
+	add("templates/domain/validation.go.tmpl", "validation.go")
+	if g.config.WithTenancy {
+		add("templates/domain/tenant.go.tmpl", "tenant.go")
+	}
+	add("templates/app/service.go.tmpl", "app", "service.go")
+	if g.config.WithEventSourcing {
+		add("templates/domain/aggregate.go.tmpl", "aggregate.go")
+		add("templates/adapters/eventstore.go.tmpl", "adapters", g.data.DomainLower+"_eventstore.go")
+		add("templates/migrations/eventstore.sql.tmpl", "migrations", g.data.DomainLower+"_eventstore.sql")
+	} else {
+		add("templates/adapters/postgres.go.tmpl", "adapters", g.data.DomainLower+"_postgres.go")
+	}
+	add("templates/adapters/openapi.yaml.tmpl", "adapters", "openapi.yaml")
+	files[filepath.Join(basePath, "README.md")] = outputFile{template: "templates/docs/README.md.tmpl", data: g.docsData()}
+
+	// Huma has its own template; the other routers share one
+	if g.data.HTTPRouter == RouterHuma {
+		add("templates/adapters/http.go.tmpl", "adapters", g.data.DomainLower+"_http.go")
+	} else {
+		add("templates/adapters/http_router.go.tmpl", "adapters", g.data.DomainLower+"_http.go")
+	}
+
+	// One file per child entity and value object of the aggregate
+	for _, child := range g.data.Children {
+		files[filepath.Join(basePath, child.Lower+".go")] = outputFile{
+			template: "templates/domain/child.go.tmpl",
+			data:     partData{TemplateData: g.data, Part: child},
+		}
+	}
+	for _, vo := range g.data.ValueObjects {
+		files[filepath.Join(basePath, vo.Lower+".go")] = outputFile{
+			template: "templates/domain/value_object.go.tmpl",
+			data:     partData{TemplateData: g.data, Part: vo},
+		}
 	}
 
 	// Add optional files based on flags
 	if g.config.WithTests {
-		files["templates/app/service_test.go.tmpl"] = filepath.Join(basePath, "app", "service_test.go")
+		if g.config.WithEventSourcing {
+			add("templates/app/service_es_test.go.tmpl", "app", "service_test.go")
+		} else {
+			add("templates/app/service_test.go.tmpl", "app", "service_test.go")
+		}
+	}
+	if g.config.WithIntegrationTests {
+		// The event store has its own schema; only the CRUD repository is covered
+		if !g.config.WithEventSourcing {
+			add("templates/adapters/postgres_integration_test.go.tmpl", "adapters", g.data.DomainLower+"_postgres_integration_test.go")
+		}
+		if g.config.WithMessaging && g.config.MessagingBackend == BackendNATS {
+			add("templates/adapters/messaging_integration_test.go.tmpl", "adapters", g.data.DomainLower+"_messaging_integration_test.go")
+		}
+	}
+	if g.withMocks() {
+		if g.config.WithEventSourcing {
+			add("templates/mocks/repository_es.go.tmpl", "mocks", "repository.go")
+		} else {
+			add("templates/mocks/repository.go.tmpl", "mocks", "repository.go")
+		}
+		add("templates/mocks/service.go.tmpl", "mocks", "service.go")
+	}
+	if g.config.WithGraphQL {
+		add("templates/adapters/graphql.graphqls.tmpl", "adapters", g.data.DomainLower+".graphqls")
+		add("templates/adapters/graphql.go.tmpl", "adapters", g.data.DomainLower+"_graphql.go")
 	}
 	if g.config.WithMessaging {
-		files["templates/adapters/messaging.go.tmpl"] = filepath.Join(basePath, "adapters", g.data.DomainLower+"_messaging.go")
+		add("templates/adapters/messaging.go.tmpl", "adapters", g.data.DomainLower+"_messaging.go")
+	}
+	if g.config.WithOutbox {
+		add("templates/adapters/outbox.go.tmpl", "adapters", g.data.DomainLower+"_outbox.go")
+		add("templates/migrations/outbox.sql.tmpl", "migrations", g.data.DomainLower+"_outbox.sql")
+	}
+	if g.data.WithTableMigration() {
+		add("templates/migrations/table.sql.tmpl", "migrations", g.data.DomainLower+".sql")
+	}
+	if g.config.WithIdempotency {
+		add("templates/app/idempotency.go.tmpl", "app", "idempotency.go")
+		add("templates/adapters/idempotency.go.tmpl", "adapters", g.data.DomainLower+"_idempotency.go")
+		add("templates/migrations/idempotency.sql.tmpl", "migrations", g.data.DomainLower+"_idempotency.sql")
+	}
+	if g.config.WithCache {
+		add("templates/app/cache.go.tmpl", "app", "cache.go")
+		add("templates/adapters/cache.go.tmpl", "adapters", g.data.DomainLower+"_cache.go")
+	}
+	if g.config.WithAuthz {
+		add("templates/app/authz.go.tmpl", "app", "authz.go")
+		add("templates/adapters/authz.go.tmpl", "adapters", g.data.DomainLower+"_authz.go")
 	}
 	if g.config.WithRiver {
-		files["templates/adapters/river.go.tmpl"] = filepath.Join(basePath, "adapters", g.data.DomainLower+"_river.go")
+		if len(g.data.Jobs) > 0 {
+			add("templates/adapters/river_jobs.go.tmpl", "adapters", g.data.DomainLower+"_river.go")
+			if g.data.HasPeriodicJobs() {
+				add("templates/adapters/river_periodic.go.tmpl", "adapters", g.data.DomainLower+"_river_periodic.go")
+			}
+		} else {
+			add("templates/adapters/river.go.tmpl", "adapters", g.data.DomainLower+"_river.go")
+		}
 	}
 	if g.config.WithCQRS {
-		files["templates/cqrs/commands.go.tmpl"] = filepath.Join(basePath, "cqrs", "commands.go")
-		files["templates/cqrs/command_handlers.go.tmpl"] = filepath.Join(basePath, "cqrs", "command_handlers.go")
-		files["templates/cqrs/events.go.tmpl"] = filepath.Join(basePath, "cqrs", "events.go")
-		files["templates/cqrs/event_handlers.go.tmpl"] = filepath.Join(basePath, "cqrs", "event_handlers.go")
-		files["templates/cqrs/wiring.go.tmpl"] = filepath.Join(basePath, "cqrs", "wiring.go")
+		add("templates/cqrs/commands.go.tmpl", "cqrs", "commands.go")
+		add("templates/cqrs/command_handlers.go.tmpl", "cqrs", "command_handlers.go")
+		add("templates/cqrs/events.go.tmpl", "cqrs", "events.go")
+		add("templates/cqrs/event_handlers.go.tmpl", "cqrs", "event_handlers.go")
+		add("templates/cqrs/wiring.go.tmpl", "cqrs", "wiring.go")
+		add("templates/readmodel/projection.go.tmpl", "readmodel", "projection.go")
+		add("templates/readmodel/query.go.tmpl", "readmodel", "query.go")
+		add("templates/migrations/readmodel.sql.tmpl", "migrations", g.data.DomainLower+"_readmodel.sql")
+	}
+	switch g.config.DI {
+	case DIManual:
+		add("templates/wiring/providers.go.tmpl", "wiring", "providers.go")
+		add("templates/wiring/manual.go.tmpl", "wiring", "wiring.go")
+	case DIWire:
+		add("templates/wiring/providers.go.tmpl", "wiring", "providers.go")
+		add("templates/wiring/wire.go.tmpl", "wiring", "wire.go")
+		add("templates/wiring/inject.go.tmpl", "wiring", "inject.go")
+	case DIFx:
+		add("templates/wiring/providers.go.tmpl", "wiring", "providers.go")
+		add("templates/wiring/fx.go.tmpl", "wiring", "module.go")
 	}
 	if g.config.WithWorkflows {
-		files["templates/adapters/temporal.go.tmpl"] = filepath.Join(basePath, "adapters", g.data.DomainLower+"_temporal.go")
+		switch g.config.WorkflowEngine {
+		case EngineSaga:
+			add("templates/saga/saga.go.tmpl", "saga", "saga.go")
+			add("templates/migrations/saga.sql.tmpl", "migrations", g.data.DomainLower+"_saga.sql")
+		default:
+			add("templates/adapters/temporal.go.tmpl", "adapters", g.data.DomainLower+"_temporal.go")
+		}
 	}
 
 	return files
 }
 
-func (g *Generator) generateFile(tmplPath, outputPath string) error {
-	// Read template from embedded FS
-	tmplContent, err := Templates.ReadFile(tmplPath)
+// dryRun renders every template in memory and writes a unified diff against
+// the existing files to g.out.
+func (g *Generator) dryRun() error {
+	files := g.getFileMapping()
+
+	outputs := make([]string, 0, len(files))
+	rendered := make(map[string][]byte, len(files))
+	for outputPath, file := range files {
+		content, err := g.renderFile(outputPath, file)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", outputPath, err)
+		}
+		outputs = append(outputs, outputPath)
+		rendered[outputPath] = content
+	}
+	sort.Strings(outputs)
+
+	changed := 0
+	for _, outputPath := range outputs {
+		existing, err := os.ReadFile(outputPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to read %s: %w", outputPath, err)
+		}
+		if err == nil && g.config.AddOnly {
+			continue
+		}
+		exists := err == nil
+		diff, err := unifiedDiff(outputPath, existing, exists, rendered[outputPath])
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", outputPath, err)
+		}
+		switch {
+		case diff == "":
+			g.record(outputPath, fileUnchanged)
+			continue
+		case exists:
+			g.record(outputPath, fileWritten)
+		default:
+			g.record(outputPath, fileCreated)
+		}
+		changed++
+		if _, err := io.WriteString(g.out, diff); err != nil {
+			return err
+		}
+	}
+
+	g.logger.Info("dry run complete",
+		slog.String("domain", g.data.DomainLower),
+		slog.Int("files", len(outputs)),
+		slog.Int("changed", changed),
+	)
+	return nil
+}
+
+// templateFuncs are available to every template.
+var templateFuncs = template.FuncMap{
+	"add":   func(a, b int) int { return a + b },
+	"upper": strings.ToUpper,
+	"kebab": codegen.ToKebabCase,
+}
+
+// fileAction describes what generateFile did with an output file.
+type fileAction string
+
+const (
+	fileCreated   fileAction = "created"
+	fileWritten   fileAction = "written"
+	fileUnchanged fileAction = "unchanged"
+	fileSkipped   fileAction = "skipped"
+	fileMerged    fileAction = "merged"
+	fileKept      fileAction = "kept" // Existing file left alone in AddOnly mode
+)
+
+// generateFile renders a template and writes it to outputPath. Files edited
+// by hand since the last generation (according to the manifest) are handled
+// by the configured ConflictStrategy. In AddOnly mode existing files are never
+// written. The manifest is updated with what was
+// generated; skipped files keep their previous entry so a later merge still
+// has the right base.
+func (g *Generator) generateFile(file outputFile, outputPath, rel string, manifest *Manifest) (fileAction, error) {
+	content, err := g.renderFile(outputPath, file)
 	if err != nil {
-		return fmt.Errorf("failed to read template %s: %w", tmplPath, err)
+		return "", err
 	}
 
-	// Parse template
-	tmpl, err := template.New(filepath.Base(tmplPath)).Parse(string(tmplContent))
+	action := fileWritten
+	current, err := os.ReadFile(outputPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		action = fileCreated
+	case err != nil:
+		return "", fmt.Errorf("failed to read existing file: %w", err)
+	case bytes.Equal(current, content):
+		manifest.Files[rel] = newManifestEntry(content)
+		return fileUnchanged, nil
+	case g.config.AddOnly:
+		g.logger.Warn("keeping existing file that the requested components would change; use --regenerate to update it",
+			slog.String("file", rel))
+		return fileKept, nil
+	case manifest.modified(rel, current):
+		return g.resolveConflict(outputPath, rel, current, content, manifest)
+	}
+
+	if err := os.WriteFile(outputPath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	manifest.Files[rel] = newManifestEntry(content)
+	return action, nil
+}
+
+// resolveConflict applies the conflict strategy to a manually edited file.
+func (g *Generator) resolveConflict(outputPath, rel string, current, content []byte, manifest *Manifest) (fileAction, error) {
+	strategy := g.config.OnConflict
+	if strategy == ConflictPrompt {
+		var err error
+		if strategy, err = g.prompt(rel); err != nil {
+			return "", err
+		}
+	}
+
+	switch strategy {
+	case ConflictOverwrite:
+		if err := os.WriteFile(outputPath, content, 0644); err != nil {
+			return "", fmt.Errorf("failed to write file: %w", err)
+		}
+		manifest.Files[rel] = newManifestEntry(content)
+		g.logger.Warn("overwrote manually edited file", slog.String("file", rel))
+		return fileWritten, nil
+
+	case ConflictMerge:
+		base, ok := manifest.Files[rel]
+		if !ok {
+			g.logger.Warn("no merge base recorded, keeping manually edited file", slog.String("file", rel))
+			return fileSkipped, nil
+		}
+		merged, conflicts := merge3(base.Content, string(current), string(content))
+		if err := os.WriteFile(outputPath, []byte(merged), 0644); err != nil {
+			return "", fmt.Errorf("failed to write file: %w", err)
+		}
+		manifest.Files[rel] = newManifestEntry(content)
+		if conflicts > 0 {
+			g.logger.Warn("merged with conflicts, resolve the conflict markers by hand",
+				slog.String("file", rel),
+				slog.Int("conflicts", conflicts),
+			)
+		} else {
+			g.logger.Info("merged manual edits with generated changes", slog.String("file", rel))
+		}
+		return fileMerged, nil
+
+	default:
+		g.logger.Warn("skipping manually edited file", slog.String("file", rel))
+		return fileSkipped, nil
+	}
+}
+
+// prompt asks which strategy to apply to a manually edited file.
+func (g *Generator) prompt(rel string) (ConflictStrategy, error) {
+	for {
+		fmt.Fprintf(g.out, "%s has local changes. [s]kip, [o]verwrite, [m]erge? ", rel)
+		answer, err := g.in.ReadString('\n')
+		if err != nil && answer == "" {
+			return "", fmt.Errorf("failed to read answer for %s: %w", rel, err)
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "s", "skip":
+			return ConflictSkip, nil
+		case "o", "overwrite":
+			return ConflictOverwrite, nil
+		case "m", "merge":
+			return ConflictMerge, nil
+		}
+	}
+}
+
+// readTemplate returns the template at path (e.g. "templates/app/service.go.tmpl").
+// A file at the same path below TemplatesDir, without the "templates/" prefix,
+// takes precedence over the embedded template.
+func (g *Generator) readTemplate(path string) ([]byte, error) {
+	if g.config.TemplatesDir != "" {
+		override := filepath.Join(g.config.TemplatesDir, filepath.FromSlash(strings.TrimPrefix(path, "templates/")))
+		content, err := os.ReadFile(override)
+		if err == nil {
+			g.logger.Debug("using template override", slog.String("template", path), slog.String("path", override))
+			return content, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read template override %s: %w", override, err)
+		}
+	}
+
+	content, err := Templates.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
 	}
+	return content, nil
+}
 
-	// Create output file
-	outFile, err := os.Create(outputPath)
+// renderFile executes a template against the file's data. Go
+// output is formatted and has unused imports removed, since which imports a
+// template needs depends on the enabled flags.
+func (g *Generator) renderFile(outputPath string, file outputFile) ([]byte, error) {
+	tmplContent, err := g.readTemplate(file.template)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, err
 	}
 
-	if err := tmpl.Execute(outFile, g.data); err != nil {
-		outFile.Close()
-		return fmt.Errorf("failed to execute template: %w", err)
+	// Parse template
+	tmpl, err := template.New(filepath.Base(file.template)).Funcs(templateFuncs).Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	return outFile.Close()
+	var data any = g.data
+	if file.data != nil {
+		data = file.data
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	if filepath.Ext(outputPath) != ".go" {
+		return buf.Bytes(), nil
+	}
+	return formatGo(outputPath, file.template, buf.Bytes())
+}
+
+// formatGo runs generated Go source through goimports. Output that does not
+// parse is a template bug, so it is reported rather than written.
+func formatGo(outputPath, tmplPath string, src []byte) ([]byte, error) {
+	formatted, err := imports.Process(outputPath, src, &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generated %s (from %s) is not valid Go: %w", filepath.Base(outputPath), tmplPath, err)
+	}
+	return formatted, nil
 }
 
 func (g *Generator) printSuccess() {
@@ -212,26 +910,83 @@ func (g *Generator) printSuccess() {
 		slog.String("domain", g.data.DomainLower),
 		slog.String("path", outputPath),
 		slog.Bool("with_tests", g.config.WithTests),
+		slog.Bool("with_integration_tests", g.config.WithIntegrationTests),
 		slog.Bool("with_cqrs", g.config.WithCQRS),
 		slog.Bool("with_messaging", g.config.WithMessaging),
+		slog.String("messaging_backend", string(g.config.MessagingBackend)),
 		slog.Bool("with_river", g.config.WithRiver),
 		slog.Bool("with_workflows", g.config.WithWorkflows),
+		slog.String("workflow_engine", string(g.config.WorkflowEngine)),
 		slog.Bool("with_decorators", g.config.WithDecorators),
+		slog.Bool("with_mocks", g.withMocks()),
+		slog.Bool("with_outbox", g.config.WithOutbox),
+		slog.Bool("with_eventsourcing", g.config.WithEventSourcing),
+		slog.Bool("with_graphql", g.config.WithGraphQL),
+		slog.Bool("with_idempotency", g.config.WithIdempotency),
+		slog.Bool("with_audit_fields", g.config.WithAuditFields),
+		slog.Bool("with_tenancy", g.config.WithTenancy),
+		slog.Bool("with_authz", g.config.WithAuthz),
+		slog.Bool("with_errorgen", g.config.WithErrorgen),
+		slog.Bool("with_cache", g.config.WithCache),
+		slog.String("di", string(g.config.DI)),
+		slog.String("dev_env", string(g.config.DevEnv)),
 	)
 
-	fmt.Printf("\n✓ SUCCESS: Generated domain '%s' in %s\n", g.data.DomainLower, outputPath)
-	fmt.Println("\nNext steps:")
-	fmt.Printf("  1. Review generated files in %s\n", outputPath)
-	fmt.Printf("  2. Customize domain entity in %s.go\n", g.data.DomainLower)
-	fmt.Println("  3. Add domain-specific repository methods")
-	fmt.Println("  4. Implement business logic in app/service.go")
-	fmt.Println("  5. Wire up HTTP routes in your application")
+	fmt.Fprintf(g.out, "\n✓ SUCCESS: Generated domain '%s' in %s\n", g.data.DomainLower, outputPath)
+	fmt.Fprintln(g.out, "\nNext steps:")
+	// Steps are numbered as printed, since which ones apply depends on the
+	// components; detail lines are indented under the step before them
+	n := 0
+	step := func(format string, args ...any) {
+		n++
+		fmt.Fprintf(g.out, "%3d. %s\n", n, fmt.Sprintf(format, args...))
+	}
+	detail := func(format string, args ...any) {
+		fmt.Fprintf(g.out, "     %s\n", fmt.Sprintf(format, args...))
+	}
+
+	step("Review generated files in %s", outputPath)
+	step("Customize domain entity in %s.go", g.data.DomainLower)
+	step("Add domain-specific repository methods")
+	step("Implement business logic in app/service.go")
+	step("Wire up HTTP routes in your application")
 
 	if g.config.WithCQRS {
-		fmt.Println("  6. Configure Watermill CQRS in cqrs/wiring.go")
+		step("Configure Watermill CQRS in cqrs/wiring.go")
+		detail("Apply migrations/%s_readmodel.sql and register readmodel.New%sProjection", g.data.DomainLower, g.data.DomainTitle)
 	}
 	if g.config.WithRiver {
-		fmt.Println("  7. Setup River client and run migrations")
+		step("Setup River client and run migrations")
+	}
+	if g.config.WithWorkflows && g.config.WorkflowEngine == EngineSaga {
+		step("Apply migrations/%s_saga.sql and register saga.New%sSaga with the CQRS setup", g.data.DomainLower, g.data.DomainTitle)
+	}
+	if g.config.WithOutbox {
+		step("Apply migrations/%s_outbox.sql and run the outbox relay", g.data.DomainLower)
+	}
+	if g.config.WithEventSourcing {
+		step("Apply migrations/%s_eventstore.sql", g.data.DomainLower)
+	}
+	if g.data.WithTableMigration() {
+		step("Apply migrations/%s.sql", g.data.DomainLower)
+	}
+	if g.config.WithTenancy {
+		detail("Put the tenant into the context of every call (TenantMiddleware or WithTenant)")
+	}
+	if g.config.WithAuthz {
+		step("Generate %s with authz-codegen and wrap the service with app.NewAuthorized%sService", g.config.AuthzPackage, g.data.DomainTitle)
+	}
+	if g.config.WithCache {
+		step("Wrap the service with app.NewCached%sService(service, adapters.New%sRedisCache(redisClient))", g.data.DomainTitle, g.data.DomainTitle)
+	}
+	if g.config.WithIdempotency {
+		step("Apply migrations/%s_idempotency.sql and wrap the service with app.NewIdempotent%sService", g.data.DomainLower, g.data.DomainTitle)
+	}
+	switch g.config.DevEnv {
+	case DevEnvTaskfile:
+		step("Start the local dependencies and apply the migrations with `task up migrate`")
+	case DevEnvMake:
+		step("Start the local dependencies and apply the migrations with `make up migrate`")
 	}
-	fmt.Println()
+	fmt.Fprintln(g.out)
 }
@@ -1,8 +1,15 @@
 package dddgen
 
 import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,12 +22,36 @@ func TestValidateDomainName(t *testing.T) {
 		assert.NoError(t, validateDomainName(n), "expected valid: %q", n)
 	}
 
-	invalid := []string{"", "1invalid", "has-hyphen", "has space", "has.dot"}
+	invalid := []string{"", "1invalid", "has-hyphen", "has space", "has.dot",
+		"type", "Func", "context", "time", "http", "order_test", "order_linux", "order_arm64"}
 	for _, n := range invalid {
 		assert.Error(t, validateDomainName(n), "expected invalid: %q", n)
 	}
 }
 
+func TestNew_reservedDomainName(t *testing.T) {
+	_, err := New(Config{DomainName: "errors", ModulePath: "github.com/x/y", OutputDir: t.TempDir()})
+	require.ErrorContains(t, err, "standard library package")
+
+	_, err = New(Config{DomainName: "range", ModulePath: "github.com/x/y", OutputDir: t.TempDir()})
+	require.ErrorContains(t, err, "Go keyword")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order"), nil, 0644))
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, Regenerate: true})
+	require.ErrorIs(t, err, ErrDomainExists)
+}
+
+func TestNew_existingDomain(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "order"), 0755))
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir})
+	require.ErrorIs(t, err, ErrDomainExists)
+	assert.ErrorContains(t, err, "--regenerate")
+	assert.ErrorContains(t, err, "--on-conflict")
+	assert.ErrorContains(t, err, "ddd-gen add")
+}
+
 func TestNew_missingModulePath(t *testing.T) {
 	_, err := New(Config{DomainName: "booking", OutputDir: t.TempDir()})
 	require.ErrorContains(t, err, "module path is required")
@@ -71,3 +102,1045 @@ func TestGenerate_createsFiles(t *testing.T) {
 		assert.FileExists(t, f)
 	}
 }
+
+func TestGenerate_customFields(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName: "task",
+		ModulePath: "github.com/x/y",
+		OutputDir:  dir,
+		Fields: []Field{
+			{Name: "title", Type: "string", Required: true, MaxLength: 200},
+			{Name: "due_at", Type: "time", Nullable: true},
+			{Name: "owner_id", Type: "uuid"},
+		},
+		WithCQRS: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entity, err := os.ReadFile(filepath.Join(dir, "task", "task.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(entity), "DueAt")
+	assert.Contains(t, string(entity), "*time.Time")
+	assert.Contains(t, string(entity), `"github.com/google/uuid"`)
+	assert.NotContains(t, string(entity), "Description")
+
+	repo, err := os.ReadFile(filepath.Join(dir, "task", "adapters", "task_postgres.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(repo), "title, due_at, owner_id")
+	assert.Contains(t, string(repo), "$1, $2, $3")
+
+	assertGeneratedGoParses(t, filepath.Join(dir, "task"))
+}
+
+func TestGenerate_allComponentsParse(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName:     "order",
+		ModulePath:     "github.com/x/y",
+		OutputDir:      dir,
+		WithTests:      true,
+		WithMessaging:  true,
+		WithRiver:      true,
+		WithCQRS:       true,
+		WithWorkflows:  true,
+		WithDecorators: true,
+		WithOutbox:     true,
+		WithGraphQL:    true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+}
+
+// assertGeneratedGoParses checks that every generated .go file under dir is
+// syntactically valid Go.
+func assertGeneratedGoParses(t *testing.T, dir string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		_, perr := parser.ParseFile(fset, path, nil, parser.AllErrors)
+		assert.NoError(t, perr, path)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestGenerate_dryRun(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir}
+	g, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entityPath := filepath.Join(dir, "order", "order.go")
+	original, err := os.ReadFile(entityPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(entityPath, append(original, []byte("// local edit\n")...), 0644))
+
+	cfg.DryRun = true
+	cfg.WithTests = true
+	g, err = New(cfg)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, g.WithOutput(&out).Generate())
+
+	diff := out.String()
+	assert.Contains(t, diff, "-// local edit")
+	assert.Contains(t, diff, "--- /dev/null\n+++ b/"+strings.TrimPrefix(filepath.ToSlash(filepath.Join(dir, "order", "app", "service_test.go")), "/"))
+	assert.NotContains(t, diff, filepath.ToSlash(filepath.Join(dir, "order", "repository.go")))
+	assert.NoFileExists(t, filepath.Join(dir, "order", "app", "service_test.go"))
+}
+
+func TestGenerate_regenerate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir}
+	g, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	assert.FileExists(t, filepath.Join(dir, "order", ManifestFile))
+
+	entityPath := filepath.Join(dir, "order", "order.go")
+	original, err := os.ReadFile(entityPath)
+	require.NoError(t, err)
+	edited := strings.Replace(string(original), "package domain\n", "package domain\n\n// Hand-written note.\n", 1)
+	require.NoError(t, os.WriteFile(entityPath, []byte(edited), 0644))
+
+	regenerate := func(strategy ConflictStrategy, fields []Field) string {
+		t.Helper()
+		cfg := cfg
+		cfg.Regenerate = true
+		cfg.OnConflict = strategy
+		cfg.Fields = fields
+		g, err := New(cfg)
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		content, err := os.ReadFile(entityPath)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	withPriority := append(append([]Field{}, DefaultFields...), Field{Name: "priority", Type: "int"})
+
+	// Default strategy keeps the edited file.
+	assert.Equal(t, edited, regenerate("", withPriority))
+
+	// Merge keeps the edit and picks up the new field.
+	merged := regenerate(ConflictMerge, withPriority)
+	assert.Contains(t, merged, "// Hand-written note.")
+	assert.Regexp(t, `Priority\s+int`, merged)
+	assert.NotContains(t, merged, conflictStart)
+
+	// Overwrite discards the edit.
+	assert.NotContains(t, regenerate(ConflictOverwrite, withPriority), "// Hand-written note.")
+}
+
+func TestGenerate_addOnly(t *testing.T) {
+	dir := t.TempDir()
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, AddOnly: true})
+	require.ErrorIs(t, err, ErrDomainNotFound)
+
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entityPath := filepath.Join(dir, "order", "order.go")
+	original, err := os.ReadFile(entityPath)
+	require.NoError(t, err)
+
+	// Audit fields change the entity, which add must leave alone
+	g, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, AddOnly: true, WithCQRS: true, WithAuditFields: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entity, err := os.ReadFile(entityPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(entity))
+	assert.FileExists(t, filepath.Join(dir, "order", "cqrs", "wiring.go"))
+	assert.FileExists(t, filepath.Join(dir, "order", "migrations", "order.sql"))
+
+	manifest, err := loadManifest(filepath.Join(dir, "order"))
+	require.NoError(t, err)
+	assert.Contains(t, manifest.Files, "cqrs/wiring.go")
+	assert.Equal(t, hashContent(original), manifest.Files["order.go"].Hash)
+}
+
+func TestGenerate_devEnv(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module github.com/x/y\n"), 0644))
+	dir := filepath.Join(root, "internal")
+
+	g, err := New(Config{DomainName: "order", OutputDir: dir, WithAuditFields: true, WithRiver: true, DevEnv: DevEnvTaskfile})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	g, err = New(Config{DomainName: "user", OutputDir: dir, WithOutbox: true, DevEnv: DevEnvTaskfile})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	taskfile, err := os.ReadFile(filepath.Join(root, "Taskfile.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(taskfile), "-f internal/order/migrations/order.sql\n")
+	assert.Contains(t, string(taskfile), "-f internal/user/migrations/user_outbox.sql\n")
+	assert.Contains(t, string(taskfile), "river:migrate:")
+	assert.NotContains(t, string(taskfile), "router:")
+	assert.FileExists(t, filepath.Join(root, "docker-compose.yaml"))
+
+	// A Makefile written by hand is kept
+	makefile := filepath.Join(root, "Makefile")
+	require.NoError(t, os.WriteFile(makefile, []byte("all:\n"), 0644))
+	g, err = New(Config{DomainName: "item", OutputDir: dir, DevEnv: DevEnvMake})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	content, err := os.ReadFile(makefile)
+	require.NoError(t, err)
+	assert.Equal(t, "all:\n", string(content))
+
+	_, err = New(Config{DomainName: "item", OutputDir: t.TempDir(), ModulePath: "github.com/x/y", DevEnv: "just"})
+	require.ErrorContains(t, err, "unknown dev env")
+}
+
+func TestGenerate_riverJobs(t *testing.T) {
+	jobs, err := ParseJobs("send_email, expire_stale@90m")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: dir, WithRiver: true, Jobs: jobs})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "booking")
+	river, err := os.ReadFile(filepath.Join(base, "adapters", "booking_river.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(river), `func (SendEmailArgs) Kind() string { return "booking.send_email" }`)
+	assert.Contains(t, string(river), "ExpireStale(ctx context.Context, args ExpireStaleArgs) error")
+	assert.Contains(t, string(river), "func (c *BookingJobClient) InsertSendEmailTx(")
+	assert.NotContains(t, string(river), "BookingCreatedJobArgs")
+
+	periodic, err := os.ReadFile(filepath.Join(base, "adapters", "booking_river_periodic.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(periodic), "river.PeriodicInterval(90*time.Minute)")
+	assert.NotContains(t, string(periodic), "SendEmailArgs")
+
+	assertGeneratedGoParses(t, base)
+
+	_, err = New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), Jobs: jobs})
+	require.ErrorContains(t, err, "--jobs requires --with-river")
+
+	for _, bad := range []JobSpec{{Name: "SendEmail"}, {Name: "cleanup", Every: "-1h"}} {
+		_, err = New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithRiver: true, Jobs: []JobSpec{bad}})
+		require.ErrorContains(t, err, "invalid jobs", bad.Name)
+	}
+}
+
+func TestGenerate_cache(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: dir, WithCache: true, WithTenancy: true, CacheTTL: "90s"})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "booking")
+	decorator, err := os.ReadFile(filepath.Join(base, "app", "cache.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(decorator), "func NewCachedBookingService(next BookingService, cache BookingCache) *CachedBookingService")
+	assert.Contains(t, string(decorator), "return s.invalidate(ctx, id)")
+
+	adapter, err := os.ReadFile(filepath.Join(base, "adapters", "booking_cache.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(adapter), "const DefaultBookingCacheTTL = 90 * time.Second")
+	assert.Contains(t, string(adapter), `"booking:" + tenantID + ":" + strconv.Itoa(id)`)
+
+	assertGeneratedGoParses(t, base)
+
+	_, err = New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithCache: true, CacheTTL: "soon"})
+	require.ErrorContains(t, err, "invalid cache TTL")
+}
+
+func TestNew_nameVariants(t *testing.T) {
+	g, err := New(Config{DomainName: "OrderCategory", ModulePath: "github.com/x/y", OutputDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "ordercategory", g.data.DomainLower)
+	assert.Equal(t, "order_categories", g.data.DomainPlural)
+	assert.Equal(t, "order_category", g.data.DomainSnake)
+	assert.Equal(t, "order-category", g.data.DomainKebab)
+	assert.Equal(t, "orderCategory", g.data.DomainCamel)
+}
+
+func TestGenerate_pluralNames(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "category", ModulePath: "github.com/x/y", OutputDir: dir, HTTPRouter: RouterChi, WithTenancy: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "category")
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "category.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "CREATE TABLE IF NOT EXISTS categories (")
+
+	handler, err := os.ReadFile(filepath.Join(base, "adapters", "category_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(handler), `r.Route("/api/v1/categories"`)
+}
+
+func TestGenerate_readme(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName:    "booking",
+		ModulePath:    "github.com/x/y",
+		OutputDir:     dir,
+		HTTPRouter:    RouterChi,
+		WithCQRS:      true,
+		WithMessaging: true,
+		WithRiver:     true,
+		Jobs:          []JobSpec{{Name: "send_reminder", Every: "1h"}},
+		Fields:        []Field{{Name: "guest", Type: "string", Required: true, MaxLength: 80, Doc: "Name of the guest"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	readme, err := os.ReadFile(filepath.Join(dir, "booking", "README.md"))
+	require.NoError(t, err)
+	for _, s := range []string{
+		"```mermaid\nflowchart LR",
+		`publisher["BookingMessagePublisher<br/>booking_messaging.go"]`,
+		"commands -- publishes --> events",
+		"| `Guest` | `string` | `guest` | `guest` | required, max length 80. Name of the guest |",
+		"| `GET` | `/api/v1/bookings/{id}` | Get |",
+		"| `booking-created` | `EventBookingCreated` | `BookingCreatedEvent` |",
+		"- `CreateBookingCommand`",
+		"| `booking.send_reminder` | every `time.Hour` |",
+	} {
+		assert.Contains(t, string(readme), s)
+	}
+	assert.NotContains(t, string(readme), "restore")
+
+	// Components that were not generated are left out
+	dir = t.TempDir()
+	g, err = New(Config{DomainName: "booking", ModulePath: "github.com/x/y", OutputDir: dir})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	readme, err = os.ReadFile(filepath.Join(dir, "booking", "README.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(readme), "broker")
+	assert.NotContains(t, string(readme), "## Commands")
+	assert.NotContains(t, string(readme), "## Jobs")
+}
+
+func TestGenerate_nextStepsNumbering(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"all": {
+			WithCQRS: true, WithRiver: true, WithWorkflows: true, WorkflowEngine: EngineSaga, WithOutbox: true,
+			WithAuthz: true, WithCache: true, WithIdempotency: true, WithTenancy: true, DevEnv: DevEnvMake,
+		},
+		"event sourcing": {WithEventSourcing: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.DomainName, cfg.ModulePath, cfg.OutputDir = "order", "github.com/x/y", t.TempDir()
+			g, err := New(cfg)
+			require.NoError(t, err)
+			var out bytes.Buffer
+			require.NoError(t, g.WithOutput(&out).Generate())
+
+			var numbers []int
+			for _, line := range strings.Split(out.String(), "\n") {
+				var n int
+				if _, err := fmt.Sscanf(strings.TrimSpace(line), "%d.", &n); err == nil {
+					numbers = append(numbers, n)
+				}
+			}
+			require.Greater(t, len(numbers), 5)
+			for i, n := range numbers {
+				assert.Equal(t, i+1, n, "steps are numbered in sequence:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestGenerate_report(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir}
+	g, err := New(cfg)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, g.WithOutput(&out).Generate())
+
+	report := g.Report()
+	assert.Equal(t, "order", report.Domain)
+	assert.Contains(t, report.Files, FileReport{Path: filepath.Join(dir, "order", "order.go"), Action: "created"})
+	assert.Contains(t, out.String(), "Next steps")
+
+	cfg.Regenerate = true
+	g, err = New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.WithOutput(io.Discard).Generate())
+	for _, f := range g.Report().Files {
+		assert.Equal(t, "unchanged", f.Action, f.Path)
+	}
+}
+
+func TestGenerate_regeneratePrompt(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir}
+	g, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entityPath := filepath.Join(dir, "order", "order.go")
+	require.NoError(t, os.WriteFile(entityPath, []byte("package domain\n"), 0644))
+
+	cfg.Regenerate = true
+	cfg.OnConflict = ConflictPrompt
+	g, err = New(cfg)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, g.WithInput(strings.NewReader("what?\no\n")).WithOutput(&out).Generate())
+
+	assert.Contains(t, out.String(), "order.go has local changes")
+	content, err := os.ReadFile(entityPath)
+	require.NoError(t, err)
+	assert.NotEqual(t, "package domain\n", string(content))
+}
+
+func TestNew_invalidConflictStrategy(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), OnConflict: "ask"})
+	require.ErrorContains(t, err, "unknown conflict strategy")
+}
+
+func TestGenerate_importPathFromGoMod(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/shop\n"), 0o644))
+
+	g, err := New(Config{DomainName: "order", OutputDir: filepath.Join(root, "modules")})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	httpAdapter, err := os.ReadFile(filepath.Join(root, "modules", "order", "adapters", "order_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(httpAdapter), `order "example.com/shop/modules/order"`)
+	assert.Contains(t, string(httpAdapter), `"example.com/shop/modules/order/app"`)
+}
+
+func TestGenerate_openAPI(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName: "task",
+		ModulePath: "github.com/x/y",
+		OutputDir:  dir,
+		Fields: []Field{
+			{Name: "title", Type: "string", Required: true, MaxLength: 200},
+			{Name: "due_at", Type: "time", Nullable: true},
+			{Name: "done", Type: "bool", Default: "false"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	value, err := loadCUEValue(filepath.Join(dir, "task", "adapters", "openapi.yaml"))
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                  `json:"required"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, value.Decode(&spec))
+
+	assert.Contains(t, spec.Paths["/api/v1/tasks"], "post")
+	assert.Contains(t, spec.Paths["/api/v1/tasks/{id}"], "patch")
+
+	input := spec.Components.Schemas["TaskInput"]
+	assert.Equal(t, []string{"title"}, input.Required)
+	assert.EqualValues(t, 200, input.Properties["title"]["maxLength"])
+	assert.Equal(t, "date-time", input.Properties["due_at"]["format"])
+	assert.Equal(t, true, input.Properties["due_at"]["nullable"])
+	assert.Equal(t, false, input.Properties["done"]["default"])
+
+	assert.Contains(t, spec.Components.Schemas, "ErrorResponse")
+}
+
+func TestGenerate_mocks(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"with mocks": {WithMocks: true},
+		"with tests": {WithTests: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg.DomainName = "order"
+			cfg.ModulePath = "github.com/x/y"
+			cfg.OutputDir = dir
+			g, err := New(cfg)
+			require.NoError(t, err)
+			require.NoError(t, g.Generate())
+
+			assert.FileExists(t, filepath.Join(dir, "order", "mocks", "repository.go"))
+			assert.FileExists(t, filepath.Join(dir, "order", "mocks", "service.go"))
+			assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+		})
+	}
+}
+
+func TestGenerate_httpRouter(t *testing.T) {
+	for router, want := range map[HTTPRouter][]string{
+		RouterChi:     {`"github.com/go-chi/chi/v5"`, "Register(r chi.Router)", "chi.URLParam(r, \"id\")", "httputil.WriteSuccess(w, status, data, nil)", "httputil.AsHTTPError(err)"},
+		RouterEcho:    {`"github.com/labstack/echo/v4"`, "Register(e *echo.Echo)", "Create(c echo.Context) error", "httputil.Success(items, meta)"},
+		RouterGin:     {`"github.com/gin-gonic/gin"`, "Register(r gin.IRouter)", "Create(c *gin.Context)"},
+		RouterNetHTTP: {"Register(mux *http.ServeMux)", `"GET /api/v1/tasks/{id}"`, "r.PathValue(\"id\")"},
+		RouterHuma:    {`"github.com/danielgtaylor/huma/v2"`, "Register(humaAPI huma.API)", "api.service.DeleteTask(ctx, input.ID, 0)", "errors.Is(err, task.ErrUnauthorized)", "httputil.AsHTTPError(err)"},
+	} {
+		t.Run(string(router), func(t *testing.T) {
+			dir := t.TempDir()
+			g, err := New(Config{
+				DomainName: "task",
+				ModulePath: "github.com/x/y",
+				OutputDir:  dir,
+				HTTPRouter: router,
+				Fields: []Field{
+					{Name: "title", Type: "string", Required: true},
+					{Name: "due_at", Type: "time", Nullable: true},
+				},
+			})
+			require.NoError(t, err)
+			require.NoError(t, g.Generate())
+
+			adapter, err := os.ReadFile(filepath.Join(dir, "task", "adapters", "task_http.go"))
+			require.NoError(t, err)
+			for _, s := range want {
+				assert.Contains(t, string(adapter), s)
+			}
+			assertGeneratedGoParses(t, filepath.Join(dir, "task"))
+		})
+	}
+}
+
+func TestNew_invalidHTTPRouter(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), HTTPRouter: "fiber"})
+	require.ErrorContains(t, err, "unknown HTTP router")
+}
+
+func TestGenerate_messagingBackend(t *testing.T) {
+	for backend, want := range map[MessagingBackend][]string{
+		"":           {`"github.com/ThreeDotsLabs/watermill-nats/v2/pkg/nats"`, "DurablePrefix: cfg.DurablePrefix"},
+		BackendKafka: {`"github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"`, "ConsumerGroup: cfg.ConsumerGroup"},
+		BackendAMQP:  {`"github.com/ThreeDotsLabs/watermill-amqp/v3/pkg/amqp"`, "amqp.NewDurablePubSubConfig(cfg.URI"},
+	} {
+		t.Run(string(backend), func(t *testing.T) {
+			dir := t.TempDir()
+			g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithMessaging: true, MessagingBackend: backend})
+			require.NoError(t, err)
+			require.NoError(t, g.Generate())
+
+			adapter, err := os.ReadFile(filepath.Join(dir, "order", "adapters", "order_messaging.go"))
+			require.NoError(t, err)
+			for _, s := range want {
+				assert.Contains(t, string(adapter), s)
+			}
+			assert.Contains(t, string(adapter), "RegisterHandlers(router *message.Router, subscriber message.Subscriber)")
+			assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+		})
+	}
+}
+
+func TestNew_invalidMessagingBackend(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithMessaging: true, MessagingBackend: "sqs"})
+	require.ErrorContains(t, err, "unknown messaging backend")
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), MessagingBackend: BackendKafka})
+	require.ErrorContains(t, err, "requires --with-messaging")
+}
+
+func TestGenerate_authz(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithAuthz: true, WithAuditFields: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	decorator, err := os.ReadFile(filepath.Join(base, "app", "authz.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(decorator), "s.authorize(ctx, PermissionOrderDelete, id)")
+	assert.Contains(t, string(decorator), "func (s *AuthorizedOrderService) RestoreOrder(")
+
+	checker, err := os.ReadFile(filepath.Join(base, "adapters", "order_authz.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(checker), `authz "github.com/x/y/internal/authz"`)
+	assert.Contains(t, string(checker), "string(authz.OrderEditPerm)")
+
+	assertGeneratedGoParses(t, base)
+
+	g, err = New(Config{DomainName: "item", ModulePath: "github.com/x/y", OutputDir: dir, WithAuthz: true, AuthzPackage: "github.com/x/y/gen/spicedb"})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	checker, err = os.ReadFile(filepath.Join(dir, "item", "adapters", "item_authz.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(checker), `authz "github.com/x/y/gen/spicedb"`)
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), AuthzPackage: "github.com/x/y/authz"})
+	require.ErrorContains(t, err, "--authz-package requires --with-authz")
+}
+
+func TestGenerate_errorgen(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	catalog, err := os.ReadFile(filepath.Join(base, "errors.cue"))
+	require.NoError(t, err)
+	assert.Contains(t, string(catalog), `code:           "ORDER_NOT_FOUND"`)
+	assert.NoFileExists(t, filepath.Join(base, "errors_gen.go"))
+
+	dir = t.TempDir()
+	g, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithErrorgen: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base = filepath.Join(dir, "order")
+	generated, err := os.ReadFile(filepath.Join(base, "errors_gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(generated), "var ErrOrderNotFound = &Error{")
+	assert.Contains(t, string(generated), "HTTPStatus: 409,")
+	assert.Contains(t, string(generated), "Public:     true,", "the domain errors are reported to clients with their message")
+
+	errs, err := os.ReadFile(filepath.Join(base, "errors.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(errs), "ErrOrderNotFound =")
+	assert.Contains(t, string(errs), "ErrOrderNotActive")
+
+	assertGeneratedGoParses(t, base)
+}
+
+func TestGenerate_sagaWorkflowEngine(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithCQRS: true, WithWorkflows: true, WorkflowEngine: EngineSaga})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	assert.NoFileExists(t, filepath.Join(base, "adapters", "order_temporal.go"))
+
+	saga, err := os.ReadFile(filepath.Join(base, "saga", "saga.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(saga), "func NewOrderSaga(db *pgxpool.Pool, commands CommandSender, step Step) *OrderSaga")
+	assert.Contains(t, string(saga), "cqrs.NewEventHandler(\"OrderSaga.OnCreated\", s.OnCreated)")
+
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "order_saga.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "CREATE TABLE IF NOT EXISTS order_saga")
+
+	assertGeneratedGoParses(t, base)
+}
+
+func TestNew_invalidWorkflowEngine(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithWorkflows: true, WorkflowEngine: "airflow"})
+	require.ErrorContains(t, err, "unknown workflow engine")
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WorkflowEngine: EngineSaga})
+	require.ErrorContains(t, err, "requires --with-workflows")
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithWorkflows: true, WorkflowEngine: EngineSaga})
+	require.ErrorContains(t, err, "--workflow-engine saga requires --with-cqrs")
+}
+
+func TestGenerate_graphQL(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName:  "task",
+		ModulePath:  "github.com/x/y",
+		OutputDir:   dir,
+		WithGraphQL: true,
+		Fields: []Field{
+			{Name: "title", Type: "string", Required: true},
+			{Name: "owner_id", Type: "uuid", Nullable: true},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	schema, err := os.ReadFile(filepath.Join(dir, "task", "adapters", "task.graphqls"))
+	require.NoError(t, err)
+	assert.Contains(t, string(schema), "  ownerID: UUID\n")
+	assert.Contains(t, string(schema), "the scalars\n# Time, UUID.")
+	assert.Contains(t, string(schema), "createTask(input: TaskInput!): Task!")
+
+	resolver, err := os.ReadFile(filepath.Join(dir, "task", "adapters", "task_graphql.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(resolver), "func (r *TaskResolver) Tasks(ctx context.Context, search *string, page *int, pageSize *int) (*TaskConnection, error)")
+	assertGeneratedGoParses(t, filepath.Join(dir, "task"))
+}
+
+func TestGenerate_di(t *testing.T) {
+	for mode, want := range map[DIMode][]string{
+		DIManual: {"providers.go", "wiring.go"},
+		DIWire:   {"providers.go", "wire.go", "inject.go"},
+		DIFx:     {"providers.go", "module.go"},
+	} {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithMessaging: true, DI: mode})
+			require.NoError(t, err)
+			require.NoError(t, g.Generate())
+
+			entries, err := os.ReadDir(filepath.Join(dir, "order", "wiring"))
+			require.NoError(t, err)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			assert.ElementsMatch(t, want, got)
+
+			providers, err := os.ReadFile(filepath.Join(dir, "order", "wiring", "providers.go"))
+			require.NoError(t, err)
+			assert.Contains(t, string(providers), "func ProvidePublisher(publisher message.Publisher) app.OrderPublisher")
+			assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+		})
+	}
+}
+
+func TestNew_invalidDIMode(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), DI: "dig"})
+	require.ErrorContains(t, err, "unknown DI mode")
+}
+
+func TestGenerate_integrationTests(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithTests: true, WithIntegrationTests: true, WithMessaging: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order", "adapters")
+	for _, name := range []string{"order_postgres_integration_test.go", "order_messaging_integration_test.go"} {
+		content, err := os.ReadFile(filepath.Join(base, name))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "//go:build integration\n"), name)
+	}
+	assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+
+	// Only the NATS publisher is covered
+	dir = t.TempDir()
+	g, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithTests: true, WithIntegrationTests: true, WithMessaging: true, MessagingBackend: BackendKafka})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	assert.NoFileExists(t, filepath.Join(dir, "order", "adapters", "order_messaging_integration_test.go"))
+	assert.FileExists(t, filepath.Join(dir, "order", "adapters", "order_postgres_integration_test.go"))
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithIntegrationTests: true})
+	require.ErrorContains(t, err, "--with-integration-tests requires --with-tests")
+}
+
+func TestGenerate_idempotency(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithIdempotency: true, HTTPRouter: RouterChi, DI: DIManual})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	service, err := os.ReadFile(filepath.Join(base, "app", "idempotency.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(service), "func NewIdempotentOrderService(next OrderService, store IdempotencyStore) *IdempotentOrderService")
+
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "order_idempotency.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "CREATE TABLE IF NOT EXISTS order_idempotency_keys")
+
+	http, err := os.ReadFile(filepath.Join(base, "adapters", "order_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(http), `r.With(IdempotencyKeyMiddleware).Post("/", api.Create)`)
+	assert.Contains(t, string(http), "app.ErrIdempotencyKeyInUse")
+
+	wiring, err := os.ReadFile(filepath.Join(base, "wiring", "wiring.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(wiring), "ProvideIdempotentService(service, ProvideIdempotencyStore(deps.DB))")
+
+	assert.FileExists(t, filepath.Join(base, "adapters", "order_idempotency.go"))
+	assertGeneratedGoParses(t, base)
+}
+
+func TestGenerate_listFilters(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName: "task",
+		ModulePath: "github.com/x/y",
+		OutputDir:  dir,
+		Fields: []Field{
+			{Name: "title", Type: "string", Required: true, Sort: true},
+			{Name: "due_at", Type: "time", Nullable: true, Filter: true, Sort: true},
+			{Name: "owner_id", Type: "uuid", Filter: true},
+		},
+		HTTPRouter: RouterChi,
+		WithCQRS:   true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "task")
+	repo, err := os.ReadFile(filepath.Join(base, "repository.go"))
+	require.NoError(t, err)
+	assert.Regexp(t, `DueAt\s+\*time\.Time`, string(repo))
+	assert.Regexp(t, `OwnerID\s+\*uuid\.UUID`, string(repo))
+	assert.Contains(t, string(repo), `var SortKeys = []string{"id", "created_at", "updated_at", "title", "due_at"}`)
+
+	postgres, err := os.ReadFile(filepath.Join(base, "adapters", "task_postgres.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(postgres), `where += fmt.Sprintf(" AND owner_id = $%d", len(args)+1)`)
+	assert.Contains(t, string(postgres), `AND id %s $%d`)
+
+	http, err := os.ReadFile(filepath.Join(base, "adapters", "task_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(http), "uuid.Parse(v)")
+	assert.Contains(t, string(http), "task.ErrInvalidListFilters")
+
+	query, err := os.ReadFile(filepath.Join(base, "readmodel", "query.go"))
+	require.NoError(t, err)
+	assert.Regexp(t, `"due_at":\s+"due_at"`, string(query))
+
+	assertGeneratedGoParses(t, base)
+}
+
+func TestGenerate_auditFields(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithAuditFields: true, WithTests: true, HTTPRouter: RouterChi})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "order.sql"))
+	require.NoError(t, err)
+	assert.Regexp(t, `deleted_at\s+TIMESTAMPTZ`, string(migration))
+
+	repo, err := os.ReadFile(filepath.Join(base, "repository.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(repo), "Restore(ctx context.Context, id int, restoredBy int) error")
+
+	postgres, err := os.ReadFile(filepath.Join(base, "adapters", "order_postgres.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(postgres), "SET deleted_at = NOW(), deleted_by = $2")
+	assert.Contains(t, string(postgres), "WHERE id = $1 AND deleted_at IS NULL")
+
+	http, err := os.ReadFile(filepath.Join(base, "adapters", "order_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(http), `r.Post("/{id}/restore", api.Restore)`)
+
+	assert.FileExists(t, filepath.Join(base, "app", "service_test.go"))
+	assertGeneratedGoParses(t, base)
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithAuditFields: true, WithEventSourcing: true})
+	require.ErrorContains(t, err, "--with-audit-fields cannot be combined with --with-eventsourcing")
+}
+
+func TestGenerate_tenancy(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithTenancy: true, WithCQRS: true, HTTPRouter: RouterChi})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "order")
+	assert.FileExists(t, filepath.Join(base, "tenant.go"))
+
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "order.sql"))
+	require.NoError(t, err)
+	assert.Regexp(t, `tenant_id\s+TEXT\s+NOT NULL`, string(migration))
+	assert.NotContains(t, string(migration), "deleted_at")
+
+	postgres, err := os.ReadFile(filepath.Join(base, "adapters", "order_postgres.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(postgres), "WHERE id = $1 AND tenant_id = $2")
+	assert.Contains(t, string(postgres), `where := " WHERE tenant_id = $1"`)
+
+	http, err := os.ReadFile(filepath.Join(base, "adapters", "order_http.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(http), "r.Use(TenantMiddleware)")
+
+	wiring, err := os.ReadFile(filepath.Join(base, "cqrs", "wiring.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(wiring), "params.Message.Metadata.Set(order.TenantMetadataKey, tenantID)")
+
+	assertGeneratedGoParses(t, base)
+
+	_, err = New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), WithTenancy: true, WithEventSourcing: true})
+	require.ErrorContains(t, err, "--with-tenancy cannot be combined with --with-eventsourcing")
+}
+
+func TestGenerate_templatesDir(t *testing.T) {
+	templates := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(templates, "domain"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "domain", "errors.go.tmpl"),
+		[]byte("package {{.DomainLower}}\n\n// Customized by the org.\nvar ErrCustom = \"custom\"\n"), 0o644))
+
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, TemplatesDir: templates})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	// Overridden template
+	errs, err := os.ReadFile(filepath.Join(dir, "order", "errors.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(errs), "// Customized by the org.")
+
+	// Embedded template
+	entity, err := os.ReadFile(filepath.Join(dir, "order", "order.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(entity), "type Order struct")
+}
+
+func TestNew_invalidTemplatesDir(t *testing.T) {
+	_, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: t.TempDir(), TemplatesDir: filepath.Join(t.TempDir(), "missing")})
+	require.ErrorContains(t, err, "invalid templates directory")
+}
+
+func TestGenerate_outbox(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{DomainName: "order", ModulePath: "github.com/x/y", OutputDir: dir, WithOutbox: true})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	migration, err := os.ReadFile(filepath.Join(dir, "order", "migrations", "order_outbox.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "CREATE TABLE IF NOT EXISTS order_outbox")
+
+	repo, err := os.ReadFile(filepath.Join(dir, "order", "adapters", "order_postgres.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(repo), "r.outbox.Write(ctx, tx, string(order.EventOrderCreated), event)")
+	assert.Contains(t, string(repo), "return tx.Commit(ctx)")
+
+	assert.FileExists(t, filepath.Join(dir, "order", "adapters", "order_outbox.go"))
+	assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+}
+
+func TestGenerate_aggregate(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName:   "order",
+		ModulePath:   "github.com/x/y",
+		OutputDir:    dir,
+		Children:     []PartSpec{{Name: "items", Fields: []Field{{Name: "sku", Type: "string", Required: true}}}, {Name: "payments"}},
+		ValueObjects: []PartSpec{{Name: "money", Fields: []Field{{Name: "amount", Type: "decimal"}, {Name: "currency", Type: "string", Required: true, MaxLength: 3}}}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	entity, err := os.ReadFile(filepath.Join(dir, "order", "order.go"))
+	require.NoError(t, err)
+	assert.Regexp(t, `Items\s+\[\]Item`, string(entity))
+	assert.Contains(t, string(entity), "func (e *Order) AddPayment(c Payment) error")
+
+	money, err := os.ReadFile(filepath.Join(dir, "order", "money.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(money), "func NewMoney(amount decimal.Decimal, currency string) (Money, error)")
+
+	assert.FileExists(t, filepath.Join(dir, "order", "item.go"))
+	assert.FileExists(t, filepath.Join(dir, "order", "payment.go"))
+	assertGeneratedGoParses(t, filepath.Join(dir, "order"))
+}
+
+func TestNew_invalidAggregate(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"root as child":   {Children: []PartSpec{{Name: "orders"}}},
+		"reserved file":   {Children: []PartSpec{{Name: "repository"}}},
+		"duplicate parts": {Children: []PartSpec{{Name: "item"}, {Name: "items"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.DomainName, cfg.ModulePath, cfg.OutputDir = "order", "github.com/x/y", t.TempDir()
+			_, err := New(cfg)
+			require.ErrorContains(t, err, "invalid aggregate")
+		})
+	}
+}
+
+func TestGenerate_eventSourcing(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName:        "ledger",
+		ModulePath:        "github.com/x/y",
+		OutputDir:         dir,
+		WithEventSourcing: true,
+		WithTests:         true,
+		WithCQRS:          true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "ledger")
+	assert.FileExists(t, filepath.Join(base, "aggregate.go"))
+	assert.FileExists(t, filepath.Join(base, "adapters", "ledger_eventstore.go"))
+	assert.FileExists(t, filepath.Join(base, "migrations", "ledger_eventstore.sql"))
+	assert.NoFileExists(t, filepath.Join(base, "adapters", "ledger_postgres.go"))
+
+	repo, err := os.ReadFile(filepath.Join(base, "repository.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(repo), "Load(ctx context.Context, id int) (*Ledger, error)")
+	assert.NotContains(t, string(repo), "GetByID")
+
+	service, err := os.ReadFile(filepath.Join(base, "app", "service.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(service), "ledger.CreateLedger(id, ledger.LedgerCreatedEvent{")
+
+	assertGeneratedGoParses(t, base)
+}
+
+func TestGenerate_cqrsReadModel(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New(Config{
+		DomainName: "task",
+		ModulePath: "github.com/x/y",
+		OutputDir:  dir,
+		WithCQRS:   true,
+		Fields:     []Field{{Name: "title", Type: "string", Required: true, MaxLength: 200}, {Name: "due_at", Type: "time", Nullable: true}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	base := filepath.Join(dir, "task")
+	migration, err := os.ReadFile(filepath.Join(base, "migrations", "task_readmodel.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "CREATE TABLE IF NOT EXISTS task_view")
+	assert.Regexp(t, `title\s+VARCHAR\(200\) NOT NULL,`, string(migration))
+	assert.Regexp(t, `due_at\s+TIMESTAMPTZ,`, string(migration))
+
+	projection, err := os.ReadFile(filepath.Join(base, "readmodel", "projection.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(projection), "func (p *TaskProjection) OnUpdated(ctx context.Context, event *taskcqrs.TaskUpdatedEvent) error")
+
+	query, err := os.ReadFile(filepath.Join(base, "readmodel", "query.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(query), "func (s *TaskQueryService) List(ctx context.Context, filters task.ListFilters) ([]*TaskView, int, error)")
+
+	events, err := os.ReadFile(filepath.Join(base, "cqrs", "events.go"))
+	require.NoError(t, err)
+	assert.Regexp(t, "DueAt\\s+\\*time\\.Time\\s+`json:\"due_at\"`", string(events))
+
+	assertGeneratedGoParses(t, base)
+}
+
+func TestNew_eventSourcingConflicts(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"outbox":   {WithOutbox: true},
+		"children": {Children: []PartSpec{{Name: "entries"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.DomainName, cfg.ModulePath, cfg.OutputDir = "ledger", "github.com/x/y", t.TempDir()
+			cfg.WithEventSourcing = true
+			_, err := New(cfg)
+			require.ErrorContains(t, err, "--with-eventsourcing")
+		})
+	}
+}
+
+func TestFormatGo(t *testing.T) {
+	src := "package app\n\nimport (\n\t\"context\"\n\t\"time\"\n)\n\nfunc Run(ctx context.Context) error {\nreturn ctx.Err()\n}\n"
+	out, err := formatGo("app/service.go", "templates/app/service.go.tmpl", []byte(src))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), `"time"`)
+	assert.Contains(t, string(out), "\treturn ctx.Err()")
+
+	_, err = formatGo("app/service.go", "templates/app/service.go.tmpl", []byte("package app\n\nfunc Run( {\n"))
+	require.ErrorContains(t, err, "generated service.go (from templates/app/service.go.tmpl) is not valid Go")
+}
@@ -0,0 +1,98 @@
+package dddgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is written into every generated domain directory. It records
+// what the generator last wrote so that regeneration can tell generated code
+// from manual edits.
+const ManifestFile = ".ddd-gen.json"
+
+// Manifest maps domain-relative file paths to the content last generated for
+// them. Options fingerprints the generator options of that run, so that a
+// project run can tell whether a domain's declared options changed since.
+type Manifest struct {
+	Version int                      `json:"version"`
+	Options string                   `json:"options,omitempty"`
+	Files   map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry holds the hash and content of a generated file. The content
+// is the merge base for three-way merges on regeneration.
+type ManifestEntry struct {
+	Hash    string `json:"hash"`
+	Content string `json:"content"`
+}
+
+// newManifestEntry records content as generated.
+func newManifestEntry(content []byte) ManifestEntry {
+	return ManifestEntry{Hash: hashContent(content), Content: string(content)}
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// loadManifest reads the manifest of a domain directory. A missing manifest
+// yields an empty one.
+func loadManifest(domainDir string) (*Manifest, error) {
+	m := &Manifest{Version: 1, Files: make(map[string]ManifestEntry)}
+
+	data, err := os.ReadFile(filepath.Join(domainDir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filepath.Join(domainDir, ManifestFile), err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]ManifestEntry)
+	}
+	return m, nil
+}
+
+// save writes the manifest into domainDir.
+func (m *Manifest) save(domainDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(domainDir, ManifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// modified reports whether current differs from what was last generated for
+// rel. Files without a manifest entry are treated as modified since their
+// origin is unknown.
+func (m *Manifest) modified(rel string, current []byte) bool {
+	entry, ok := m.Files[rel]
+	return !ok || entry.Hash != hashContent(current)
+}
+
+// optionsFingerprint hashes the options of cfg that shape the generated
+// files. How a run treats existing files (dry run, regeneration, conflicts)
+// and where it reads templates from and writes to are left out.
+func optionsFingerprint(cfg Config) string {
+	cfg.OutputDir, cfg.TemplatesDir = "", ""
+	cfg.DryRun, cfg.Regenerate, cfg.AddOnly = false, false, false
+	cfg.OnConflict = ""
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		// Never matches a recorded fingerprint, so the domain is regenerated
+		return ""
+	}
+	return hashContent(data)
+}
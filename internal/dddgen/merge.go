@@ -0,0 +1,94 @@
+package dddgen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Conflict markers written by merge3 when both sides changed the same region.
+const (
+	conflictStart = "<<<<<<< local\n"
+	conflictSep   = "=======\n"
+	conflictEnd   = ">>>>>>> generated\n"
+)
+
+// merge3 performs a line-based three-way merge of the user's version (local)
+// and the freshly generated version (generated) against the previously
+// generated base. Regions changed on only one side are taken from that side;
+// regions changed differently on both sides are emitted between conflict
+// markers. It returns the merged content and the number of conflicts.
+func merge3(base, local, generated string) (string, int) {
+	o, a, b := splitLines(base), splitLines(local), splitLines(generated)
+	matchA := matchLines(o, a)
+	matchB := matchLines(o, b)
+
+	var out strings.Builder
+	conflicts := 0
+	emit := func(lines []string) {
+		for _, l := range lines {
+			out.WriteString(l)
+		}
+	}
+
+	iO, iA, iB := 0, 0, 0
+	for iO < len(o) || iA < len(a) || iB < len(b) {
+		// Stable run: base lines kept unchanged on both sides.
+		n := 0
+		for iO+n < len(o) && matchA[iO+n] == iA+n && matchB[iO+n] == iB+n {
+			n++
+		}
+		if n > 0 {
+			emit(o[iO : iO+n])
+			iO, iA, iB = iO+n, iA+n, iB+n
+			continue
+		}
+
+		// Unstable chunk up to the next base line present on both sides.
+		j := iO
+		for j < len(o) && (matchA[j] < 0 || matchB[j] < 0) {
+			j++
+		}
+		endA, endB := len(a), len(b)
+		if j < len(o) {
+			endA, endB = matchA[j], matchB[j]
+		}
+
+		chunkO, chunkA, chunkB := o[iO:j], a[iA:endA], b[iB:endB]
+		switch {
+		case slices.Equal(chunkA, chunkO):
+			emit(chunkB)
+		case slices.Equal(chunkB, chunkO), slices.Equal(chunkA, chunkB):
+			emit(chunkA)
+		default:
+			conflicts++
+			out.WriteString(conflictStart)
+			emit(chunkA)
+			out.WriteString(conflictSep)
+			emit(chunkB)
+			out.WriteString(conflictEnd)
+		}
+		iO, iA, iB = j, endA, endB
+	}
+
+	return out.String(), conflicts
+}
+
+// matchLines maps every line of o to the index of the matching line in x, or
+// -1 when the line was removed or changed.
+func matchLines(o, x []string) []int {
+	match := make([]int, len(o))
+	for i := range match {
+		match[i] = -1
+	}
+	// Autojunk is disabled so that common lines such as "}" still anchor the
+	// alignment in large files.
+	m := difflib.NewMatcherWithJunk(o, x, false, nil)
+	for _, blk := range m.GetMatchingBlocks() {
+		for k := 0; k < blk.Size; k++ {
+			match[blk.A+k] = blk.B + k
+		}
+	}
+	return match
+}
@@ -0,0 +1,60 @@
+package dddgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "package x\n\nfunc A() {}\n\nfunc B() {}\n"
+
+	tests := []struct {
+		name      string
+		local     string
+		generated string
+		want      string
+		conflicts int
+	}{
+		{
+			name:      "only local changed",
+			local:     "package x\n\nfunc A() { println() }\n\nfunc B() {}\n",
+			generated: base,
+			want:      "package x\n\nfunc A() { println() }\n\nfunc B() {}\n",
+		},
+		{
+			name:      "only generated changed",
+			local:     base,
+			generated: "package x\n\nfunc A() {}\n\nfunc B() {}\n\nfunc C() {}\n",
+			want:      "package x\n\nfunc A() {}\n\nfunc B() {}\n\nfunc C() {}\n",
+		},
+		{
+			name:      "both changed different regions",
+			local:     "package x\n\nfunc A() { println() }\n\nfunc B() {}\n",
+			generated: "package x\n\nfunc A() {}\n\nfunc B(n int) {}\n",
+			want:      "package x\n\nfunc A() { println() }\n\nfunc B(n int) {}\n",
+		},
+		{
+			name:      "both changed the same way",
+			local:     "package y\n\nfunc A() {}\n\nfunc B() {}\n",
+			generated: "package y\n\nfunc A() {}\n\nfunc B() {}\n",
+			want:      "package y\n\nfunc A() {}\n\nfunc B() {}\n",
+		},
+		{
+			name:      "conflict",
+			local:     "package x\n\nfunc A() { one() }\n\nfunc B() {}\n",
+			generated: "package x\n\nfunc A() { two() }\n\nfunc B() {}\n",
+			want: "package x\n\n" + conflictStart + "func A() { one() }\n" + conflictSep +
+				"func A() { two() }\n" + conflictEnd + "\nfunc B() {}\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := merge3(base, tt.local, tt.generated)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.conflicts, conflicts)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package dddgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// findModule walks up from dir to the nearest go.mod and returns the
+// directory containing it and the declared module path. It returns
+// os.ErrNotExist when no go.mod is found.
+func findModule(dir string) (root, modulePath string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modulePath := modfile.ModulePath(data)
+			if modulePath == "" {
+				return "", "", fmt.Errorf("%s has no module directive", filepath.Join(dir, "go.mod"))
+			}
+			return dir, modulePath, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", "", fmt.Errorf("failed to read go.mod: %w", err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
+// resolveImportPath determines the module path and the import path of the
+// generated domain package. The nearest go.mod above the output directory
+// supplies the module root and, when modulePath is empty, the module path.
+// Without a go.mod the output directory is assumed to sit at the module root.
+func resolveImportPath(modulePath, outputDir, domainLower string) (string, string, error) {
+	root, found, err := findModule(outputDir)
+	if errors.Is(err, os.ErrNotExist) {
+		if modulePath == "" {
+			return "", "", fmt.Errorf("module path is required (e.g. github.com/user/project): no go.mod found above %s", outputDir)
+		}
+		dir := filepath.Base(outputDir)
+		if !filepath.IsAbs(outputDir) {
+			dir = filepath.Clean(outputDir)
+		}
+		if dir == "." {
+			return modulePath, path.Join(modulePath, domainLower), nil
+		}
+		return modulePath, path.Join(modulePath, filepath.ToSlash(dir), domainLower), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if modulePath == "" {
+		modulePath = found
+	}
+
+	absDomain, err := filepath.Abs(filepath.Join(outputDir, domainLower))
+	if err != nil {
+		return "", "", err
+	}
+	rel, err := filepath.Rel(root, absDomain)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", "", fmt.Errorf("output directory %s is outside the module rooted at %s", outputDir, root)
+	}
+	return modulePath, path.Join(modulePath, filepath.ToSlash(rel)), nil
+}
@@ -0,0 +1,37 @@
+package dddgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveImportPath_goMod(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/shop\n\ngo 1.26\n"), 0o644))
+
+	modulePath, importPath, err := resolveImportPath("", filepath.Join(root, "services", "billing", "internal"), "invoice")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/shop", modulePath)
+	assert.Equal(t, "example.com/shop/services/billing/internal/invoice", importPath)
+
+	// An explicit module path wins over go.mod but the location still comes from it.
+	modulePath, importPath, err = resolveImportPath("example.com/other", filepath.Join(root, "pkg"), "invoice")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/other", modulePath)
+	assert.Equal(t, "example.com/other/pkg/invoice", importPath)
+}
+
+func TestResolveImportPath_noGoMod(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, err := resolveImportPath("", dir, "invoice")
+	require.ErrorContains(t, err, "module path is required")
+
+	_, importPath, err := resolveImportPath("example.com/shop", filepath.Join(dir, "internal"), "invoice")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/shop/internal/invoice", importPath)
+}
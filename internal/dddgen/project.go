@@ -0,0 +1,354 @@
+package dddgen
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/encoding/yaml"
+)
+
+// DefaultProjectFiles lists the project config file names looked up, in order,
+// when no explicit path is given.
+var DefaultProjectFiles = []string{"ddd-gen.yaml", "ddd-gen.yml", "ddd-gen.cue", "ddd-gen.json"}
+
+// ProjectConfig declares the module path, default output directory, and the
+// set of domains to generate. It is loaded from a ddd-gen.yaml or ddd-gen.cue
+// file so that `ddd-gen generate` can run without flags:
+//
+//	module: github.com/acme/shop
+//	output: ./internal
+//	domains:
+//	  - name: order
+//	    withCQRS: true
+//	    withTests: true
+//	  - name: customer
+//	    all: true
+//	    spec: specs/customer.yaml
+type ProjectConfig struct {
+	Module    string         `json:"module"`
+	Output    string         `json:"output,omitempty"`
+	Templates string         `json:"templates,omitempty"` // Template override directory, relative to the project file
+	Domains   []DomainConfig `json:"domains"`
+
+	// OnConflict is how manually edited files of existing domains are
+	// treated when they are regenerated; ConflictMerge when empty.
+	OnConflict ConflictStrategy `json:"onConflict,omitempty"`
+	// Regenerate regenerates existing domains even when their options did
+	// not change, e.g. to pick up updated templates.
+	Regenerate bool `json:"regenerate,omitempty"`
+
+	dir string // Directory of the project file; spec paths are relative to it
+}
+
+// DomainConfig holds the per-domain options of a project file. The fields
+// mirror the ddd-gen command-line flags. Entity fields come from either an
+// external spec file or an inline fields list, not both.
+type DomainConfig struct {
+	Name                 string     `json:"name"`
+	Output               string     `json:"output,omitempty"`       // Overrides ProjectConfig.Output
+	Spec                 string     `json:"spec,omitempty"`         // Path to a DomainSpec file
+	Fields               []Field    `json:"fields,omitempty"`       // Inline entity fields
+	Children             []PartSpec `json:"children,omitempty"`     // Inline child entities
+	ValueObjects         []PartSpec `json:"valueObjects,omitempty"` // Inline value objects
+	Jobs                 []JobSpec  `json:"jobs,omitempty"`         // River jobs, added to those of the spec; need withRiver
+	CacheTTL             string     `json:"cacheTTL,omitempty"`     // Overrides the cacheTTL of the spec
+	WithTests            bool       `json:"withTests,omitempty"`
+	WithIntegrationTests bool       `json:"withIntegrationTests,omitempty"` // Needs withTests
+	WithMessaging        bool       `json:"withMessaging,omitempty"`
+	WithRiver            bool       `json:"withRiver,omitempty"`
+	WithCQRS             bool       `json:"withCQRS,omitempty"`
+	WithWorkflows        bool       `json:"withWorkflows,omitempty"`
+	WithDecorators       bool       `json:"withDecorators,omitempty"`
+	WithMocks            bool       `json:"withMocks,omitempty"`
+	WithOutbox           bool       `json:"withOutbox,omitempty"`
+	WithEventSourcing    bool       `json:"withEventSourcing,omitempty"` // Not implied by all
+	WithGraphQL          bool       `json:"withGraphQL,omitempty"`
+	WithIdempotency      bool       `json:"withIdempotency,omitempty"`
+	WithAuditFields      bool       `json:"withAuditFields,omitempty"`
+	WithTenancy          bool       `json:"withTenancy,omitempty"`
+	WithErrorgen         bool       `json:"withErrorgen,omitempty"`
+	WithCache            bool       `json:"withCache,omitempty"`
+	WithAuthz            bool       `json:"withAuthz,omitempty"`        // Not implied by all
+	AuthzPackage         string     `json:"authzPackage,omitempty"`     // Needs withAuthz
+	HTTPRouter           string     `json:"httpRouter,omitempty"`       // huma, chi, echo, gin, or net-http
+	MessagingBackend     string     `json:"messagingBackend,omitempty"` // nats, kafka, or amqp; needs withMessaging
+	WorkflowEngine       string     `json:"workflowEngine,omitempty"`   // temporal or saga; needs withWorkflows
+	DI                   string     `json:"di,omitempty"`               // wire, fx, or manual
+	DevEnv               string     `json:"devEnv,omitempty"`           // taskfile or make
+	All                  bool       `json:"all,omitempty"`
+}
+
+// FindProjectFile returns the first of DefaultProjectFiles present in dir.
+func FindProjectFile(dir string) (string, error) {
+	for _, name := range DefaultProjectFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no project file found in %s (looked for %s)", dir, strings.Join(DefaultProjectFiles, ", "))
+}
+
+// LoadProjectConfig reads a project file. YAML, JSON, and CUE are supported;
+// the format is chosen by file extension and all of them are evaluated through
+// CUE so constraints and defaults can be used in .cue files.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	value, err := loadCUEValue(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg ProjectConfig
+	if err := value.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode project file %s: %w", path, err)
+	}
+	if cfg.Output == "" {
+		cfg.Output = "./internal"
+	}
+	cfg.dir = filepath.Dir(path)
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid project file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// loadCUEValue compiles a YAML, JSON, or CUE file into a concrete cue.Value.
+func loadCUEValue(path string) (cue.Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	ctx := cuecontext.New()
+	var value cue.Value
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		file, err := yaml.Extract(path, data)
+		if err != nil {
+			return cue.Value{}, fmt.Errorf("failed to parse YAML %s: %w", path, err)
+		}
+		value = ctx.BuildFile(file)
+	case ".cue", ".json":
+		// JSON is a subset of CUE, so both compile the same way.
+		value = ctx.CompileBytes(data, cue.Filename(path))
+	default:
+		return cue.Value{}, fmt.Errorf("unsupported file extension %q for %s (use .yaml, .yml, .json, or .cue)", filepath.Ext(path), path)
+	}
+
+	if err := value.Err(); err != nil {
+		return cue.Value{}, fmt.Errorf("failed to evaluate %s: %w", path, err)
+	}
+	if err := value.Validate(cue.Concrete(true)); err != nil {
+		return cue.Value{}, fmt.Errorf("%s must contain only concrete values: %w", path, err)
+	}
+	return value, nil
+}
+
+func (p *ProjectConfig) validate() error {
+	if p.Module == "" {
+		return fmt.Errorf("module is required")
+	}
+	if len(p.Domains) == 0 {
+		return fmt.Errorf("at least one domain must be declared")
+	}
+	if _, err := ParseConflictStrategy(string(p.OnConflict)); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for i, d := range p.Domains {
+		if err := validateDomainName(d.Name); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		key := strings.ToLower(d.Name)
+		if seen[key] {
+			return fmt.Errorf("domains[%d]: duplicate domain %q", i, d.Name)
+		}
+		seen[key] = true
+
+		if _, err := ParseHTTPRouter(d.HTTPRouter); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := ParseMessagingBackend(d.MessagingBackend); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := ParseWorkflowEngine(d.WorkflowEngine); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := ParseDIMode(d.DI); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := ParseDevEnv(d.DevEnv); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if d.Spec != "" && (len(d.Fields) > 0 || len(d.Children) > 0 || len(d.ValueObjects) > 0) {
+			return fmt.Errorf("domains[%d]: spec and inline fields, children, or valueObjects are mutually exclusive", i)
+		}
+		if len(d.Fields) > 0 {
+			if _, err := buildFieldData(d.Fields); err != nil {
+				return fmt.Errorf("domains[%d]: %w", i, err)
+			}
+		}
+		if _, _, err := buildParts(d.Children, d.ValueObjects); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := buildJobs("", d.Jobs); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+		if _, err := cacheTTLExpr(d.CacheTTL); err != nil {
+			return fmt.Errorf("domains[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Configs expands the project file into one generator Config per domain,
+// loading any referenced spec files.
+func (p *ProjectConfig) Configs() ([]Config, error) {
+	templates := p.Templates
+	if templates != "" && !filepath.IsAbs(templates) {
+		templates = filepath.Join(p.dir, templates)
+	}
+
+	configs := make([]Config, 0, len(p.Domains))
+	for _, d := range p.Domains {
+		output := p.Output
+		if d.Output != "" {
+			output = d.Output
+		}
+
+		fields, children, valueObjects, jobs, cacheTTL := d.Fields, d.Children, d.ValueObjects, d.Jobs, d.CacheTTL
+		if d.Spec != "" {
+			path := d.Spec
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(p.dir, path)
+			}
+			spec, err := LoadDomainSpec(path)
+			if err != nil {
+				return nil, fmt.Errorf("domain %s: %w", d.Name, err)
+			}
+			fields, children, valueObjects = spec.Fields, spec.Children, spec.ValueObjects
+			jobs = append(spec.Jobs, d.Jobs...)
+			if cacheTTL == "" {
+				cacheTTL = spec.CacheTTL
+			}
+		}
+
+		configs = append(configs, Config{
+			DomainName:           d.Name,
+			OutputDir:            output,
+			ModulePath:           p.Module,
+			Fields:               fields,
+			Children:             children,
+			ValueObjects:         valueObjects,
+			Jobs:                 jobs,
+			WithTests:            d.WithTests || d.All,
+			WithIntegrationTests: d.WithIntegrationTests || d.All,
+			WithMessaging:        d.WithMessaging || d.All,
+			WithRiver:            d.WithRiver || d.All,
+			WithCQRS:             d.WithCQRS || d.All,
+			WithWorkflows:        d.WithWorkflows || d.All,
+			WithDecorators:       d.WithDecorators || d.All,
+			WithMocks:            d.WithMocks || d.All,
+			WithOutbox:           d.WithOutbox || d.All,
+			WithEventSourcing:    d.WithEventSourcing,
+			WithGraphQL:          d.WithGraphQL || d.All,
+			WithIdempotency:      d.WithIdempotency || d.All,
+			WithAuditFields:      d.WithAuditFields || d.All,
+			WithTenancy:          d.WithTenancy || d.All,
+			WithAuthz:            d.WithAuthz,
+			AuthzPackage:         d.AuthzPackage,
+			WithErrorgen:         d.WithErrorgen || d.All,
+			WithCache:            d.WithCache || d.All,
+			CacheTTL:             cacheTTL,
+			HTTPRouter:           HTTPRouter(d.HTTPRouter),
+			MessagingBackend:     MessagingBackend(d.MessagingBackend),
+			WorkflowEngine:       WorkflowEngine(d.WorkflowEngine),
+			DI:                   DIMode(d.DI),
+			DevEnv:               DevEnv(d.DevEnv),
+			TemplatesDir:         templates,
+		})
+	}
+	return configs, nil
+}
+
+// DomainStatus describes what happened to a domain during a project run.
+type DomainStatus string
+
+const (
+	DomainGenerated   DomainStatus = "generated"
+	DomainRegenerated DomainStatus = "regenerated"
+	DomainUnchanged   DomainStatus = "unchanged"
+)
+
+// DomainResult reports the outcome for a single domain of a project run.
+type DomainResult struct {
+	Domain string       `json:"domain"`
+	Path   string       `json:"path"`
+	Status DomainStatus `json:"status"`
+	Report *Report      `json:"report,omitempty"` // Nil for domains that were not regenerated
+}
+
+// GenerateProject generates every domain declared in the project file. New
+// domains are generated and reported as generated. Existing domains whose
+// options changed since they were last generated, according to their
+// manifest, are regenerated, applying p.OnConflict to files edited by hand,
+// and reported as regenerated; the others are left untouched and reported as
+// unchanged, unless p.Regenerate is set. The next steps of each domain are
+// written to out, os.Stdout when nil.
+func GenerateProject(p *ProjectConfig, logger *slog.Logger, out io.Writer) ([]DomainResult, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	onConflict := p.OnConflict
+	if onConflict == "" {
+		onConflict = ConflictMerge
+	}
+
+	configs, err := p.Configs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]DomainResult, 0, len(configs))
+	for _, cfg := range configs {
+		domainDir := filepath.Join(cfg.OutputDir, strings.ToLower(cfg.DomainName))
+		info, statErr := os.Stat(domainDir)
+		exists := statErr == nil && info.IsDir()
+		cfg.Regenerate = exists
+		cfg.OnConflict = onConflict
+
+		g, err := New(cfg)
+		if err != nil {
+			return results, fmt.Errorf("domain %s: %w", cfg.DomainName, err)
+		}
+		status := DomainGenerated
+		if exists {
+			changed, err := g.optionsChanged()
+			if err != nil {
+				return results, fmt.Errorf("domain %s: %w", cfg.DomainName, err)
+			}
+			if !changed && !p.Regenerate {
+				logger.Info("domain options unchanged, skipping", slog.String("domain", cfg.DomainName), slog.String("path", domainDir))
+				results = append(results, DomainResult{Domain: cfg.DomainName, Path: domainDir, Status: DomainUnchanged})
+				continue
+			}
+			status = DomainRegenerated
+		}
+
+		if err := g.WithLogger(logger).WithOutput(out).Generate(); err != nil {
+			return results, fmt.Errorf("domain %s: %w", cfg.DomainName, err)
+		}
+		results = append(results, DomainResult{Domain: cfg.DomainName, Path: domainDir, Status: status, Report: g.Report()})
+	}
+	return results, nil
+}
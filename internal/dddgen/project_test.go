@@ -0,0 +1,195 @@
+package dddgen
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProjectFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadProjectConfig_YAML(t *testing.T) {
+	path := writeProjectFile(t, "ddd-gen.yaml", `
+module: github.com/x/y
+domains:
+  - name: order
+    withCQRS: true
+  - name: customer
+    all: true
+    output: ./other
+`)
+	p, err := LoadProjectConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/x/y", p.Module)
+	assert.Equal(t, "./internal", p.Output)
+
+	configs, err := p.Configs()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "order", configs[0].DomainName)
+	assert.True(t, configs[0].WithCQRS)
+	assert.False(t, configs[0].WithTests)
+	assert.Equal(t, "./internal", configs[0].OutputDir)
+	assert.True(t, configs[1].WithTests)
+	assert.True(t, configs[1].WithWorkflows)
+	assert.Equal(t, "./other", configs[1].OutputDir)
+}
+
+func TestLoadProjectConfig_CUE(t *testing.T) {
+	path := writeProjectFile(t, "ddd-gen.cue", `
+module: "github.com/x/y"
+output: "./pkg"
+domains: [{name: "booking", withTests: true}]
+`)
+	p, err := LoadProjectConfig(path)
+	require.NoError(t, err)
+	require.Len(t, p.Domains, 1)
+	assert.Equal(t, "./pkg", p.Output)
+	assert.True(t, p.Domains[0].WithTests)
+}
+
+func TestLoadProjectConfig_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"missing module":   "domains:\n  - name: order\n",
+		"no domains":       "module: github.com/x/y\n",
+		"bad domain name":  "module: github.com/x/y\ndomains:\n  - name: 1order\n",
+		"duplicate domain": "module: github.com/x/y\ndomains:\n  - name: order\n  - name: Order\n",
+		"spec and fields":  "module: github.com/x/y\ndomains:\n  - name: order\n    spec: o.yaml\n    fields: [{name: total, type: int}]\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadProjectConfig(writeProjectFile(t, "ddd-gen.yaml", content))
+			require.Error(t, err)
+		})
+	}
+
+	_, err := LoadProjectConfig(writeProjectFile(t, "ddd-gen.toml", ""))
+	require.ErrorContains(t, err, "unsupported file extension")
+}
+
+func TestProjectConfig_Configs_Spec(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order.yaml"), []byte("fields:\n  - name: total\n    type: decimal\n"), 0o644))
+	path := filepath.Join(dir, "ddd-gen.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+module: github.com/x/y
+domains:
+  - name: order
+    spec: order.yaml
+  - name: customer
+    fields:
+      - name: email
+        type: string
+        required: true
+`), 0o644))
+
+	p, err := LoadProjectConfig(path)
+	require.NoError(t, err)
+	configs, err := p.Configs()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, []Field{{Name: "total", Type: "decimal"}}, configs[0].Fields)
+	assert.Equal(t, []Field{{Name: "email", Type: "string", Required: true}}, configs[1].Fields)
+}
+
+func TestProjectConfig_Configs_Templates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ddd-gen.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("module: github.com/x/y\ntemplates: tmpl\ndomains:\n  - name: order\n"), 0o644))
+
+	p, err := LoadProjectConfig(path)
+	require.NoError(t, err)
+	configs, err := p.Configs()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "tmpl"), configs[0].TemplatesDir)
+}
+
+func TestFindProjectFile(t *testing.T) {
+	dir := t.TempDir()
+	_, err := FindProjectFile(dir)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ddd-gen.cue"), []byte(""), 0o644))
+	path, err := FindProjectFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ddd-gen.cue"), path)
+}
+
+func TestGenerateProject_reportsStatus(t *testing.T) {
+	dir := t.TempDir()
+	p := &ProjectConfig{
+		Module: "github.com/x/y",
+		Output: dir,
+		Domains: []DomainConfig{
+			{Name: "order"},
+			{Name: "customer"},
+		},
+	}
+	// An existing directory without a manifest was not generated with these options
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "customer"), 0o755))
+
+	results, err := GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, DomainGenerated, results[0].Status)
+	assert.Equal(t, DomainRegenerated, results[1].Status)
+	assert.FileExists(t, filepath.Join(dir, "order", "order.go"))
+	assert.FileExists(t, filepath.Join(dir, "customer", "customer.go"))
+	require.NotNil(t, results[0].Report)
+	assert.Contains(t, results[0].Report.Files, FileReport{Path: filepath.Join(dir, "order", "order.go"), Action: "created"})
+
+	results, err = GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.Equal(t, DomainUnchanged, r.Status, r.Domain)
+		assert.Nil(t, r.Report)
+	}
+
+	p.Regenerate = true
+	results, err = GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, DomainRegenerated, results[0].Status)
+	assert.Contains(t, results[0].Report.Files, FileReport{Path: filepath.Join(dir, "order", "order.go"), Action: "unchanged"})
+}
+
+func TestGenerateProject_regeneratesChangedOptions(t *testing.T) {
+	dir := t.TempDir()
+	p := &ProjectConfig{
+		Module:  "github.com/x/y",
+		Output:  dir,
+		Domains: []DomainConfig{{Name: "order"}},
+	}
+	_, err := GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+
+	servicePath := filepath.Join(dir, "order", "app", "service.go")
+	service, err := os.ReadFile(servicePath)
+	require.NoError(t, err)
+	edited := append([]byte("// Hand-written note.\n"), service...)
+	require.NoError(t, os.WriteFile(servicePath, edited, 0o644))
+
+	p.Domains[0].WithCQRS = true
+	results, err := GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, DomainRegenerated, results[0].Status)
+	assert.FileExists(t, filepath.Join(dir, "order", "cqrs", "commands.go"))
+	assert.Contains(t, results[0].Report.Files, FileReport{Path: servicePath, Action: "merged"})
+
+	merged, err := os.ReadFile(servicePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(merged), "// Hand-written note.")
+
+	results, err = GenerateProject(p, nil, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, DomainUnchanged, results[0].Status)
+}
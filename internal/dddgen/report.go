@@ -0,0 +1,24 @@
+package dddgen
+
+// Report summarizes what a Generate run did, for the --json output of the
+// command-line tool.
+type Report struct {
+	Domain     string       `json:"domain"`
+	Path       string       `json:"path"` // Domain directory
+	DryRun     bool         `json:"dryRun,omitempty"`
+	Files      []FileReport `json:"files"`
+	DurationMS int64        `json:"durationMs"`
+	Error      string       `json:"error,omitempty"` // Set by the caller when Generate failed
+}
+
+// FileReport is a file that Generate wrote or considered. In dry-run mode the
+// action is what a real run would do.
+type FileReport struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // created, written, unchanged, skipped, merged, or kept
+}
+
+// record adds a file to the report of the current run.
+func (g *Generator) record(path string, action fileAction) {
+	g.report.Files = append(g.report.Files, FileReport{Path: path, Action: string(action)})
+}
@@ -0,0 +1,159 @@
+package dddgen
+
+import (
+	"errors"
+	"fmt"
+	"go/version"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ServiceConfig configures the service skeleton written by GenerateService.
+type ServiceConfig struct {
+	Name          string     // Service name; the binary is cmd/<name>
+	ModulePath    string     // Go module path; read from go.mod when empty
+	Dir           string     // Module root, or where go.mod is created (default ".")
+	HTTPRouter    HTTPRouter // Router the domains register their routes on
+	WithMessaging bool       // Run a Watermill message router over NATS next to the HTTP server
+	TemplatesDir  string     // Directory of template overrides, as in Config
+	DryRun        bool       // Report the files without writing them
+}
+
+// serviceData is passed to the templates under templates/service.
+type serviceData struct {
+	Name          string
+	ModulePath    string
+	HTTPRouter    HTTPRouter
+	WithMessaging bool
+	DatabaseURL   string
+	NATSURL       string
+}
+
+// GenerateService writes the skeleton of a service that generated domains
+// plug into: cmd/<name>/main.go, environment based configuration, and an HTTP
+// server with health endpoints and graceful shutdown. When no go.mod exists
+// one is created for cfg.ModulePath. Existing files are never overwritten;
+// they are reported as kept.
+func GenerateService(cfg ServiceConfig, logger *slog.Logger) ([]FileReport, error) {
+	if err := validateServiceName(cfg.Name); err != nil {
+		return nil, err
+	}
+	router, err := ParseHTTPRouter(string(cfg.HTTPRouter))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	root, modulePath, err := findModule(cfg.Dir)
+	writeGoMod := errors.Is(err, os.ErrNotExist)
+	switch {
+	case writeGoMod && cfg.ModulePath == "":
+		return nil, fmt.Errorf("module path is required (e.g. github.com/user/project): no go.mod found above %s", cfg.Dir)
+	case writeGoMod:
+		modulePath = cfg.ModulePath
+		if root, err = filepath.Abs(cfg.Dir); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case cfg.ModulePath != "" && cfg.ModulePath != modulePath:
+		return nil, fmt.Errorf("module path %q does not match %q declared in %s", cfg.ModulePath, modulePath, filepath.Join(root, "go.mod"))
+	}
+
+	g := &Generator{
+		config: Config{TemplatesDir: cfg.TemplatesDir, DryRun: cfg.DryRun},
+		logger: logger,
+		report: &Report{},
+	}
+	data := serviceData{
+		Name:          cfg.Name,
+		ModulePath:    modulePath,
+		HTTPRouter:    router,
+		WithMessaging: cfg.WithMessaging,
+		DatabaseURL:   devDatabaseURL,
+		NATSURL:       devNATSURL,
+	}
+
+	if writeGoMod {
+		goMod := fmt.Sprintf("module %s\n\ngo %s\n", modulePath, goDirective())
+		if err := g.writeServiceFile(filepath.Join(root, "go.mod"), []byte(goMod)); err != nil {
+			return nil, err
+		}
+	}
+
+	files := []struct{ rel, template string }{
+		{filepath.Join("cmd", cfg.Name, "main.go"), "templates/service/main.go.tmpl"},
+		{filepath.Join("internal", "config", "config.go"), "templates/service/config.go.tmpl"},
+		{filepath.Join("internal", "server", "server.go"), "templates/service/server.go.tmpl"},
+	}
+	for _, f := range files {
+		outputPath := filepath.Join(root, f.rel)
+		content, err := g.renderFile(outputPath, outputFile{template: f.template, data: data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", f.rel, err)
+		}
+		if err := g.writeServiceFile(outputPath, content); err != nil {
+			return nil, err
+		}
+	}
+	return g.report.Files, nil
+}
+
+// writeServiceFile creates a file of the service skeleton. Existing files
+// belong to the project and are left alone.
+func (g *Generator) writeServiceFile(outputPath string, content []byte) error {
+	if _, err := os.Stat(outputPath); err == nil {
+		g.logger.Warn("keeping existing file", slog.String("file", outputPath))
+		g.record(outputPath, fileKept)
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check %s: %w", outputPath, err)
+	}
+
+	if !g.config.DryRun {
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+		}
+		if err := os.WriteFile(outputPath, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		g.logger.Debug("generated file", slog.String("output", outputPath))
+	}
+	g.record(outputPath, fileCreated)
+	return nil
+}
+
+// validateServiceName ensures the name works as a directory under cmd and as
+// the binary name: lowercase letters, digits, '-' and '_', starting with a
+// letter.
+func validateServiceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("service name is required")
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_'):
+		default:
+			return fmt.Errorf("invalid service name %q (use lowercase letters, digits, '-' and '_', starting with a letter)", name)
+		}
+	}
+	return nil
+}
+
+// goDirective returns the language version of the running toolchain for the
+// go directive of a new go.mod, e.g. "1.26".
+func goDirective() string {
+	if lang := version.Lang(runtime.Version()); lang != "" {
+		return strings.TrimPrefix(lang, "go")
+	}
+	return "1.24"
+}
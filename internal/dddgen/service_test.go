@@ -0,0 +1,56 @@
+package dddgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateService(t *testing.T) {
+	dir := t.TempDir()
+	files, err := GenerateService(ServiceConfig{Name: "api", ModulePath: "github.com/x/y", Dir: dir, HTTPRouter: RouterChi, WithMessaging: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	for _, f := range files {
+		assert.Equal(t, "created", f.Action, f.Path)
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "module github.com/x/y\n")
+
+	main, err := os.ReadFile(filepath.Join(dir, "cmd", "api", "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), `"github.com/x/y/internal/server"`)
+	assert.Contains(t, string(main), "func registerDomains(router chi.Router, db *pgxpool.Pool, messageRouter *messaging.Router")
+	assert.Contains(t, string(main), "go func() { errs <- messageRouter.Run(ctx) }()")
+	assertGeneratedGoParses(t, dir)
+
+	// A second run keeps what the project already has
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "api", "main.go"), []byte("package main\n"), 0644))
+	files, err = GenerateService(ServiceConfig{Name: "api", Dir: dir}, nil)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, f := range files {
+		assert.Equal(t, "kept", f.Action, f.Path)
+	}
+	main, err = os.ReadFile(filepath.Join(dir, "cmd", "api", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(main))
+}
+
+func TestGenerateService_invalid(t *testing.T) {
+	_, err := GenerateService(ServiceConfig{Name: "Api", ModulePath: "github.com/x/y", Dir: t.TempDir()}, nil)
+	require.ErrorContains(t, err, "invalid service name")
+
+	_, err = GenerateService(ServiceConfig{Name: "api", Dir: t.TempDir()}, nil)
+	require.ErrorContains(t, err, "module path is required")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/x/y\n"), 0644))
+	_, err = GenerateService(ServiceConfig{Name: "api", ModulePath: "github.com/x/z", Dir: dir}, nil)
+	require.ErrorContains(t, err, "does not match")
+}
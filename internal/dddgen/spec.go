@@ -0,0 +1,636 @@
+package dddgen
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ianmuhia/kit/pkg/codegen"
+)
+
+// DomainSpec describes the shape of a domain entity. It is loaded from a
+// YAML, JSON, or CUE file passed with --spec or referenced from the project
+// file:
+//
+//	fields:
+//	  - name: title
+//	    type: string
+//	    required: true
+//	    maxLength: 200
+//	    sort: true
+//	  - name: due_at
+//	    type: time
+//	    nullable: true
+//	    filter: true
+//	children:
+//	  - name: item
+//	    fields:
+//	      - name: sku
+//	        type: string
+//	valueObjects:
+//	  - name: money
+//	    fields:
+//	      - name: amount
+//	        type: decimal
+//	jobs:
+//	  - name: send_reminder
+//	  - name: expire_stale
+//	    every: 1h
+//	cacheTTL: 10m
+type DomainSpec struct {
+	Fields       []Field    `json:"fields"`
+	Children     []PartSpec `json:"children,omitempty"`     // Child entities owned by the aggregate root
+	ValueObjects []PartSpec `json:"valueObjects,omitempty"` // Immutable value types of the domain
+	Jobs         []JobSpec  `json:"jobs,omitempty"`         // River jobs generated with --with-river
+	CacheTTL     string     `json:"cacheTTL,omitempty"`     // How long --with-cache keeps an entity, e.g. "10m"
+}
+
+// JobSpec describes a River job of the domain. Jobs with an interval are
+// also registered as periodic jobs.
+type JobSpec struct {
+	Name  string `json:"name"`            // snake_case job name, e.g. "send_email"
+	Every string `json:"every,omitempty"` // Interval of a periodic job as a Go duration, e.g. "1h"
+}
+
+// PartSpec describes a child entity or value object of the aggregate. Names
+// may be given in singular or plural form ("item" or "items"). Child entities
+// may omit fields; value objects need at least one.
+type PartSpec struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Field describes a single entity field. Only Name and Type are required;
+// the JSON tag and database column default to the snake_case field name.
+type Field struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Nullable  bool   `json:"nullable,omitempty"`
+	JSON      string `json:"json,omitempty"`
+	Column    string `json:"column,omitempty"`
+	Required  bool   `json:"required,omitempty"`
+	MinLength int    `json:"minLength,omitempty"`
+	MaxLength int    `json:"maxLength,omitempty"`
+	Default   string `json:"default,omitempty"`
+	Doc       string `json:"doc,omitempty"`
+	Filter    bool   `json:"filter,omitempty"` // List operations accept an exact-match filter on the field
+	Sort      bool   `json:"sort,omitempty"`   // List operations can be sorted by the field
+}
+
+// fieldType maps a spec type to its Go type and the import it needs.
+type fieldType struct {
+	goType     string
+	importPath string
+}
+
+// fieldTypes lists the spec types understood by the generator. Both the
+// short form ("uuid") and the Go form ("uuid.UUID") are accepted.
+var fieldTypes = map[string]fieldType{
+	"string":          {goType: "string"},
+	"int":             {goType: "int"},
+	"int32":           {goType: "int32"},
+	"int64":           {goType: "int64"},
+	"float64":         {goType: "float64"},
+	"bool":            {goType: "bool"},
+	"time":            {goType: "time.Time", importPath: "time"},
+	"time.Time":       {goType: "time.Time", importPath: "time"},
+	"uuid":            {goType: "uuid.UUID", importPath: "github.com/google/uuid"},
+	"uuid.UUID":       {goType: "uuid.UUID", importPath: "github.com/google/uuid"},
+	"decimal":         {goType: "decimal.Decimal", importPath: "github.com/shopspring/decimal"},
+	"decimal.Decimal": {goType: "decimal.Decimal", importPath: "github.com/shopspring/decimal"},
+}
+
+// listParams are the query parameters of the generated list endpoints; a
+// filter field cannot use one of them as its JSON name.
+var listParams = map[string]bool{
+	"page": true, "page_size": true, "search": true, "active": true, "sort_by": true, "sort_order": true, "after": true,
+	"name_contains": true, "created_after": true, "created_before": true, "include_deleted": true,
+}
+
+// reservedFieldNames are emitted by the templates for every entity and
+// cannot be declared in a spec.
+var reservedFieldNames = map[string]bool{
+	"ID": true, "CreatedAt": true, "UpdatedAt": true, "CreatedBy": true, "UpdatedBy": true,
+}
+
+// DefaultFields is used when no spec is given. It matches the placeholder
+// entity the generator has always produced.
+var DefaultFields = []Field{
+	{Name: "name", Type: "string", Required: true, MinLength: 3, MaxLength: 100, Doc: "Name"},
+	{Name: "description", Type: "string", MaxLength: 500, Doc: "Description"},
+	{Name: "active", Type: "bool", Default: "true", Doc: "Whether the entity is active"},
+}
+
+// LoadDomainSpec reads a domain spec file (.yaml, .yml, .json, or .cue).
+func LoadDomainSpec(path string) (*DomainSpec, error) {
+	value, err := loadCUEValue(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec DomainSpec
+	if err := value.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to decode spec %s: %w", path, err)
+	}
+	if _, err := buildFieldData(spec.Fields); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	if _, _, err := buildParts(spec.Children, spec.ValueObjects); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	if _, err := buildJobs("", spec.Jobs); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	if _, err := cacheTTLExpr(spec.CacheTTL); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// ParseAggregate parses the --aggregate flag value "root:child1,child2" into
+// the aggregate root name and its child entities.
+func ParseAggregate(s string) (string, []PartSpec, error) {
+	root, list, ok := strings.Cut(s, ":")
+	root = strings.TrimSpace(root)
+	if !ok || root == "" || strings.TrimSpace(list) == "" {
+		return "", nil, fmt.Errorf("invalid aggregate %q (want root:child1,child2)", s)
+	}
+
+	var children []PartSpec
+	for name := range strings.SplitSeq(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			children = append(children, PartSpec{Name: name})
+		}
+	}
+	return root, children, nil
+}
+
+// ParseJobs parses the --jobs flag value "send_email,expire_stale@1h" into
+// job specs; "@interval" makes a job periodic.
+func ParseJobs(s string) ([]JobSpec, error) {
+	var jobs []JobSpec
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, every, _ := strings.Cut(item, "@")
+		jobs = append(jobs, JobSpec{Name: strings.TrimSpace(name), Every: strings.TrimSpace(every)})
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("invalid jobs %q (want name1,name2@interval)", s)
+	}
+	return jobs, nil
+}
+
+// MergeParts appends the parts in extra that are not already declared in
+// parts, comparing by type name. Declarations in parts win because they may
+// carry fields.
+func MergeParts(parts, extra []PartSpec) []PartSpec {
+	declared := make(map[string]bool, len(parts))
+	for _, p := range parts {
+		declared[codegen.ToPascalCase(codegen.Singularize(p.Name))] = true
+	}
+	for _, p := range extra {
+		if !declared[codegen.ToPascalCase(codegen.Singularize(p.Name))] {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// PartData is the template view of a child entity or value object.
+type PartData struct {
+	Title        string      // Go type name, e.g. "LineItem"
+	Lower        string      // File name without extension, e.g. "line_item"
+	Plural       string      // Name of the slice on the aggregate root, e.g. "LineItems"
+	Fields       []FieldData // May be empty for child entities
+	FieldImports []string    // Extra imports needed by field types (uuid, decimal)
+	UsesTime     bool        // Some field is a time.Time
+}
+
+// partData is passed to the per-part templates.
+type partData struct {
+	TemplateData
+	Part PartData
+}
+
+// buildParts resolves the child entities and value objects of an aggregate
+// and checks that their type names are unique.
+func buildParts(children, valueObjects []PartSpec) ([]PartData, []PartData, error) {
+	seen := make(map[string]bool)
+	build := func(kind string, specs []PartSpec, requireFields bool) ([]PartData, error) {
+		parts := make([]PartData, 0, len(specs))
+		for i, spec := range specs {
+			if err := validateDomainName(spec.Name); err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", kind, i, err)
+			}
+			title := codegen.ToPascalCase(codegen.Singularize(spec.Name))
+			if seen[title] {
+				return nil, fmt.Errorf("%s[%d]: duplicate type %s", kind, i, title)
+			}
+			seen[title] = true
+
+			part := PartData{
+				Title:  title,
+				Lower:  codegen.ToSnakeCase(title),
+				Plural: codegen.Pluralize(title),
+			}
+			if len(spec.Fields) > 0 || requireFields {
+				fields, err := buildFieldData(spec.Fields)
+				if err != nil {
+					return nil, fmt.Errorf("%s[%d] %s: %w", kind, i, title, err)
+				}
+				part.Fields = fields
+				part.FieldImports = fieldImports(fields)
+				part.UsesTime = TemplateData{Fields: fields}.UsesTime()
+			}
+			parts = append(parts, part)
+		}
+		return parts, nil
+	}
+
+	childParts, err := build("children", children, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	valueParts, err := build("valueObjects", valueObjects, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return childParts, valueParts, nil
+}
+
+// JobData is the template view of a River job.
+type JobData struct {
+	Title string // Go name prefix, e.g. "SendEmail"
+	Kind  string // River job kind, e.g. "booking.send_email"
+	Every string // Go expression of the periodic interval, e.g. "30 * time.Minute"; empty for on-demand jobs
+}
+
+// buildJobs resolves the River jobs of a domain. The kind of each job is
+// prefixed with domainLower so that kinds stay unique across domains.
+func buildJobs(domainLower string, specs []JobSpec) ([]JobData, error) {
+	seen := make(map[string]bool)
+	jobs := make([]JobData, 0, len(specs))
+	for i, spec := range specs {
+		if !isSnakeCase(spec.Name) {
+			return nil, fmt.Errorf("jobs[%d]: invalid job name %q (use snake_case, e.g. send_email)", i, spec.Name)
+		}
+		title := codegen.ToPascalCase(spec.Name)
+		if seen[title] {
+			return nil, fmt.Errorf("jobs[%d]: duplicate job %s", i, spec.Name)
+		}
+		seen[title] = true
+
+		job := JobData{Title: title, Kind: codegen.ToSnakeCase(title)}
+		if domainLower != "" {
+			job.Kind = domainLower + "." + job.Kind
+		}
+		if spec.Every != "" {
+			every, err := time.ParseDuration(spec.Every)
+			if err != nil || every <= 0 {
+				return nil, fmt.Errorf("jobs[%d]: invalid interval %q of %s (want a positive duration such as 30m or 1h)", i, spec.Every, spec.Name)
+			}
+			job.Every = durationExpr(every)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// isSnakeCase reports whether s is a lowercase letter followed by lowercase
+// letters, digits, and underscores.
+func isSnakeCase(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// defaultCacheTTL is used when neither the spec nor the flags set a TTL.
+const defaultCacheTTL = 5 * time.Minute
+
+// cacheTTLExpr validates a cache TTL and returns it as a Go expression.
+func cacheTTLExpr(s string) (string, error) {
+	if s == "" {
+		return durationExpr(defaultCacheTTL), nil
+	}
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl <= 0 {
+		return "", fmt.Errorf("invalid cache TTL %q (want a positive duration such as 30s or 10m)", s)
+	}
+	return durationExpr(ttl), nil
+}
+
+// durationExpr formats d as a Go expression in the largest whole unit.
+func durationExpr(d time.Duration) string {
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+	} {
+		if d%unit.d == 0 {
+			if d == unit.d {
+				return unit.name
+			}
+			return fmt.Sprintf("%d * %s", d/unit.d, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
+// FieldData is the template view of a Field with all defaults resolved.
+type FieldData struct {
+	Name      string // Go field name, e.g. "DueAt"
+	Type      string // Go type including pointer for nullable fields, e.g. "*time.Time"
+	BaseType  string // Go type without pointer, e.g. "time.Time"
+	JSON      string // JSON tag name
+	Column    string // Database column name
+	Nullable  bool
+	Required  bool
+	MinLength int
+	MaxLength int
+	Default   string
+	Doc       string
+	Filter    bool // Exact-match filter of list operations
+	Sort      bool // Sort key of list operations
+}
+
+// ParamName returns the field name as a function parameter ("UserID" -> "userID").
+func (f FieldData) ParamName() string {
+	name := f.GraphQLName()
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	return name
+}
+
+// GraphQLName returns the field name in lower camel case ("UserID" -> "userID").
+func (f FieldData) GraphQLName() string {
+	runes := []rune(f.Name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper-- // Keep the start of the next word: "HTTPServer" -> "httpServer"
+	}
+	return strings.ToLower(string(runes[:upper])) + string(runes[upper:])
+}
+
+// GraphQLType returns the GraphQL type of the field, non-null unless the field
+// is nullable. Time, UUID, and Decimal are custom scalars.
+func (f FieldData) GraphQLType() string {
+	var t string
+	switch f.BaseType {
+	case "string":
+		t = "String"
+	case "int", "int32", "int64":
+		t = "Int"
+	case "float64":
+		t = "Float"
+	case "bool":
+		t = "Boolean"
+	case "time.Time":
+		t = "Time"
+	case "uuid.UUID":
+		t = "UUID"
+	case "decimal.Decimal":
+		t = "Decimal"
+	default:
+		t = "String"
+	}
+	if !f.Nullable {
+		t += "!"
+	}
+	return t
+}
+
+// HasEqualMethod reports whether values of the field type are compared with
+// an Equal method rather than ==.
+func (f FieldData) HasEqualMethod() bool {
+	return f.BaseType == "time.Time" || f.BaseType == "decimal.Decimal"
+}
+
+// IsString reports whether the field holds a string.
+func (f FieldData) IsString() bool { return f.BaseType == "string" }
+
+// OpenAPIType returns the OpenAPI schema type of the field.
+func (f FieldData) OpenAPIType() string {
+	switch f.BaseType {
+	case "int", "int32", "int64":
+		return "integer"
+	case "float64":
+		return "number"
+	case "bool":
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// OpenAPIFormat returns the OpenAPI schema format of the field, if any.
+func (f FieldData) OpenAPIFormat() string {
+	switch f.BaseType {
+	case "int32", "int64":
+		return f.BaseType
+	case "float64":
+		return "double"
+	case "time.Time":
+		return "date-time"
+	case "uuid.UUID":
+		return "uuid"
+	case "decimal.Decimal":
+		return "decimal"
+	default:
+		return ""
+	}
+}
+
+// ParseQuery returns a Go expression parsing the string v into the field's
+// base type, or a type convertible to it, and an error. Not used for strings.
+func (f FieldData) ParseQuery() string {
+	switch f.BaseType {
+	case "int":
+		return "strconv.Atoi(v)"
+	case "int32":
+		return "strconv.ParseInt(v, 10, 32)"
+	case "int64":
+		return "strconv.ParseInt(v, 10, 64)"
+	case "float64":
+		return "strconv.ParseFloat(v, 64)"
+	case "bool":
+		return "strconv.ParseBool(v)"
+	case "time.Time":
+		return "time.Parse(time.RFC3339, v)"
+	case "uuid.UUID":
+		return "uuid.Parse(v)"
+	case "decimal.Decimal":
+		return "decimal.NewFromString(v)"
+	default:
+		return "v, error(nil)"
+	}
+}
+
+// SQLType returns the PostgreSQL column definition of the field, including
+// NOT NULL unless the field is nullable.
+func (f FieldData) SQLType() string {
+	var t string
+	switch f.BaseType {
+	case "string":
+		t = "TEXT"
+		if f.MaxLength > 0 {
+			t = fmt.Sprintf("VARCHAR(%d)", f.MaxLength)
+		}
+	case "int", "int64":
+		t = "BIGINT"
+	case "int32":
+		t = "INTEGER"
+	case "float64":
+		t = "DOUBLE PRECISION"
+	case "bool":
+		t = "BOOLEAN"
+	case "time.Time":
+		t = "TIMESTAMPTZ"
+	case "uuid.UUID":
+		t = "UUID"
+	case "decimal.Decimal":
+		t = "NUMERIC"
+	default:
+		t = "TEXT"
+	}
+	if !f.Nullable {
+		t += " NOT NULL"
+	}
+	return t
+}
+
+// SampleValue returns a Go expression producing a valid value for the field,
+// used by the generated tests. Nullable fields get a pointer to the value.
+func (f FieldData) SampleValue() string {
+	var v string
+	switch f.BaseType {
+	case "string":
+		n := max(f.MinLength, 7)
+		if f.MaxLength > 0 && n > f.MaxLength {
+			n = f.MaxLength
+		}
+		v = fmt.Sprintf("%q", strings.Repeat("x", n))
+	case "int", "int32", "int64":
+		v = f.BaseType + "(1)"
+	case "float64":
+		v = "1.5"
+	case "bool":
+		v = "true"
+	case "time.Time":
+		v = "time.Now()"
+	case "uuid.UUID":
+		v = "uuid.New()"
+	case "decimal.Decimal":
+		v = "decimal.NewFromInt(1)"
+	}
+	if f.Nullable {
+		return fmt.Sprintf("func() *%s { v := %s; return &v }()", f.BaseType, v)
+	}
+	return v
+}
+
+// buildFieldData validates the spec fields and resolves defaults.
+func buildFieldData(fields []Field) ([]FieldData, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+
+	result := make([]FieldData, 0, len(fields))
+	seen := make(map[string]bool)
+	for i, f := range fields {
+		if err := validateDomainName(f.Name); err != nil {
+			return nil, fmt.Errorf("fields[%d]: invalid field name: %w", i, err)
+		}
+		ft, ok := fieldTypes[f.Type]
+		if !ok {
+			return nil, fmt.Errorf("fields[%d] %s: unsupported type %q (supported: %s)", i, f.Name, f.Type, strings.Join(supportedFieldTypes(), ", "))
+		}
+
+		goName := codegen.ToPascalCase(f.Name)
+		if reservedFieldNames[goName] {
+			return nil, fmt.Errorf("fields[%d]: %s is generated automatically and cannot be declared", i, goName)
+		}
+		if seen[goName] {
+			return nil, fmt.Errorf("fields[%d]: duplicate field %s", i, goName)
+		}
+		seen[goName] = true
+
+		data := FieldData{
+			Name:      goName,
+			Type:      ft.goType,
+			BaseType:  ft.goType,
+			JSON:      f.JSON,
+			Column:    f.Column,
+			Nullable:  f.Nullable,
+			Required:  f.Required,
+			MinLength: f.MinLength,
+			MaxLength: f.MaxLength,
+			Default:   f.Default,
+			Doc:       f.Doc,
+			Filter:    f.Filter,
+			Sort:      f.Sort,
+		}
+		if data.Nullable {
+			data.Type = "*" + ft.goType
+		}
+		if data.JSON == "" {
+			data.JSON = codegen.ToSnakeCase(f.Name)
+		}
+		if data.Column == "" {
+			data.Column = codegen.ToSnakeCase(f.Name)
+		}
+		if data.Doc == "" {
+			data.Doc = goName
+		}
+		if data.Filter && goName != "Active" && listParams[data.JSON] {
+			return nil, fmt.Errorf("fields[%d] %s: filter parameter %q clashes with a list query parameter; set json to another name", i, goName, data.JSON)
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// fieldImports returns the sorted, de-duplicated imports required by the
+// field types, excluding "time" which every entity template already imports.
+func fieldImports(fields []FieldData) []string {
+	seen := make(map[string]bool)
+	var imports []string
+	for _, f := range fields {
+		for _, ft := range fieldTypes {
+			if ft.goType == f.BaseType && ft.importPath != "" && ft.importPath != "time" && !seen[ft.importPath] {
+				seen[ft.importPath] = true
+				imports = append(imports, ft.importPath)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func supportedFieldTypes() []string {
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
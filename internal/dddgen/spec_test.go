@@ -0,0 +1,132 @@
+package dddgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDomainSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+fields:
+  - name: title
+    type: string
+    required: true
+    maxLength: 200
+  - name: due_at
+    type: time
+    nullable: true
+`), 0o644))
+
+	spec, err := LoadDomainSpec(path)
+	require.NoError(t, err)
+	require.Len(t, spec.Fields, 2)
+	assert.Equal(t, "title", spec.Fields[0].Name)
+	assert.Equal(t, 200, spec.Fields[0].MaxLength)
+	assert.True(t, spec.Fields[1].Nullable)
+}
+
+func TestBuildFieldData(t *testing.T) {
+	fields, err := buildFieldData([]Field{
+		{Name: "owner_id", Type: "uuid"},
+		{Name: "dueAt", Type: "time", Nullable: true},
+		{Name: "price", Type: "decimal", Column: "price_cents", JSON: "cost"},
+	})
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+
+	assert.Equal(t, "OwnerID", fields[0].Name)
+	assert.Equal(t, "uuid.UUID", fields[0].Type)
+	assert.Equal(t, "owner_id", fields[0].Column)
+
+	assert.Equal(t, "DueAt", fields[1].Name)
+	assert.Equal(t, "*time.Time", fields[1].Type)
+	assert.Equal(t, "time.Time", fields[1].BaseType)
+	assert.Equal(t, "due_at", fields[1].JSON)
+
+	assert.Equal(t, "price_cents", fields[2].Column)
+	assert.Equal(t, "cost", fields[2].JSON)
+
+	assert.Equal(t, []string{"github.com/google/uuid", "github.com/shopspring/decimal"}, fieldImports(fields))
+}
+
+func TestBuildFieldData_Invalid(t *testing.T) {
+	cases := map[string][]Field{
+		"empty":            nil,
+		"unsupported type": {{Name: "tags", Type: "[]string"}},
+		"bad name":         {{Name: "1st", Type: "string"}},
+		"reserved":         {{Name: "created_at", Type: "time"}},
+		"duplicate":        {{Name: "title", Type: "string"}, {Name: "Title", Type: "string"}},
+		"filter clash":     {{Name: "page", Type: "int", Filter: true}},
+	}
+	for name, fields := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildFieldData(fields)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseAggregate(t *testing.T) {
+	root, children, err := ParseAggregate("order:items, payments")
+	require.NoError(t, err)
+	assert.Equal(t, "order", root)
+	assert.Equal(t, []PartSpec{{Name: "items"}, {Name: "payments"}}, children)
+
+	for _, s := range []string{"order", "order:", ":items"} {
+		_, _, err := ParseAggregate(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestBuildParts(t *testing.T) {
+	children, valueObjects, err := buildParts(
+		[]PartSpec{{Name: "line_items"}, {Name: "category", Fields: []Field{{Name: "label", Type: "string"}}}},
+		[]PartSpec{{Name: "money", Fields: []Field{{Name: "amount", Type: "decimal"}, {Name: "paid_at", Type: "time"}}}},
+	)
+	require.NoError(t, err)
+
+	require.Len(t, children, 2)
+	assert.Equal(t, PartData{Title: "LineItem", Lower: "line_item", Plural: "LineItems"}, children[0])
+	assert.Equal(t, "Categories", children[1].Plural)
+
+	require.Len(t, valueObjects, 1)
+	assert.Equal(t, []string{"github.com/shopspring/decimal"}, valueObjects[0].FieldImports)
+	assert.True(t, valueObjects[0].UsesTime)
+
+	_, _, err = buildParts([]PartSpec{{Name: "item"}}, []PartSpec{{Name: "items", Fields: []Field{{Name: "a", Type: "int"}}}})
+	assert.ErrorContains(t, err, "duplicate type Item")
+
+	_, _, err = buildParts(nil, []PartSpec{{Name: "money"}})
+	assert.ErrorContains(t, err, "at least one field is required")
+}
+
+func TestFieldData_ParamName(t *testing.T) {
+	for name, want := range map[string]string{"Name": "name", "ID": "id", "UserID": "userID", "HTTPServer": "httpServer", "Type": "typeValue"} {
+		assert.Equal(t, want, FieldData{Name: name}.ParamName())
+	}
+}
+
+func TestFieldData_SQLType(t *testing.T) {
+	assert.Equal(t, "VARCHAR(50) NOT NULL", FieldData{BaseType: "string", MaxLength: 50}.SQLType())
+	assert.Equal(t, "TEXT NOT NULL", FieldData{BaseType: "string"}.SQLType())
+	assert.Equal(t, "TIMESTAMPTZ", FieldData{BaseType: "time.Time", Nullable: true}.SQLType())
+	assert.Equal(t, "NUMERIC NOT NULL", FieldData{BaseType: "decimal.Decimal"}.SQLType())
+}
+
+func TestFieldData_GraphQLType(t *testing.T) {
+	assert.Equal(t, "String!", FieldData{BaseType: "string"}.GraphQLType())
+	assert.Equal(t, "Int", FieldData{BaseType: "int64", Nullable: true}.GraphQLType())
+	assert.Equal(t, "Time!", FieldData{BaseType: "time.Time"}.GraphQLType())
+	assert.Equal(t, "type", FieldData{Name: "Type"}.GraphQLName())
+}
+
+func TestFieldData_ParseQuery(t *testing.T) {
+	assert.Equal(t, "strconv.Atoi(v)", FieldData{BaseType: "int"}.ParseQuery())
+	assert.Equal(t, "time.Parse(time.RFC3339, v)", FieldData{BaseType: "time.Time", Nullable: true}.ParseQuery())
+	assert.Equal(t, "uuid.Parse(v)", FieldData{BaseType: "uuid.UUID"}.ParseQuery())
+}
@@ -0,0 +1,209 @@
+package dddgen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// maxTypeErrors is how many compiler errors a failed type check reports.
+const maxTypeErrors = 10
+
+// missingImportPattern matches the type checker error for an import that
+// could not be loaded and captures its path.
+var missingImportPattern = regexp.MustCompile(`could not import (\S+)`)
+
+// missingModuleMessages are go list errors caused by dependencies the module
+// does not require or has not downloaded yet, rather than by the code.
+var missingModuleMessages = []string{
+	"no required module provides package",
+	"cannot find module providing package",
+	"missing go.sum entry",
+	"updates to go.mod needed",
+}
+
+// typeCheckResult is the outcome of type-checking the generated packages.
+type typeCheckResult struct {
+	Errors  []string // Compiler errors, "file:line:col: message"
+	Missing []string // Imports that could not be loaded, e.g. before go mod tidy
+}
+
+// typeCheck loads and type-checks the packages below domainDir, tests
+// included, in the module that contains it. Errors caused by dependencies
+// that are missing from the module are left out and their imports returned
+// in Missing instead: generated code is typically checked before the module
+// requires everything it imports.
+func typeCheck(domainDir string) (*typeCheckResult, error) {
+	absDir, err := filepath.Abs(domainDir)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		// Dependencies are checked from source too: their export data is
+		// written by the go command, whose format may be newer than this
+		// build of go/packages understands
+		Mode:  packages.LoadAllSyntax,
+		Dir:   absDir,
+		Tests: true,
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &typeCheckResult{}
+	seen := make(map[string]bool)
+	missing := make(map[string]bool)
+	var pending []packages.Error
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		files = append(files, pkg.Syntax...)
+		for _, e := range pkg.Errors {
+			if seen[e.Error()] {
+				// Test variants repeat the errors of the package
+				continue
+			}
+			seen[e.Error()] = true
+			switch {
+			case missingImportPattern.MatchString(e.Msg):
+				missing[strings.Trim(missingImportPattern.FindStringSubmatch(e.Msg)[1], `"`)] = true
+			case isMissingModule(e.Msg):
+			default:
+				pending = append(pending, e)
+			}
+		}
+	}
+
+	// Uses of a missing import are undefined; they say nothing about the code
+	undefined := missingImportNames(files, missing, pkgs)
+	for _, e := range pending {
+		if name, ok := strings.CutPrefix(e.Msg, "undefined: "); ok && undefined[errorFile(e)][name] {
+			continue
+		}
+		result.Errors = append(result.Errors, relativeError(e, absDir))
+	}
+	for imp := range missing {
+		result.Missing = append(result.Missing, imp)
+	}
+	sort.Strings(result.Missing)
+	return result, nil
+}
+
+// isMissingModule reports whether msg is a go list error about a dependency
+// the module does not provide.
+func isMissingModule(msg string) bool {
+	for _, m := range missingModuleMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingImportNames returns, per file name, the names the missing imports
+// are referred to by in that file.
+func missingImportNames(files []*ast.File, missing map[string]bool, pkgs []*packages.Package) map[string]map[string]bool {
+	names := make(map[string]map[string]bool)
+	if len(missing) == 0 {
+		return names
+	}
+	fset := pkgs[0].Fset
+	for _, file := range files {
+		filename := fset.Position(file.Pos()).Filename
+		for _, spec := range file.Imports {
+			imp, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || !missing[imp] {
+				continue
+			}
+			if names[filename] == nil {
+				names[filename] = make(map[string]bool)
+			}
+			if spec.Name != nil {
+				names[filename][spec.Name.Name] = true
+			} else {
+				names[filename][guessPackageName(imp)] = true
+			}
+		}
+	}
+	return names
+}
+
+// guessPackageName returns the name a package is conventionally declared
+// with: the last element of its import path without a major version suffix
+// or a go- prefix, e.g. "redis" for github.com/redis/go-redis/v9.
+func guessPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.Index(name, ".v"); i > 0 {
+		// gopkg.in/yaml.v3
+		name = name[:i]
+	}
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// errorFile returns the file name of the position of e, or "".
+func errorFile(e packages.Error) string {
+	file, _, _ := strings.Cut(e.Pos, ":")
+	return file
+}
+
+// relativeError formats e with its file relative to dir.
+func relativeError(e packages.Error, dir string) string {
+	if e.Pos == "" {
+		return e.Msg
+	}
+	pos := e.Pos
+	if rel, err := filepath.Rel(dir, pos); err == nil && !strings.HasPrefix(rel, "..") {
+		pos = rel
+	}
+	return pos + ": " + e.Msg
+}
+
+// verify type-checks the generated domain when it is part of a module.
+// Compiler errors are returned: they are bugs in the templates. Imports
+// the module does not provide yet are only logged.
+func (g *Generator) verify() error {
+	domainDir := filepath.Join(g.config.OutputDir, g.data.DomainLower)
+	if _, _, err := findModule(domainDir); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		g.logger.Debug("not type-checking the domain: no go.mod above it", slog.String("path", domainDir))
+		return nil
+	}
+
+	result, err := typeCheck(domainDir)
+	if err != nil {
+		g.logger.Warn("could not type-check the generated domain", slog.String("error", err.Error()))
+		return nil
+	}
+	if len(result.Missing) > 0 {
+		g.logger.Warn("type-checked without dependencies the module does not provide yet; run go mod tidy",
+			slog.Any("imports", result.Missing))
+	}
+	if len(result.Errors) == 0 {
+		return nil
+	}
+
+	msgs := result.Errors
+	if len(msgs) > maxTypeErrors {
+		msgs = append(msgs[:maxTypeErrors:maxTypeErrors], fmt.Sprintf("(and %d more)", len(result.Errors)-maxTypeErrors))
+	}
+	source := "the ddd-gen templates"
+	if g.config.TemplatesDir != "" {
+		source += " or the overrides in " + g.config.TemplatesDir
+	}
+	return fmt.Errorf("generated code in %s does not compile, a bug in %s:\n\t%s", domainDir, source, strings.Join(msgs, "\n\t"))
+}
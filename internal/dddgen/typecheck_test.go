@@ -0,0 +1,88 @@
+package dddgen
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuessPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"github.com/danielgtaylor/huma/v2":   "huma",
+		"github.com/redis/go-redis/v9":       "redis",
+		"github.com/jackc/pgx/v5/pgxpool":    "pgxpool",
+		"go.temporal.io/sdk/workflow":        "workflow",
+		"gopkg.in/yaml.v3":                   "yaml",
+		"github.com/jellydator/validation":   "validation",
+		"github.com/ThreeDotsLabs/watermill": "watermill",
+	} {
+		assert.Equal(t, want, guessPackageName(path), path)
+	}
+}
+
+func TestGenerate_typeCheckFailure(t *testing.T) {
+	// Dependencies stay missing; their errors must not be reported
+	t.Setenv("GOFLAGS", "-mod=readonly")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module github.com/x/y\n\ngo 1.22\n"), 0o644))
+
+	templates := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(templates, "domain"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "domain", "validation.go.tmpl"),
+		[]byte("package domain\n\nvar maxNameLength int = \"200\"\n"), 0o644))
+
+	g, err := New(Config{DomainName: "order", OutputDir: filepath.Join(root, "internal"), TemplatesDir: templates})
+	require.NoError(t, err)
+	err = g.WithOutput(&bytes.Buffer{}).Generate()
+	require.ErrorContains(t, err, "does not compile")
+	assert.ErrorContains(t, err, "overrides in "+templates)
+	assert.ErrorContains(t, err, `validation.go:3:25: cannot use "200"`)
+	assert.NotContains(t, err.Error(), "huma", "errors of missing dependencies are left out")
+}
+
+// TestGenerate_typeChecksDefaultOutput builds the output of a plain
+// `ddd-gen -d booking --with-tests` in a module that provides its
+// dependencies.
+func TestGenerate_typeChecksDefaultOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("type-checks the generated code and its dependencies")
+	}
+	kit, err := filepath.Abs(filepath.Join("..", ".."))
+	require.NoError(t, err)
+	sum, err := os.ReadFile(filepath.Join(kit, "go.sum"))
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	goMod := `module github.com/x/y
+
+go 1.26
+
+require (
+	github.com/danielgtaylor/huma/v2 v2.37.2
+	github.com/ianmuhia/kit v0.0.0
+	github.com/jellydator/validation v1.2.0
+)
+
+replace github.com/ianmuhia/kit => ` + kit + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.sum"), sum, 0o644))
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	var logs bytes.Buffer
+	g, err := New(Config{DomainName: "booking", OutputDir: filepath.Join(root, "internal"), WithTests: true})
+	require.NoError(t, err)
+	require.Equal(t, RouterHuma, g.config.HTTPRouter)
+	require.NoError(t, g.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))).WithOutput(&bytes.Buffer{}).Generate())
+	if bytes.Contains(logs.Bytes(), []byte("run go mod tidy")) {
+		t.Skipf("dependencies of the generated code are unavailable:\n%s", logs.String())
+	}
+
+	result, err := typeCheck(filepath.Join(root, "internal", "booking"))
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+}
@@ -0,0 +1,53 @@
+package authzgen
+
+import (
+	"slices"
+	"strings"
+)
+
+// cacheDependency is an entry of the generated cacheDependents map: the
+// definitions whose permissions may change when a relationship on a resource
+// of Type is written.
+type cacheDependency struct {
+	Type       string   // Go name of the definition, e.g. "Team"
+	Dependents []string // Go names of the definitions, in name order
+}
+
+// cacheDependencies returns, for each definition, itself and every definition
+// with a permission whose walks traverse one of its relations.
+func cacheDependencies(definitions []Definition) []cacheDependency {
+	goNames := make(map[string]string, len(definitions))
+	for _, def := range definitions {
+		goNames[def.FullName] = ToPascalCase(def.Name)
+	}
+
+	dependents := make(map[string][]string, len(definitions))
+	for _, def := range definitions {
+		name := goNames[def.FullName]
+		dependents[name] = append(dependents[name], name)
+	}
+	for _, def := range definitions {
+		for _, perm := range def.Permissions {
+			// Subtracted relations change the permission as much as granting ones
+			for _, walk := range slices.Concat(perm.Walks, perm.Excludes) {
+				for _, step := range walk {
+					ns, _, _ := strings.Cut(step, "#")
+					t, ok := goNames[ns]
+					if ok && !slices.Contains(dependents[t], goNames[def.FullName]) {
+						dependents[t] = append(dependents[t], goNames[def.FullName])
+					}
+				}
+			}
+		}
+	}
+
+	deps := make([]cacheDependency, 0, len(definitions))
+	for _, def := range definitions {
+		t := goNames[def.FullName]
+		names := dependents[t]
+		slices.Sort(names)
+		deps = append(deps, cacheDependency{Type: t, Dependents: names})
+	}
+	slices.SortFunc(deps, func(a, b cacheDependency) int { return strings.Compare(a.Type, b.Type) })
+	return deps
+}
@@ -0,0 +1,328 @@
+package authzgen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Change is a difference between two versions of a schema found by Diff.
+type Change struct {
+	Subject string // What changed, e.g. "document", "document#viewer", or "caveat ip_allowlist"
+	Message string
+	// Breaking is set when code or relationships written against the old
+	// schema may fail against the new one: a definition, relation,
+	// permission, or caveat was removed or renamed, a relation became a
+	// permission or the reverse, or a relation no longer allows some subject.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("%s: %s (breaking)", c.Subject, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Subject, c.Message)
+}
+
+// Diff compares two versions of a schema and returns their differences,
+// definitions in name order. A removed relation or permission whose subject
+// types or expression match an added one in the same definition is
+// reported as renamed.
+func Diff(old, cur *Schema) []Change {
+	var changes []Change
+
+	oldDefs := make(map[string]Definition, len(old.Definitions))
+	for _, def := range old.Definitions {
+		oldDefs[def.FullName] = def
+	}
+	curDefs := make(map[string]Definition, len(cur.Definitions))
+	for _, def := range cur.Definitions {
+		curDefs[def.FullName] = def
+	}
+	for _, name := range sortedKeys(oldDefs, curDefs) {
+		o, hadOld := oldDefs[name]
+		c, hasCur := curDefs[name]
+		switch {
+		case !hadOld:
+			changes = append(changes, Change{Subject: name, Message: "definition added"})
+		case !hasCur:
+			changes = append(changes, Change{Subject: name, Message: "definition removed", Breaking: true})
+		default:
+			changes = append(changes, diffDefinition(o, c)...)
+		}
+	}
+
+	oldCaveats := make(map[string]Caveat, len(old.Caveats))
+	for _, cv := range old.Caveats {
+		oldCaveats[cv.FullName] = cv
+	}
+	curCaveats := make(map[string]Caveat, len(cur.Caveats))
+	for _, cv := range cur.Caveats {
+		curCaveats[cv.FullName] = cv
+	}
+	for _, name := range sortedKeys(oldCaveats, curCaveats) {
+		o, hadOld := oldCaveats[name]
+		c, hasCur := curCaveats[name]
+		subject := "caveat " + name
+		switch {
+		case !hadOld:
+			changes = append(changes, Change{Subject: subject, Message: "caveat added"})
+		case !hasCur:
+			changes = append(changes, Change{Subject: subject, Message: "caveat removed", Breaking: true})
+		case !slices.Equal(o.Parameters, c.Parameters):
+			changes = append(changes, Change{Subject: subject, Message: fmt.Sprintf("parameters changed from (%s) to (%s)", formatParameters(o.Parameters), formatParameters(c.Parameters)), Breaking: true})
+		case o.Expression != c.Expression:
+			changes = append(changes, Change{Subject: subject, Message: fmt.Sprintf("expression changed from %q to %q", o.Expression, c.Expression)})
+		}
+	}
+	return changes
+}
+
+// diffDefinition compares the relations and permissions of two versions of
+// a definition.
+func diffDefinition(old, cur Definition) []Change {
+	var changes []Change
+	subject := func(name string) string { return cur.FullName + "#" + name }
+
+	// Relations and permissions by name, to spot one turning into the other
+	oldRels := make(map[string]Relation)
+	for _, r := range old.Relations {
+		oldRels[r.Name] = r
+	}
+	oldPerms := make(map[string]Permission)
+	for _, p := range old.Permissions {
+		oldPerms[p.Name] = p
+	}
+	curRels := make(map[string]Relation)
+	for _, r := range cur.Relations {
+		curRels[r.Name] = r
+	}
+	curPerms := make(map[string]Permission)
+	for _, p := range cur.Permissions {
+		curPerms[p.Name] = p
+	}
+
+	var removedRels, addedRels []Relation
+	for _, r := range old.Relations {
+		c, ok := curRels[r.Name]
+		switch {
+		case ok:
+			changes = append(changes, diffRelation(subject(r.Name), r, c)...)
+		case hasPermission(curPerms, r.Name):
+			changes = append(changes, Change{Subject: subject(r.Name), Message: "relation became a permission", Breaking: true})
+		default:
+			removedRels = append(removedRels, r)
+		}
+	}
+	for _, r := range cur.Relations {
+		if _, ok := oldRels[r.Name]; !ok && !hasPermission(oldPerms, r.Name) {
+			addedRels = append(addedRels, r)
+		}
+	}
+
+	var removedPerms, addedPerms []Permission
+	for _, p := range old.Permissions {
+		c, ok := curPerms[p.Name]
+		switch {
+		case ok:
+			if p.Expression != c.Expression {
+				changes = append(changes, Change{Subject: subject(p.Name), Message: fmt.Sprintf("expression changed from %q to %q", p.Expression, c.Expression)})
+			}
+		case hasRelation(curRels, p.Name):
+			changes = append(changes, Change{Subject: subject(p.Name), Message: "permission became a relation", Breaking: true})
+		default:
+			removedPerms = append(removedPerms, p)
+		}
+	}
+	for _, p := range cur.Permissions {
+		if _, ok := oldPerms[p.Name]; !ok && !hasRelation(oldRels, p.Name) {
+			addedPerms = append(addedPerms, p)
+		}
+	}
+
+	// A removed relation is renamed to an added one allowing the same
+	// subjects, and a removed permission to an added one with the same
+	// expression once relations are renamed
+	renamed := make(map[string]string)
+	relRenames := pairRenames(removedRels, addedRels, sameSubjects)
+	for i, r := range removedRels {
+		if j, ok := relRenames[i]; ok {
+			renamed[r.Name] = addedRels[j].Name
+			changes = append(changes, Change{Subject: subject(r.Name), Message: "relation renamed to " + addedRels[j].Name, Breaking: true})
+		} else {
+			changes = append(changes, Change{Subject: subject(r.Name), Message: "relation removed", Breaking: true})
+		}
+	}
+	for j, r := range addedRels {
+		if !slices.Contains(slices.Collect(maps.Values(relRenames)), j) {
+			changes = append(changes, Change{Subject: subject(r.Name), Message: "relation added"})
+		}
+	}
+
+	permRenames := pairRenames(removedPerms, addedPerms, func(o, c Permission) bool {
+		return sameTree(o.Tree, c.Tree, renamed)
+	})
+	for i, p := range removedPerms {
+		if j, ok := permRenames[i]; ok {
+			changes = append(changes, Change{Subject: subject(p.Name), Message: "permission renamed to " + addedPerms[j].Name, Breaking: true})
+		} else {
+			changes = append(changes, Change{Subject: subject(p.Name), Message: "permission removed", Breaking: true})
+		}
+	}
+	for j, p := range addedPerms {
+		if !slices.Contains(slices.Collect(maps.Values(permRenames)), j) {
+			changes = append(changes, Change{Subject: subject(p.Name), Message: "permission added"})
+		}
+	}
+	return changes
+}
+
+// pairRenames pairs removed and added items by index when they match each
+// other and nothing else, so that an ambiguous rename is reported as a
+// removal and an addition.
+func pairRenames[T any](removed, added []T, match func(old, cur T) bool) map[int]int {
+	pairs := make(map[int]int)
+	for i, o := range removed {
+		candidates := 0
+		var j int
+		for k, c := range added {
+			if match(o, c) {
+				candidates++
+				j = k
+			}
+		}
+		if candidates != 1 {
+			continue
+		}
+		rivals := 0
+		for _, other := range removed {
+			if match(other, added[j]) {
+				rivals++
+			}
+		}
+		if rivals == 1 {
+			pairs[i] = j
+		}
+	}
+	return pairs
+}
+
+// sameTree reports whether two permission expressions are the same once the
+// relations of the old one are renamed.
+func sameTree(old, cur *ExprNode, renamed map[string]string) bool {
+	if old == nil || cur == nil {
+		return old == cur
+	}
+	rel := old.Relation
+	if to, ok := renamed[rel]; ok {
+		rel = to
+	}
+	if old.Kind != cur.Kind || rel != cur.Relation || old.Target != cur.Target ||
+		old.Function != cur.Function || len(old.Children) != len(cur.Children) {
+		return false
+	}
+	for i := range old.Children {
+		if !sameTree(old.Children[i], cur.Children[i], renamed) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffRelation compares the subjects two versions of a relation allow.
+// Allowing fewer subject types or caveats is breaking, since relationships
+// already written or still being written with them are rejected.
+func diffRelation(subject string, old, cur Relation) []Change {
+	var changes []Change
+	oldTypes, curTypes := relationSubjects(old), relationSubjects(cur)
+	if removed := missing(oldTypes, curTypes); len(removed) > 0 {
+		changes = append(changes, Change{Subject: subject, Message: "subject types narrowed: no longer allows " + strings.Join(removed, ", "), Breaking: true})
+	}
+	if added := missing(curTypes, oldTypes); len(added) > 0 {
+		changes = append(changes, Change{Subject: subject, Message: "subject types widened: now allows " + strings.Join(added, ", ")})
+	}
+	for _, o := range old.Types {
+		c, ok := cur.FindType(o)
+		if !ok {
+			continue
+		}
+		if removed := missing(o.Caveats, c.Caveats); len(removed) > 0 {
+			changes = append(changes, Change{Subject: subject, Message: "caveats narrowed: " + o.String() + " no longer allows " + strings.Join(removed, ", "), Breaking: true})
+		}
+		if added := missing(c.Caveats, o.Caveats); len(added) > 0 {
+			changes = append(changes, Change{Subject: subject, Message: "caveats widened: " + o.String() + " now allows " + strings.Join(added, ", ")})
+		}
+	}
+	return changes
+}
+
+// relationSubjects returns the subject types of r in schema notation.
+func relationSubjects(r Relation) []string {
+	subjects := make([]string, len(r.Types))
+	for i, t := range r.Types {
+		subjects[i] = t.String()
+	}
+	return subjects
+}
+
+// sameSubjects reports whether two relations allow the same subjects with
+// the same caveats.
+func sameSubjects(a, b Relation) bool {
+	if len(missing(relationSubjects(a), relationSubjects(b))) > 0 ||
+		len(missing(relationSubjects(b), relationSubjects(a))) > 0 {
+		return false
+	}
+	for _, t := range a.Types {
+		o, _ := b.FindType(t)
+		if len(missing(t.Caveats, o.Caveats)) > 0 || len(missing(o.Caveats, t.Caveats)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// missing returns the elements of a not in b.
+func missing(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func hasRelation(rels map[string]Relation, name string) bool {
+	_, ok := rels[name]
+	return ok
+}
+
+func hasPermission(perms map[string]Permission, name string) bool {
+	_, ok := perms[name]
+	return ok
+}
+
+func formatParameters(params []CaveatParameter) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.Name + " " + p.Type
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys returns the keys of both maps in order.
+func sortedKeys[V any](a, b map[string]V) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
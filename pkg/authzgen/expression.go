@@ -0,0 +1,278 @@
+package authzgen
+
+import (
+	"slices"
+	"strings"
+
+	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// setOp identifies the operator of a set operation in a permission expression.
+type setOp int
+
+const (
+	opNone setOp = iota // A single operand
+	opUnion
+	opIntersection
+	opExclusion
+)
+
+// formatRewrite renders a permission's userset rewrite back into schema
+// syntax, e.g. "(writer & approved) - banned". A nested operation is
+// parenthesized whenever its operator differs from the enclosing one, so the
+// expression reads the same whatever the reader assumes about precedence
+// (in the schema language + binds tighter than &, which binds tighter
+// than -), and it parses back to the same rewrite.
+func formatRewrite(rewrite *corev1.UsersetRewrite) string {
+	expr, _ := formatRewriteOp(rewrite)
+	return expr
+}
+
+// formatRewriteOp renders rewrite and returns its top-level operator.
+func formatRewriteOp(rewrite *corev1.UsersetRewrite) (string, setOp) {
+	var (
+		set *corev1.SetOperation
+		sep string
+		op  setOp
+	)
+	switch {
+	case rewrite.GetUnion() != nil:
+		set, sep, op = rewrite.GetUnion(), " + ", opUnion
+	case rewrite.GetIntersection() != nil:
+		set, sep, op = rewrite.GetIntersection(), " & ", opIntersection
+	case rewrite.GetExclusion() != nil:
+		set, sep, op = rewrite.GetExclusion(), " - ", opExclusion
+	default:
+		return "", opNone
+	}
+
+	children := set.GetChild()
+	if len(children) == 1 {
+		return formatChild(children[0])
+	}
+	parts := make([]string, len(children))
+	for i, child := range children {
+		expr, childOp := formatChild(child)
+		// Exclusion is left-associative: a - b - c is (a - b) - c, so only
+		// the first operand may be an unparenthesized exclusion
+		if childOp != opNone && (childOp != op || (op == opExclusion && i > 0)) {
+			expr = "(" + expr + ")"
+		}
+		parts[i] = expr
+	}
+	return strings.Join(parts, sep), op
+}
+
+// formatChild renders one operand of a set operation.
+func formatChild(child *corev1.SetOperation_Child) (string, setOp) {
+	switch c := child.GetChildType().(type) {
+	case *corev1.SetOperation_Child_ComputedUserset:
+		return c.ComputedUserset.GetRelation(), opNone
+	case *corev1.SetOperation_Child_TupleToUserset:
+		ttu := c.TupleToUserset
+		return ttu.GetTupleset().GetRelation() + "->" + ttu.GetComputedUserset().GetRelation(), opNone
+	case *corev1.SetOperation_Child_FunctionedTupleToUserset:
+		fttu := c.FunctionedTupleToUserset
+		fn := "any"
+		if fttu.GetFunction() == corev1.FunctionedTupleToUserset_FUNCTION_ALL {
+			fn = "all"
+		}
+		return fttu.GetTupleset().GetRelation() + "." + fn + "(" + fttu.GetComputedUserset().GetRelation() + ")", opNone
+	case *corev1.SetOperation_Child_UsersetRewrite:
+		return formatRewriteOp(c.UsersetRewrite)
+	case *corev1.SetOperation_Child_XNil:
+		return "nil", opNone
+	case *corev1.SetOperation_Child_XSelf:
+		return "self", opNone
+	case *corev1.SetOperation_Child_XThis:
+		return "_this", opNone
+	}
+	return "", opNone
+}
+
+// buildTree converts a permission's userset rewrite into an ExprNode tree.
+func buildTree(rewrite *corev1.UsersetRewrite) *ExprNode {
+	var (
+		set  *corev1.SetOperation
+		kind string
+	)
+	switch {
+	case rewrite.GetUnion() != nil:
+		set, kind = rewrite.GetUnion(), ExprUnion
+	case rewrite.GetIntersection() != nil:
+		set, kind = rewrite.GetIntersection(), ExprIntersection
+	case rewrite.GetExclusion() != nil:
+		set, kind = rewrite.GetExclusion(), ExprExclusion
+	default:
+		return &ExprNode{Kind: ExprNil}
+	}
+
+	children := set.GetChild()
+	if len(children) == 1 {
+		return buildChildTree(children[0])
+	}
+	node := &ExprNode{Kind: kind}
+	for _, child := range children {
+		node.Children = append(node.Children, buildChildTree(child))
+	}
+	return node
+}
+
+func buildChildTree(child *corev1.SetOperation_Child) *ExprNode {
+	switch c := child.GetChildType().(type) {
+	case *corev1.SetOperation_Child_ComputedUserset:
+		return &ExprNode{Kind: ExprRelation, Relation: c.ComputedUserset.GetRelation()}
+	case *corev1.SetOperation_Child_TupleToUserset:
+		ttu := c.TupleToUserset
+		return &ExprNode{Kind: ExprArrow, Relation: ttu.GetTupleset().GetRelation(), Target: ttu.GetComputedUserset().GetRelation()}
+	case *corev1.SetOperation_Child_FunctionedTupleToUserset:
+		fttu := c.FunctionedTupleToUserset
+		fn := "any"
+		if fttu.GetFunction() == corev1.FunctionedTupleToUserset_FUNCTION_ALL {
+			fn = "all"
+		}
+		return &ExprNode{Kind: ExprArrow, Relation: fttu.GetTupleset().GetRelation(), Target: fttu.GetComputedUserset().GetRelation(), Function: fn}
+	case *corev1.SetOperation_Child_UsersetRewrite:
+		return buildTree(c.UsersetRewrite)
+	case *corev1.SetOperation_Child_XSelf:
+		return &ExprNode{Kind: ExprSelf}
+	}
+	return &ExprNode{Kind: ExprNil}
+}
+
+// walker resolves the relation paths permissions traverse.
+type walker struct {
+	namespaces map[string]*corev1.NamespaceDefinition
+}
+
+func newWalker(defs []*corev1.NamespaceDefinition) *walker {
+	w := &walker{namespaces: make(map[string]*corev1.NamespaceDefinition, len(defs))}
+	for _, ns := range defs {
+		w.namespaces[ns.Name] = ns
+	}
+	return w
+}
+
+// walkSet holds the walks of an expression: those that may grant it and
+// those whose subjects it subtracts.
+type walkSet struct {
+	grants   [][]string
+	excludes [][]string
+}
+
+// add appends the walks of more not already in ws; all of them are
+// exclusions when exclude is set.
+func (ws *walkSet) add(more walkSet, exclude bool) {
+	if exclude {
+		ws.excludes = appendWalks(ws.excludes, more.grants)
+	} else {
+		ws.grants = appendWalks(ws.grants, more.grants)
+	}
+	ws.excludes = appendWalks(ws.excludes, more.excludes)
+}
+
+// walks returns the paths from the permission to the relations that hold
+// its subjects, and separately the paths to the relations whose subjects it
+// subtracts: those of the operands after the first of an exclusion. A
+// referenced permission is expanded into its own walks, so
+// "admin = parent->org_admin" with "org_admin = org->admin" on the parent
+// walks document#parent, folder#org, org#admin: the chain SpiceDB does not
+// allow writing as parent->org->admin directly. A path that reaches a
+// permission already being expanded ends at that permission.
+func (w *walker) walks(nsName, permission string) (grants, excludes [][]string) {
+	ws := w.walkRelation(nsName, permission, map[string]bool{})
+	return ws.grants, ws.excludes
+}
+
+// walkRelation returns the walks of the relation or permission name on
+// nsName. active holds the permissions being expanded, to stop recursion.
+func (w *walker) walkRelation(nsName, name string, active map[string]bool) walkSet {
+	step := nsName + "#" + name
+	rel := w.relation(nsName, name)
+	if rel == nil {
+		return walkSet{} // SpiceDB skips arrows to types that lack the relation
+	}
+	if rel.UsersetRewrite == nil || active[step] {
+		return walkSet{grants: [][]string{{step}}}
+	}
+
+	active[step] = true
+	defer delete(active, step)
+	return w.walkRewrite(nsName, rel.UsersetRewrite, active)
+}
+
+func (w *walker) walkRewrite(nsName string, rewrite *corev1.UsersetRewrite, active map[string]bool) walkSet {
+	var set *corev1.SetOperation
+	exclusion := false
+	switch {
+	case rewrite.GetUnion() != nil:
+		set = rewrite.GetUnion()
+	case rewrite.GetIntersection() != nil:
+		set = rewrite.GetIntersection()
+	case rewrite.GetExclusion() != nil:
+		set, exclusion = rewrite.GetExclusion(), true
+	}
+
+	var ws walkSet
+	for i, child := range set.GetChild() {
+		// Only the base operand of an exclusion grants; the others subtract
+		exclude := exclusion && i > 0
+		switch c := child.GetChildType().(type) {
+		case *corev1.SetOperation_Child_ComputedUserset:
+			ws.add(w.walkRelation(nsName, c.ComputedUserset.GetRelation(), active), exclude)
+		case *corev1.SetOperation_Child_TupleToUserset:
+			ws.add(w.walkArrow(nsName, c.TupleToUserset.GetTupleset().GetRelation(), c.TupleToUserset.GetComputedUserset().GetRelation(), active), exclude)
+		case *corev1.SetOperation_Child_FunctionedTupleToUserset:
+			ws.add(w.walkArrow(nsName, c.FunctionedTupleToUserset.GetTupleset().GetRelation(), c.FunctionedTupleToUserset.GetComputedUserset().GetRelation(), active), exclude)
+		case *corev1.SetOperation_Child_UsersetRewrite:
+			ws.add(w.walkRewrite(nsName, c.UsersetRewrite, active), exclude)
+		}
+	}
+	return ws
+}
+
+// walkArrow returns the walks of tupleset->computed: the tupleset relation
+// followed by the walks of computed on each subject type of the tupleset.
+func (w *walker) walkArrow(nsName, tupleset, computed string, active map[string]bool) walkSet {
+	step := nsName + "#" + tupleset
+	rel := w.relation(nsName, tupleset)
+	if rel == nil {
+		return walkSet{}
+	}
+
+	var ws walkSet
+	var seen []string
+	for _, ar := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+		if slices.Contains(seen, ar.Namespace) {
+			continue
+		}
+		seen = append(seen, ar.Namespace)
+		target := w.walkRelation(ar.Namespace, computed, active)
+		for _, walk := range target.grants {
+			ws.grants = append(ws.grants, append([]string{step}, walk...))
+		}
+		for _, walk := range target.excludes {
+			ws.excludes = append(ws.excludes, append([]string{step}, walk...))
+		}
+	}
+	return ws
+}
+
+// relation finds the relation or permission name on nsName.
+func (w *walker) relation(nsName, name string) *corev1.Relation {
+	ns, ok := w.namespaces[nsName]
+	if !ok {
+		return nil
+	}
+	return findRelation(ns, name)
+}
+
+// appendWalks appends the walks not already in walks.
+func appendWalks(walks, more [][]string) [][]string {
+	for _, walk := range more {
+		if !slices.ContainsFunc(walks, func(w []string) bool { return slices.Equal(w, walk) }) {
+			walks = append(walks, walk)
+		}
+	}
+	return walks
+}
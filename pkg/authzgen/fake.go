@@ -0,0 +1,186 @@
+package authzgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fakeCase is a row of the generated table-driven test of Fake: the
+// relationships along one walk of a permission, and whether they grant it.
+type fakeCase struct {
+	Name          string
+	Relationships []string // E.g. "document:document-0#parent@folder:folder-1"
+	Definition    string   // Go name of the resource's definition, e.g. "Document"
+	ResourceID    string
+	Permission    string
+	SubjectType   string
+	SubjectID     string
+	Allowed       bool
+}
+
+// fakeCases returns the test rows of every permission: one denying the
+// permission without relationships and, for permissions that only combine
+// their operands with unions, one granting it through each walk.
+func fakeCases(definitions []Definition) []fakeCase {
+	byName := make(map[string]Definition, len(definitions))
+	for _, def := range definitions {
+		byName[def.FullName] = def
+	}
+
+	var cases []fakeCase
+	for _, def := range definitions {
+		for _, perm := range def.Permissions {
+			var walkCases []fakeCase
+			for _, walk := range perm.Walks {
+				if c, ok := walkCase(byName, walk); ok {
+					c.Name = fmt.Sprintf("%s %s via %s", def.Name, perm.Name, strings.Join(walk, " > "))
+					c.Definition = ToPascalCase(def.Name)
+					c.Permission = perm.Name
+					c.Allowed = true
+					walkCases = append(walkCases, c)
+				}
+			}
+
+			denied := fakeCase{
+				Name:        fmt.Sprintf("%s %s without relationships", def.Name, perm.Name),
+				Definition:  ToPascalCase(def.Name),
+				ResourceID:  def.Name + "-0",
+				Permission:  perm.Name,
+				SubjectType: def.Name,
+				SubjectID:   "subject",
+			}
+			if len(walkCases) > 0 {
+				denied.SubjectType = walkCases[0].SubjectType
+			}
+			cases = append(cases, denied)
+			// A single walk only grants a permission without intersections
+			// or exclusions
+			if monotonic(byName, def.FullName, perm.Name, map[string]bool{}) {
+				cases = append(cases, walkCases...)
+			}
+		}
+	}
+	return cases
+}
+
+// walkCase builds the relationships of walk, from object 0 of the first
+// definition to a subject of the last relation's first direct subject type.
+// It returns false when the walk ends at a permission (a recursive walk) or
+// at a relation without a direct subject type.
+func walkCase(byName map[string]Definition, walk []string) (fakeCase, bool) {
+	var c fakeCase
+	object := func(ns string, i int) string {
+		_, name := splitNamespace(ns)
+		return name + ":" + name + "-" + strconv.Itoa(i)
+	}
+	for i, step := range walk {
+		ns, relName, _ := strings.Cut(step, "#")
+		rel, ok := findDefRelation(byName[ns], relName)
+		if !ok {
+			return fakeCase{}, false
+		}
+		if i < len(walk)-1 {
+			next, _, _ := strings.Cut(walk[i+1], "#")
+			c.Relationships = append(c.Relationships, object(ns, i)+"#"+relName+"@"+object(next, i+1))
+			continue
+		}
+		for _, t := range rel.Types {
+			if t.Relation == "" && !t.Wildcard {
+				_, c.SubjectType = splitNamespace(t.Type)
+				break
+			}
+		}
+		if c.SubjectType == "" {
+			return fakeCase{}, false
+		}
+		c.SubjectID = "subject"
+		c.Relationships = append(c.Relationships, object(ns, i)+"#"+relName+"@"+c.SubjectType+":"+c.SubjectID)
+	}
+	_, first := splitNamespace(strings.SplitN(walk[0], "#", 2)[0])
+	c.ResourceID = first + "-0"
+	return c, true
+}
+
+// monotonic reports whether permission perm of ns only combines its
+// operands, and the permissions they reference, with unions and arrows, so
+// that any one of its walks grants it.
+func monotonic(byName map[string]Definition, ns, perm string, visiting map[string]bool) bool {
+	key := ns + "#" + perm
+	if visiting[key] {
+		return true
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	def := byName[ns]
+	for _, p := range def.Permissions {
+		if p.Name == perm {
+			return monotonicNode(byName, def, p.Tree, visiting)
+		}
+	}
+	return true // A relation
+}
+
+func monotonicNode(byName map[string]Definition, def Definition, node *ExprNode, visiting map[string]bool) bool {
+	switch node.Kind {
+	case ExprUnion:
+		for _, child := range node.Children {
+			if !monotonicNode(byName, def, child, visiting) {
+				return false
+			}
+		}
+		return true
+	case ExprRelation:
+		return monotonic(byName, def.FullName, node.Relation, visiting)
+	case ExprArrow:
+		rel, _ := findDefRelation(def, node.Relation)
+		for _, t := range rel.Types {
+			if !monotonic(byName, t.Type, node.Target, visiting) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// findDefRelation returns the relation name of def.
+func findDefRelation(def Definition, name string) (Relation, bool) {
+	for _, rel := range def.Relations {
+		if rel.Name == name {
+			return rel, true
+		}
+	}
+	return Relation{}, false
+}
+
+// fakeExprSource renders node as the fakeExpr literal of the generated Fake.
+func fakeExprSource(node *ExprNode) string {
+	if node == nil {
+		return `&fakeExpr{kind: "nil"}`
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "&fakeExpr{kind: %q", node.Kind)
+	if node.Relation != "" {
+		fmt.Fprintf(&b, ", relation: %q", node.Relation)
+	}
+	if node.Target != "" {
+		fmt.Fprintf(&b, ", target: %q", node.Target)
+	}
+	if node.Function == "all" {
+		b.WriteString(", all: true")
+	}
+	if len(node.Children) > 0 {
+		b.WriteString(", children: []*fakeExpr{")
+		for i, child := range node.Children {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(fakeExprSource(child))
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package authzgen
+
+// fixtureRelationship is a relationship the generated fixtures build with a
+// typed Builder method: a relation of a definition with one of its subject
+// types.
+type fixtureRelationship struct {
+	Method          string // Builder method, e.g. "DocumentViewerGroupMember"
+	ResourceType    string
+	Relation        string
+	SubjectType     string
+	SubjectRelation string // Empty for the subject objects themselves
+	Wildcard        bool
+	Caveat          string // First caveat of the subject type, attached by Every
+}
+
+// fixtureRelationships returns a fixtureRelationship for every subject type
+// of every relation, in definition and schema order.
+func fixtureRelationships(definitions []Definition) []fixtureRelationship {
+	var rels []fixtureRelationship
+	for _, def := range definitions {
+		for _, rel := range def.Relations {
+			for _, t := range rel.Types {
+				f := fixtureRelationship{
+					Method:          ToPascalCase(def.Name) + ToPascalCase(rel.Name) + ToPascalCase(subjectKey(t)),
+					ResourceType:    def.FullName,
+					Relation:        rel.Name,
+					SubjectType:     t.Type,
+					SubjectRelation: t.Relation,
+					Wildcard:        t.Wildcard,
+				}
+				if len(t.Caveats) > 0 {
+					f.Caveat = t.Caveats[0]
+				}
+				rels = append(rels, f)
+			}
+		}
+	}
+	return rels
+}
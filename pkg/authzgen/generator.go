@@ -3,33 +3,58 @@ package authzgen
 import (
 	"fmt"
 	"go/format"
+	"go/token"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
 	"unicode"
 
+	"github.com/authzed/spicedb/pkg/caveats"
+	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
+	"github.com/authzed/spicedb/pkg/namespace"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
-	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
-	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // Generator handles AuthZed schema code generation
 type Generator struct {
-	schemaFile string
-	outputDir  string
-	logger     *slog.Logger
+	schemaFiles []string // Schema files and directories of them
+	dialect     string   // Schema language of the files
+	outputDir   string
+	packageName string // Package of the generated code, when set
+	splitFiles  bool   // One file per definition rather than one for all
+	logger      *slog.Logger
+	middleware  []string // Routers to generate permission middleware for
+	fake        bool     // Generate Fake and its table-driven test
+	fixtures    bool     // Generate the fixtures package
+	docs        bool     // Generate PERMISSIONS.md
+	seedFile    string   // YAML file of relationships for Client.Seed
+	cache       []string // Backends to generate the permission cache with
+	plugins     []namedPlugin
 }
 
 // Option is a functional option for configuring the Generator
 type Option func(*Generator)
 
-// WithSchemaFile sets the schema file path
+// WithSchemaFile adds a schema file, or a directory whose schema files (.zed,
+// or .fga with the OpenFGA dialect) are all read. Repeat it to generate one package from several files; they may
+// reference each other's definitions.
 func WithSchemaFile(path string) Option {
 	return func(g *Generator) {
-		g.schemaFile = path
+		g.schemaFiles = append(g.schemaFiles, path)
+	}
+}
+
+// WithDialect sets the language of the schema files: DialectSpiceDB, the
+// default, or DialectOpenFGA. OpenFGA models are translated into the
+// equivalent SpiceDB schema, so both generate the same code.
+func WithDialect(dialect string) Option {
+	return func(g *Generator) {
+		g.dialect = dialect
 	}
 }
 
@@ -40,6 +65,24 @@ func WithOutputDir(dir string) Option {
 	}
 }
 
+// WithPackageName sets the package of the generated code. By default it is
+// the namespace prefix of the schema's definitions (e.g. "platform" for
+// "platform/user"), or "authz" for unprefixed definitions.
+func WithPackageName(name string) Option {
+	return func(g *Generator) {
+		g.packageName = name
+	}
+}
+
+// WithFilePerDefinition sets whether each definition is generated into its
+// own <name>.gen.go, the default, or all of them into definitions.gen.go.
+// Splitting keeps the files of large schemas readable.
+func WithFilePerDefinition(split bool) Option {
+	return func(g *Generator) {
+		g.splitFiles = split
+	}
+}
+
 // WithLogger sets the logger
 func WithLogger(logger *slog.Logger) Option {
 	return func(g *Generator) {
@@ -47,80 +90,201 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithMiddleware generates permission middleware for the given routers:
+// "http" (net/http, which chi uses as is), "chi", and "echo". Generated
+// middleware reports errors with github.com/ianmuhia/kit/pkg/httputil.
+func WithMiddleware(routers ...string) Option {
+	return func(g *Generator) {
+		g.middleware = append(g.middleware, routers...)
+	}
+}
+
+// WithFake generates fake.gen.go, with the Authorizer interface and Fake, an
+// in-memory Authorizer for application tests, and fake_gen_test.go, which
+// checks every permission with Fake.
+func WithFake() Option {
+	return func(g *Generator) {
+		g.fake = true
+	}
+}
+
+// WithFixtures generates fixtures/fixtures.gen.go, package fixtures, with a
+// Builder of the schema's relationships for integration tests: a method for
+// each subject type of each relation, Every for one relationship of each, and
+// Hierarchy for trees of objects such as organizations, teams, and users.
+func WithFixtures() Option {
+	return func(g *Generator) {
+		g.fixtures = true
+	}
+}
+
+// WithPermissionsDoc generates PERMISSIONS.md, a matrix of the permissions
+// of every definition and the role each relation plays in them, with the
+// paths each permission walks to its subjects, for reviewing the model
+// without reading the schema.
+func WithPermissionsDoc() Option {
+	return func(g *Generator) {
+		g.docs = true
+	}
+}
+
+// WithCache generates CachedClient, which caches the results of the typed
+// permission checks in a PermissionCache and invalidates them on writes, with
+// the given backends: "lru" (LRUCache, in memory) and "redis" (RedisCache,
+// using github.com/redis/go-redis/v9).
+func WithCache(backends ...string) Option {
+	return func(g *Generator) {
+		g.cache = append(g.cache, backends...)
+	}
+}
+
+// WithSeedFile generates seed.gen.go from a YAML file of relationships, with
+// Client.Seed writing them idempotently, e.g. to bootstrap platform admins in
+// a new environment. The file lists relationships in SpiceDB's syntax:
+//
+//	relationships:
+//	  - platform:main#admin@user:alice
+//	  - document:handbook#viewer@group:staff#member
+//
+// Generation fails when a relationship is not allowed by the schema.
+func WithSeedFile(path string) Option {
+	return func(g *Generator) {
+		g.seedFile = path
+	}
+}
+
 // NewGenerator creates a new AuthZed code generator with the given options
 func NewGenerator(opts ...Option) (*Generator, error) {
 	g := &Generator{
-		outputDir: ".",
-		logger:    slog.Default(),
+		outputDir:  ".",
+		splitFiles: true,
+		dialect:    DialectSpiceDB,
+		logger:     slog.Default(),
 	}
 
 	for _, opt := range opts {
 		opt(g)
 	}
 
-	if g.schemaFile == "" {
+	if len(g.schemaFiles) == 0 {
 		return nil, fmt.Errorf("schema file is required")
 	}
+	for _, backend := range g.cache {
+		switch backend {
+		case "lru", "redis":
+		default:
+			return nil, fmt.Errorf("unsupported cache backend %q (want lru or redis)", backend)
+		}
+	}
+	if g.packageName != "" && !token.IsIdentifier(g.packageName) {
+		return nil, fmt.Errorf("invalid package name %q", g.packageName)
+	}
+	if g.dialect != DialectSpiceDB && g.dialect != DialectOpenFGA {
+		return nil, fmt.Errorf("unsupported schema dialect %q (want %s or %s)", g.dialect, DialectSpiceDB, DialectOpenFGA)
+	}
+	for _, p := range g.plugins {
+		if p.plugin == nil {
+			return nil, fmt.Errorf("plugin %q is nil", p.name)
+		}
+	}
+	for _, router := range g.middleware {
+		switch router {
+		case "http", "chi", "echo":
+		default:
+			return nil, fmt.Errorf("unsupported middleware router %q (want http, chi, or echo)", router)
+		}
+	}
 
 	return g, nil
 }
 
 // Generate parses the schema and generates the code
 func (g *Generator) Generate() error {
-	g.logger.Info("Starting schema parsing", "file", g.schemaFile)
+	g.logger.Info("Starting schema parsing", "files", g.schemaFiles)
 
-	schema, err := g.parseSchema()
+	schema, err := g.ParseSchema()
 	if err != nil {
-		g.logger.Error("Schema parsing failed", "file", g.schemaFile, "error", err)
+		g.logger.Error("Schema parsing failed", "files", g.schemaFiles, "error", err)
 		return fmt.Errorf("failed to parse schema: %w", err)
 	}
 
 	// Use a single package name for all definitions
 	packageName := "authz"
-	if len(schema.Definitions) > 0 {
-		if schema.Definitions[0].Package != "" {
-			packageName = schema.Definitions[0].Package
-		}
+	switch {
+	case g.packageName != "":
+		packageName = g.packageName
+	case len(schema.Definitions) > 0 && schema.Definitions[0].Package != "":
+		packageName = schema.Definitions[0].Package
 	}
 
 	g.logger.Info("Generating code for single package", "package", packageName, "definitions_count", len(schema.Definitions))
 
-	if err := g.generateCode(packageName, schema.Definitions); err != nil {
+	if err := g.generateCode(packageName, schema.Definitions, schema.Caveats); err != nil {
 		g.logger.Error("Code generation failed", "package", packageName, "output_dir", g.outputDir, "error", err)
 		return fmt.Errorf("failed to generate code for package %s: %w", packageName, err)
 	}
 
-	g.logger.Info("code generation completed", "package", packageName, "output_dir", g.outputDir, "files", len(schema.Definitions)+1)
-	return nil
-}
-
-func (g *Generator) parseSchema() (*Schema, error) {
-	content, err := os.ReadFile(g.schemaFile)
+	pluginFiles, err := g.runPlugins(*schema)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read schema file: %w", err)
+		g.logger.Error("Plugin failed", "output_dir", g.outputDir, "error", err)
+		return err
 	}
 
-	g.logger.Debug("schema file read", "size_bytes", len(content))
+	files := 2 // client.gen.go and definitions.gen.go
+	if g.splitFiles {
+		files = len(schema.Definitions) + 1
+	}
+	if len(schema.Caveats) > 0 {
+		files++
+	}
+	if len(g.middleware) > 0 {
+		files++
+	}
+	if slices.Contains(g.middleware, "echo") {
+		files++
+	}
+	if g.fake {
+		files += 2
+	}
+	if g.fixtures {
+		files++
+	}
+	if g.docs {
+		files++
+	}
+	if g.seedFile != "" {
+		files++
+	}
+	if len(g.cache) > 0 {
+		files++
+	}
+	if slices.Contains(g.cache, "redis") {
+		files++
+	}
+	files += pluginFiles
+	g.logger.Info("code generation completed", "package", packageName, "output_dir", g.outputDir, "files", files)
+	return nil
+}
 
-	compiled, err := compiler.Compile(
-		compiler.InputSchema{
-			Source:       input.Source(g.schemaFile),
-			SchemaString: string(content),
-		},
-		compiler.AllowUnprefixedObjectType(),
-	)
+// ParseSchema parses the schema files into the Schema code is generated
+// from.
+func (g *Generator) ParseSchema() (*Schema, error) {
+	compiled, _, err := g.compileSchema()
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile schema: %w", err)
+		return nil, err
 	}
 
 	g.logger.Info("schema compiled", "definitions", len(compiled.ObjectDefinitions))
 
 	var schema Schema
+	walker := newWalker(compiled.ObjectDefinitions)
 	for _, ns := range compiled.ObjectDefinitions {
 		pkg, name := splitNamespace(ns.Name)
 		def := Definition{
-			Package: pkg,
-			Name:    name,
+			Package:  pkg,
+			Name:     name,
+			FullName: ns.Name,
+			Doc:      docComment(ns.Metadata),
 		}
 
 		for _, rel := range ns.Relation {
@@ -129,21 +293,106 @@ func (g *Generator) parseSchema() (*Schema, error) {
 				r := Relation{
 					Name:  rel.Name,
 					Types: extractAllowedTypes(rel.TypeInformation),
+					Doc:   docComment(rel.Metadata),
 				}
 				r.IsUnion = len(r.Types) > 1
 				def.Relations = append(def.Relations, r)
 			} else {
 				// permission: has userset rewrite expression
-				def.Permissions = append(def.Permissions, Permission{Name: rel.Name})
+				walks, excludes := walker.walks(ns.Name, rel.Name)
+				def.Permissions = append(def.Permissions, Permission{
+					Name:       rel.Name,
+					Expression: formatRewrite(rel.UsersetRewrite),
+					Walks:      walks,
+					Excludes:   excludes,
+					Tree:       buildTree(rel.UsersetRewrite),
+					Doc:        docComment(rel.Metadata),
+				})
 			}
 		}
 
 		schema.Definitions = append(schema.Definitions, def)
 	}
 
+	byName := make(map[string]Definition, len(schema.Definitions))
+	for _, def := range schema.Definitions {
+		byName[def.FullName] = def
+	}
+	for _, def := range schema.Definitions {
+		for i := range def.Permissions {
+			def.Permissions[i].SubjectTypes = subjectTypes(byName, def.Permissions[i])
+		}
+	}
+
+	for _, cd := range compiled.CaveatDefinitions {
+		caveat, err := convertCaveat(cd)
+		if err != nil {
+			return nil, err
+		}
+		schema.Caveats = append(schema.Caveats, caveat)
+	}
+
 	return &schema, nil
 }
 
+// convertCaveat converts a compiled caveat definition into a Caveat with its
+// parameters sorted by name and its expression decompiled back to CEL.
+func convertCaveat(cd *corev1.CaveatDefinition) (Caveat, error) {
+	pkg, name := splitNamespace(cd.Name)
+	caveat := Caveat{Package: pkg, Name: name, FullName: cd.Name, Doc: docComment(cd.Metadata)}
+	for paramName, ref := range cd.ParameterTypes {
+		caveat.Parameters = append(caveat.Parameters, CaveatParameter{Name: paramName, Type: formatCaveatType(ref)})
+	}
+	sort.Slice(caveat.Parameters, func(i, j int) bool {
+		return caveat.Parameters[i].Name < caveat.Parameters[j].Name
+	})
+
+	paramTypes, err := caveattypes.DecodeParameterTypes(caveattypes.Default.TypeSet, cd.ParameterTypes)
+	if err != nil {
+		return Caveat{}, fmt.Errorf("caveat %s: failed to decode parameter types: %w", cd.Name, err)
+	}
+	compiled, err := caveats.DeserializeCaveatWithDefaultTypeSet(cd.SerializedExpression, paramTypes)
+	if err != nil {
+		return Caveat{}, fmt.Errorf("caveat %s: failed to decode expression: %w", cd.Name, err)
+	}
+	if caveat.Expression, err = compiled.ExprString(); err != nil {
+		return Caveat{}, fmt.Errorf("caveat %s: failed to format expression: %w", cd.Name, err)
+	}
+	return caveat, nil
+}
+
+// docComment returns the comments written before a definition, relation,
+// permission, or caveat in the schema ("// ...", "/* ... */", or
+// "/** ... */") as plain text, without the comment markers.
+func docComment(metadata *corev1.Metadata) string {
+	var lines []string
+	for _, c := range namespace.GetComments(metadata) {
+		if text, ok := strings.CutPrefix(c, "//"); ok {
+			lines = append(lines, strings.TrimSpace(text))
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(c, "/*"), "*"), "*/")
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "*")))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// formatCaveatType renders a caveat parameter type as written in the schema,
+// e.g. "list<string>".
+func formatCaveatType(ref *corev1.CaveatTypeReference) string {
+	if len(ref.ChildTypes) == 0 {
+		return ref.TypeName
+	}
+	children := make([]string, len(ref.ChildTypes))
+	for i, child := range ref.ChildTypes {
+		children[i] = formatCaveatType(child)
+	}
+	return ref.TypeName + "<" + strings.Join(children, ", ") + ">"
+}
+
 // splitNamespace splits a SpiceDB namespace name (e.g. "platform/user") into
 // the Go package name ("platform") and the short definition name ("user").
 // Unprefixed names (e.g. "user") use "authz" as the package.
@@ -154,30 +403,48 @@ func splitNamespace(nsName string) (pkg, name string) {
 	return "authz", nsName
 }
 
-// extractAllowedTypes converts the SpiceDB TypeInformation into the type-string
-// slice the code template expects (e.g. "user", "platform/user", "group#member").
-// Public-wildcard entries (e.g. user:*) are omitted because they cannot be
-// represented as typed Go struct fields.
-func extractAllowedTypes(ti *corev1.TypeInformation) []string {
-	if ti == nil {
-		return nil
-	}
-	types := make([]string, 0, len(ti.AllowedDirectRelations))
-	for _, ar := range ti.AllowedDirectRelations {
+// extractAllowedTypes converts the SpiceDB TypeInformation into the subject
+// types of a relation (e.g. user, platform/user, group#member, user:*), in
+// schema order, with the caveats each allows.
+func extractAllowedTypes(ti *corev1.TypeInformation) []SubjectType {
+	var types []SubjectType
+	for _, ar := range ti.GetAllowedDirectRelations() {
+		t := SubjectType{Type: ar.Namespace}
 		switch rw := ar.GetRelationOrWildcard().(type) {
 		case *corev1.AllowedRelation_Relation:
-			if rw.Relation != "" && rw.Relation != "..." {
-				types = append(types, ar.Namespace+"#"+rw.Relation)
-			} else {
-				types = append(types, ar.Namespace)
+			if rw.Relation != tuple.Ellipsis {
+				t.Relation = rw.Relation
 			}
-		// *corev1.AllowedRelation_PublicWildcard_ (type:*) — skip; not a typed subject
+		case *corev1.AllowedRelation_PublicWildcard_:
+			t.Wildcard = true
+		}
+		// "user | user with ip_allowlist" allows one subject type twice
+		i := slices.IndexFunc(types, t.Is)
+		if i < 0 {
+			types = append(types, t)
+			i = len(types) - 1
+		}
+		if name := ar.GetRequiredCaveat().GetCaveatName(); name != "" && !slices.Contains(types[i].Caveats, name) {
+			types[i].Caveats = append(types[i].Caveats, name)
 		}
 	}
 	return types
 }
 
-func (g *Generator) generateCode(packageName string, definitions []Definition) error {
+// subjectKey names a subject type in the generated Objects structs, in
+// snake case: "user", "group_member", or "user_wildcard".
+func subjectKey(t SubjectType) string {
+	_, key := splitNamespace(t.Type)
+	if t.Relation != "" {
+		key += "_" + t.Relation
+	}
+	if t.Wildcard {
+		key += "_wildcard"
+	}
+	return key
+}
+
+func (g *Generator) generateCode(packageName string, definitions []Definition, caveats []Caveat) error {
 	if err := os.MkdirAll(g.outputDir, 0o755); err != nil {
 		return err
 	}
@@ -192,24 +459,163 @@ func (g *Generator) generateCode(packageName string, definitions []Definition) e
 		return fmt.Errorf("client file: %w", err)
 	}
 
-	// One file per definition, sorted for deterministic output.
+	// Caveat names and parameters, when the schema declares any.
+	if len(caveats) > 0 {
+		sort.Slice(caveats, func(i, j int) bool {
+			return caveats[i].Name < caveats[j].Name
+		})
+		if err := g.renderFile("caveats", caveatsTemplate, funcMap,
+			struct {
+				Package string
+				Caveats []Caveat
+			}{packageName, caveats},
+			filepath.Join(g.outputDir, "caveats.gen.go"),
+		); err != nil {
+			return fmt.Errorf("caveats file: %w", err)
+		}
+	}
+
+	// One file per definition, or one for all, sorted for deterministic output.
 	sort.Slice(definitions, func(i, j int) bool {
 		return definitions[i].Name < definitions[j].Name
 	})
-	for _, def := range definitions {
+	if g.splitFiles {
+		for _, def := range definitions {
+			data := struct {
+				Package     string
+				Definitions []Definition
+			}{packageName, []Definition{def}}
+			outPath := filepath.Join(g.outputDir, strings.ToLower(def.Name)+".gen.go")
+			if err := g.renderFile(def.Name, definitionTemplate, funcMap, data, outPath); err != nil {
+				return fmt.Errorf("definition %s: %w", def.Name, err)
+			}
+		}
+	} else {
+		data := struct {
+			Package     string
+			Definitions []Definition
+		}{packageName, definitions}
+		if err := g.renderFile("definitions", definitionTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "definitions.gen.go"),
+		); err != nil {
+			return fmt.Errorf("definitions file: %w", err)
+		}
+	}
+
+	// Permission middleware for the requested routers.
+	if len(g.middleware) > 0 {
+		data := struct {
+			Package     string
+			Definitions []Definition
+		}{packageName, definitions}
+		if err := g.renderFile("middleware", middlewareTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "middleware.gen.go"),
+		); err != nil {
+			return fmt.Errorf("middleware file: %w", err)
+		}
+		if slices.Contains(g.middleware, "echo") {
+			if err := g.renderFile("middleware_echo", echoMiddlewareTemplate, funcMap, data,
+				filepath.Join(g.outputDir, "middleware_echo.gen.go"),
+			); err != nil {
+				return fmt.Errorf("echo middleware file: %w", err)
+			}
+		}
+	}
+
+	// In-memory Authorizer for tests, and its test.
+	if g.fake {
+		data := struct {
+			Package     string
+			Definitions []Definition
+		}{packageName, definitions}
+		if err := g.renderFile("fake", fakeTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "fake.gen.go"),
+		); err != nil {
+			return fmt.Errorf("fake file: %w", err)
+		}
+		testData := struct {
+			Package string
+			Cases   []fakeCase
+		}{packageName, fakeCases(definitions)}
+		if err := g.renderFile("fake_test", fakeTestTemplate, funcMap, testData,
+			filepath.Join(g.outputDir, "fake_gen_test.go"),
+		); err != nil {
+			return fmt.Errorf("fake test file: %w", err)
+		}
+	}
+
+	// Relationship builders for integration tests, in their own package.
+	if g.fixtures {
+		dir := filepath.Join(g.outputDir, "fixtures")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		data := struct {
+			Definitions   []Definition
+			Relationships []fixtureRelationship
+		}{definitions, fixtureRelationships(definitions)}
+		if err := g.renderFile("fixtures", fixturesTemplate, funcMap, data,
+			filepath.Join(dir, "fixtures.gen.go"),
+		); err != nil {
+			return fmt.Errorf("fixtures file: %w", err)
+		}
+	}
+
+	// Permission check cache and its backends.
+	if len(g.cache) > 0 {
 		data := struct {
-			Package    string
-			Definition Definition
-		}{packageName, def}
-		outPath := filepath.Join(g.outputDir, strings.ToLower(def.Name)+".gen.go")
-		if err := g.renderFile(def.Name, definitionTemplate, funcMap, data, outPath); err != nil {
-			return fmt.Errorf("definition %s: %w", def.Name, err)
+			Package      string
+			Definitions  []Definition
+			Dependencies []cacheDependency
+			LRU, Fake    bool
+		}{packageName, definitions, cacheDependencies(definitions), slices.Contains(g.cache, "lru"), g.fake}
+		if err := g.renderFile("cache", cacheTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "cache.gen.go"),
+		); err != nil {
+			return fmt.Errorf("cache file: %w", err)
+		}
+		if slices.Contains(g.cache, "redis") {
+			if err := g.renderFile("cache_redis", redisCacheTemplate, funcMap,
+				struct{ Package string }{packageName},
+				filepath.Join(g.outputDir, "cache_redis.gen.go"),
+			); err != nil {
+				return fmt.Errorf("redis cache file: %w", err)
+			}
+		}
+	}
+
+	// Relationships to bootstrap an environment with.
+	if g.seedFile != "" {
+		seeds, err := g.loadSeed(definitions)
+		if err != nil {
+			return err
+		}
+		data := struct {
+			Package       string
+			Source        string
+			Relationships []seedRelationship
+			HasContext    bool
+		}{packageName, filepath.Base(g.seedFile), seeds, slices.ContainsFunc(seeds, func(s seedRelationship) bool { return s.CaveatContext != "" })}
+		if err := g.renderFile("seed", seedTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "seed.gen.go"),
+		); err != nil {
+			return fmt.Errorf("seed file: %w", err)
+		}
+	}
+
+	// Permission matrix for security reviews.
+	if g.docs {
+		data := struct{ Definitions []matrixDefinition }{permissionMatrix(definitions)}
+		if err := g.renderFile("permissions", permissionsDocTemplate, funcMap, data,
+			filepath.Join(g.outputDir, "PERMISSIONS.md"),
+		); err != nil {
+			return fmt.Errorf("permissions doc: %w", err)
 		}
 	}
 	return nil
 }
 
-// renderFile parses tmplStr, executes it with data, formats the result with
+// renderFile parses tmplStr, executes it with data, formats Go results with
 // go/format, and writes it to outPath.
 func (g *Generator) renderFile(name, tmplStr string, funcMap template.FuncMap, data any, outPath string) error {
 	tmpl, err := template.New(name).Funcs(funcMap).Parse(tmplStr)
@@ -220,6 +626,9 @@ func (g *Generator) renderFile(name, tmplStr string, funcMap template.FuncMap, d
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return err
 	}
+	if filepath.Ext(outPath) != ".go" {
+		return os.WriteFile(outPath, []byte(buf.String()), 0o644)
+	}
 	formatted, err := format.Source([]byte(buf.String()))
 	if err != nil {
 		formatted = []byte(buf.String()) // write unformatted so the caller sees the compile error
@@ -231,7 +640,26 @@ func (g *Generator) renderFile(name, tmplStr string, funcMap template.FuncMap, d
 func buildFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"camelcase": ToPascalCase,
-		"lower":     strings.ToLower,
+		"code":      func(s string) string { return "`" + s + "`" },
+		"comment":   goComment,
+		// commentParagraph continues a doc comment with a paragraph
+		"commentParagraph": func(text string) string {
+			if text == "" {
+				return ""
+			}
+			return "//\n" + goComment(text)
+		},
+		"codeList": func(items []string) string {
+			quoted := make([]string, len(items))
+			for i, item := range items {
+				quoted[i] = "`" + item + "`"
+			}
+			return strings.Join(quoted, ", ")
+		},
+		"fakeExpr":   fakeExprSource,
+		"lower":      strings.ToLower,
+		"plural":     plural,
+		"subjectKey": subjectKey,
 		"extractType": func(fullType string) string {
 			parts := strings.Split(fullType, "/")
 			typeName := fullType
@@ -246,6 +674,23 @@ func buildFuncMap() template.FuncMap {
 	}
 }
 
+// goComment renders text as the lines of a Go comment, each ending with a
+// newline, or returns "" for empty text.
+func goComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("//\n")
+		} else {
+			b.WriteString("// " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
 // ToPascalCase converts a string to PascalCase
 func ToPascalCase(s string) string {
 	var result strings.Builder
@@ -275,25 +720,157 @@ func ToPascalCase(s string) string {
 // Schema represents the parsed AuthZed schema
 type Schema struct {
 	Definitions []Definition
+	Caveats     []Caveat
+}
+
+// Caveat represents a caveat declaration, e.g.
+// "caveat ip_allowlist(user_ip ipaddress, allowed_ranges list<string>)".
+type Caveat struct {
+	Name       string // Short name, e.g. "ip_allowlist"
+	Package    string
+	FullName   string // Name in the schema, including any namespace prefix
+	Parameters []CaveatParameter
+	Expression string // CEL expression of the caveat
+	Doc        string // Comment written before the caveat
+}
+
+// CaveatParameter is a typed parameter of a caveat.
+type CaveatParameter struct {
+	Name string
+	Type string // Schema type, e.g. "ipaddress" or "list<string>"
 }
 
 // Definition represents a definition in the schema
 type Definition struct {
 	Name        string
 	Package     string
+	FullName    string // Name in the schema, including any namespace prefix
 	Relations   []Relation
 	Permissions []Permission
+	Doc         string // Comment written before the definition
 }
 
 // Relation represents a relation in a definition
 type Relation struct {
 	Name    string
-	Types   []string
+	Types   []SubjectType // Subjects allowed, in schema order
 	IsUnion bool
+	Doc     string // Comment written before the relation
+}
+
+// SubjectType is a subject a relation allows: the objects of a definition
+// (user), the subjects of one of its relations or permissions
+// (group#member), or every object of it through a public wildcard (user:*).
+type SubjectType struct {
+	Type     string // Definition, including any namespace prefix
+	Relation string // Subject relation, or empty for the objects themselves
+	Wildcard bool
+	Caveats  []string // Caveats allowed on these subjects, e.g. "ip_allowlist" for "user with ip_allowlist"
+}
+
+// Is reports whether t and o are the same subject, whatever caveats they
+// allow.
+func (t SubjectType) Is(o SubjectType) bool {
+	return t.Type == o.Type && t.Relation == o.Relation && t.Wildcard == o.Wildcard
+}
+
+// String returns t in schema notation, e.g. "user", "group#member", or
+// "user:*".
+func (t SubjectType) String() string {
+	switch {
+	case t.Wildcard:
+		return t.Type + ":*"
+	case t.Relation != "":
+		return t.Type + "#" + t.Relation
+	}
+	return t.Type
+}
+
+// ObjectTypes returns the subject types of r other than public wildcards.
+func (r Relation) ObjectTypes() []SubjectType {
+	var types []SubjectType
+	for _, t := range r.Types {
+		if !t.Wildcard {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// WildcardTypes returns the subject types r allows as a public wildcard.
+func (r Relation) WildcardTypes() []SubjectType {
+	var types []SubjectType
+	for _, t := range r.Types {
+		if t.Wildcard {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Caveats returns the caveats r allows on any of its subject types, in schema
+// order.
+func (r Relation) Caveats() []string {
+	var names []string
+	for _, t := range r.Types {
+		for _, c := range t.Caveats {
+			if !slices.Contains(names, c) {
+				names = append(names, c)
+			}
+		}
+	}
+	return names
+}
+
+// FindType returns the subject type of r that is t, if r allows it.
+func (r Relation) FindType(t SubjectType) (SubjectType, bool) {
+	i := slices.IndexFunc(r.Types, t.Is)
+	if i < 0 {
+		return SubjectType{}, false
+	}
+	return r.Types[i], true
+}
+
+// IsPublic reports whether the relation can be granted to every subject of
+// some type through a wildcard.
+func (r Relation) IsPublic() bool {
+	return slices.ContainsFunc(r.Types, func(t SubjectType) bool { return t.Wildcard })
 }
 
 // Permission represents a permission in a definition
 type Permission struct {
 	Name       string
-	Expression string
+	Expression string // Schema expression, e.g. "writer & approved - banned"
+	// Walks are the relation paths that may grant the permission, following
+	// arrows and the permissions it references across definitions, e.g.
+	// {"document#parent", "folder#org", "org#admin"}.
+	Walks [][]string
+	// Excludes are the relation paths whose subjects the permission
+	// subtracts, e.g. {"document#banned"} for "owner - banned".
+	Excludes [][]string
+	// SubjectTypes are the types of the objects the permission may be
+	// granted to, e.g. {"user", "serviceaccount"}.
+	SubjectTypes []string
+	Tree         *ExprNode // The expression as a tree
+	Doc          string    // Comment written before the permission
+}
+
+// ExprNode kinds.
+const (
+	ExprRelation     = "relation"     // A relation or permission of the definition
+	ExprArrow        = "arrow"        // Relation->Target, or a functioned arrow
+	ExprUnion        = "union"        // Children joined with +
+	ExprIntersection = "intersection" // Children joined with &
+	ExprExclusion    = "exclusion"    // The first child minus the others
+	ExprNil          = "nil"
+	ExprSelf         = "self"
+)
+
+// ExprNode is a node of a permission expression tree.
+type ExprNode struct {
+	Kind     string
+	Relation string // The relation or permission, or the tupleset of an arrow
+	Target   string // The relation or permission an arrow reaches
+	Function string // "any" or "all" for functioned arrows, e.g. parent.all(member)
+	Children []*ExprNode
 }
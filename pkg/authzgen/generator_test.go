@@ -1,10 +1,15 @@
 package authzgen
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,24 +95,105 @@ func TestNewGenerator(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, ".", g.outputDir)
 	})
+
+	t.Run("unsupported middleware router returns error", func(t *testing.T) {
+		f := writeSchema(t, "definition user {}")
+		_, err := NewGenerator(WithSchemaFile(f), WithMiddleware("http", "gin"))
+		require.ErrorContains(t, err, `unsupported middleware router "gin"`)
+	})
+
+	t.Run("invalid package name returns error", func(t *testing.T) {
+		f := writeSchema(t, "definition user {}")
+		_, err := NewGenerator(WithSchemaFile(f), WithPackageName("access-control"))
+		require.ErrorContains(t, err, `invalid package name "access-control"`)
+	})
+
+	t.Run("unsupported cache backend returns error", func(t *testing.T) {
+		f := writeSchema(t, "definition user {}")
+		_, err := NewGenerator(WithSchemaFile(f), WithCache("lru", "memcached"))
+		require.ErrorContains(t, err, `unsupported cache backend "memcached"`)
+	})
+
+	t.Run("unsupported dialect returns error", func(t *testing.T) {
+		f := writeSchema(t, "definition user {}")
+		_, err := NewGenerator(WithSchemaFile(f), WithDialect("cedar"))
+		require.ErrorContains(t, err, `unsupported schema dialect "cedar"`)
+	})
 }
 
-// parseSchema ────
+// ParseSchema ────
 
 func TestParseSchema_MissingFile(t *testing.T) {
 	g, err := NewGenerator(WithSchemaFile("/nonexistent/schema.zed"), WithOutputDir(t.TempDir()))
 	require.NoError(t, err)
-	_, err = g.parseSchema()
+	_, err = g.ParseSchema()
 	require.ErrorContains(t, err, "failed to read schema file")
 }
 
+func TestParseSchema_MultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		f := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(f, []byte(content), 0o644))
+		return f
+	}
+	users := write("users.zed", "definition user {}\n")
+	docs := write("docs.zed", `
+definition document {
+    relation owner: user
+    relation parent: folder
+    permission view = owner + parent->view
+}`)
+	write("folders.zed", `use import
+
+import "users.zed"
+
+definition folder {
+    relation owner: user
+    permission view = owner
+}`)
+
+	t.Run("files", func(t *testing.T) {
+		g, err := NewGenerator(WithSchemaFile(users), WithSchemaFile(docs), WithSchemaFile(filepath.Join(dir, "folders.zed")))
+		require.NoError(t, err)
+		s, err := g.ParseSchema()
+		require.NoError(t, err)
+		assert.Len(t, s.Definitions, 3)
+		assert.Equal(t, [][]string{{"document#owner"}, {"document#parent", "folder#owner"}}, findDef(t, s, "document").Permissions[0].Walks)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		g, err := NewGenerator(WithSchemaFile(dir))
+		require.NoError(t, err)
+		s, err := g.ParseSchema()
+		require.NoError(t, err, "user is imported by folders.zed and declared by users.zed")
+		assert.Len(t, s.Definitions, 3)
+	})
+
+	t.Run("duplicate definition", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "other.zed")
+		require.NoError(t, os.WriteFile(other, []byte("definition user {\n    relation manager: user\n}\n"), 0o644))
+		g, err := NewGenerator(WithSchemaFile(users), WithSchemaFile(other))
+		require.NoError(t, err)
+		_, err = g.ParseSchema()
+		require.ErrorContains(t, err, `definition "user" is declared in both `+users+" and "+other)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		g, err := NewGenerator(WithSchemaFile(t.TempDir()))
+		require.NoError(t, err)
+		_, err = g.ParseSchema()
+		require.ErrorContains(t, err, "no .zed files in schema directory")
+	})
+}
+
 func TestParseSchema_InvalidSchema(t *testing.T) {
 	g, err := NewGenerator(
 		WithSchemaFile(writeSchema(t, "this is not valid schema content")),
 		WithOutputDir(t.TempDir()),
 	)
 	require.NoError(t, err)
-	_, err = g.parseSchema()
+	_, err = g.ParseSchema()
 	require.ErrorContains(t, err, "failed to compile schema")
 }
 
@@ -122,7 +208,7 @@ definition team {
 	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
 	require.NoError(t, err)
 
-	s, err := g.parseSchema()
+	s, err := g.ParseSchema()
 	require.NoError(t, err)
 	require.Len(t, s.Definitions, 2)
 
@@ -135,7 +221,7 @@ definition team {
 	assert.Equal(t, "authz", team.Package)
 	require.Len(t, team.Relations, 1)
 	assert.Equal(t, "direct_member", team.Relations[0].Name)
-	assert.Equal(t, []string{"user"}, team.Relations[0].Types)
+	assert.Equal(t, []SubjectType{{Type: "user"}}, team.Relations[0].Types)
 	assert.False(t, team.Relations[0].IsUnion)
 	require.Len(t, team.Permissions, 1)
 	assert.Equal(t, "member", team.Permissions[0].Name)
@@ -157,7 +243,7 @@ definition doctype {
 	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
 	require.NoError(t, err)
 
-	s, err := g.parseSchema()
+	s, err := g.ParseSchema()
 	require.NoError(t, err)
 
 	dt := findDef(t, s, "doctype")
@@ -166,15 +252,14 @@ definition doctype {
 	admin := dt.Relations[0]
 	assert.Equal(t, "admin", admin.Name)
 	assert.True(t, admin.IsUnion)
-	assert.Contains(t, admin.Types, "user")
-	assert.Contains(t, admin.Types, "team#member")
+	assert.Equal(t, []SubjectType{{Type: "user"}, {Type: "team", Relation: "member"}}, admin.Types)
 
 	require.Len(t, dt.Permissions, 1)
 	assert.Equal(t, "view", dt.Permissions[0].Name)
 }
 
 func TestParseSchema_SubjectRelation(t *testing.T) {
-	// team#member is a subject relation — type string must include the fragment
+	// team#member is a subject relation — the type must carry the relation
 	schema := `
 definition user {}
 
@@ -189,12 +274,13 @@ definition resource {
 	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
 	require.NoError(t, err)
 
-	s, err := g.parseSchema()
+	s, err := g.ParseSchema()
 	require.NoError(t, err)
 
 	res := findDef(t, s, "resource")
 	require.Len(t, res.Relations, 1)
-	assert.Contains(t, res.Relations[0].Types, "team#member")
+	assert.Contains(t, res.Relations[0].Types, SubjectType{Type: "team", Relation: "member"})
+	assert.Equal(t, "team#member", res.Relations[0].Types[1].String())
 }
 
 func TestParseSchema_PrefixedNamespace(t *testing.T) {
@@ -211,7 +297,7 @@ definition platform/document {
 	)
 	require.NoError(t, err)
 
-	s, err := g.parseSchema()
+	s, err := g.ParseSchema()
 	require.NoError(t, err)
 
 	for _, d := range s.Definitions {
@@ -220,7 +306,7 @@ definition platform/document {
 
 	doc := findDef(t, s, "document")
 	require.Len(t, doc.Relations, 1)
-	assert.Equal(t, []string{"platform/user"}, doc.Relations[0].Types)
+	assert.Equal(t, []SubjectType{{Type: "platform/user"}}, doc.Relations[0].Types)
 }
 
 func TestParseSchema_ArrowPermission(t *testing.T) {
@@ -241,7 +327,7 @@ definition organization {
 	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
 	require.NoError(t, err)
 
-	s, err := g.parseSchema()
+	s, err := g.ParseSchema()
 	require.NoError(t, err)
 
 	org := findDef(t, s, "organization")
@@ -254,6 +340,323 @@ definition organization {
 	assert.Contains(t, permNames, "org_member")
 }
 
+func TestParseSchema_PermissionOperators(t *testing.T) {
+	schema := `
+definition user {}
+
+definition organization {
+    relation member: user
+}
+
+definition document {
+    relation org: organization
+    relation writer: user
+    relation approved: user
+    relation banned: user
+    relation reader: user
+
+    permission edit = writer & approved - banned
+    permission grouped = writer - (approved - banned)
+    permission mixed = (reader - banned) + writer
+    permission tight = reader + writer & approved
+    permission nested = (reader & writer) - banned & approved
+    permission via_org = org->member - banned
+    permission all_orgs = org.all(member)
+    permission nothing = nil
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	exprs := make(map[string]string)
+	for _, p := range findDef(t, s, "document").Permissions {
+		exprs[p.Name] = p.Expression
+	}
+	assert.Equal(t, map[string]string{
+		"edit":     "(writer & approved) - banned",
+		"grouped":  "writer - (approved - banned)",
+		"mixed":    "(reader - banned) + writer",
+		"tight":    "(reader + writer) & approved",
+		"nested":   "(reader & writer) - (banned & approved)",
+		"via_org":  "org->member - banned",
+		"all_orgs": "org.all(member)",
+		"nothing":  "nil",
+	}, exprs)
+}
+
+func TestParseSchema_ParenthesizedPermission(t *testing.T) {
+	parse := func(t *testing.T, expr string) string {
+		t.Helper()
+		schema := `
+definition user {}
+
+definition document {
+    relation editor: user
+    relation viewer: user
+    relation blocked: user
+    relation owner: user
+    permission view = ` + expr + `
+}`
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+		require.NoError(t, err)
+		s, err := g.ParseSchema()
+		require.NoError(t, err)
+		perms := findDef(t, s, "document").Permissions
+		require.Len(t, perms, 1)
+		return perms[0].Expression
+	}
+
+	for expr, want := range map[string]string{
+		"(editor + viewer) - blocked":             "(editor + viewer) - blocked",
+		"editor + (viewer - blocked)":             "editor + (viewer - blocked)",
+		"((editor))":                              "editor",
+		"(editor + viewer) & (owner + editor)":    "(editor + viewer) & (owner + editor)",
+		"owner + (editor + viewer)":               "owner + editor + viewer",
+		"((editor - blocked) & viewer) - blocked": "((editor - blocked) & viewer) - blocked",
+		"editor - blocked - viewer":               "editor - blocked - viewer",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			got := parse(t, expr)
+			assert.Equal(t, want, got)
+			// The rendered expression parses back to the same permission
+			assert.Equal(t, got, parse(t, got))
+		})
+	}
+}
+
+const caveatSchema = `
+caveat ip_allowlist(user_ip ipaddress, allowed_ranges list<string>) {
+    allowed_ranges.exists(r, user_ip.in_cidr(r))
+}
+
+definition user {}
+
+definition document {
+    relation viewer: user | user with ip_allowlist
+    relation owner: user
+    permission view = viewer + owner
+}`
+
+func TestParseSchema_Caveats(t *testing.T) {
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, caveatSchema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	require.Len(t, s.Caveats, 1)
+	caveat := s.Caveats[0]
+	assert.Equal(t, "ip_allowlist", caveat.Name)
+	assert.Equal(t, "authz", caveat.Package)
+	assert.Equal(t, []CaveatParameter{
+		{Name: "allowed_ranges", Type: "list<string>"},
+		{Name: "user_ip", Type: "ipaddress"},
+	}, caveat.Parameters)
+	assert.Equal(t, "allowed_ranges.exists(r, user_ip.in_cidr(r))", caveat.Expression)
+
+	doc := findDef(t, s, "document")
+	require.Len(t, doc.Relations, 2)
+	viewer := doc.Relations[0]
+	assert.Equal(t, []SubjectType{{Type: "user", Caveats: []string{"ip_allowlist"}}}, viewer.Types, "the caveated and plain subject type are one field")
+	assert.Equal(t, []string{"ip_allowlist"}, viewer.Caveats())
+	assert.Empty(t, doc.Relations[1].Caveats())
+}
+
+func TestParseSchema_WildcardRelation(t *testing.T) {
+	schema := `
+definition user {}
+
+definition post {
+    relation reader: user:* | user
+    relation public_only: user:*
+    relation author: user
+    permission read = reader + public_only + author
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	post := findDef(t, s, "post")
+	require.Len(t, post.Relations, 3)
+	reader, publicOnly, author := post.Relations[0], post.Relations[1], post.Relations[2]
+	assert.Equal(t, []SubjectType{{Type: "user", Wildcard: true}, {Type: "user"}}, reader.Types)
+	assert.Equal(t, []SubjectType{{Type: "user"}}, reader.ObjectTypes())
+	assert.Equal(t, []SubjectType{{Type: "user", Wildcard: true}}, reader.WildcardTypes())
+	assert.Equal(t, "user:*", reader.Types[0].String())
+	assert.True(t, reader.IsPublic())
+	assert.Empty(t, publicOnly.ObjectTypes())
+	assert.True(t, publicOnly.IsPublic())
+	assert.False(t, author.IsPublic())
+}
+
+func TestParseSchema_AliasesAndNil(t *testing.T) {
+	f := writeSchema(t, `
+definition user {}
+
+definition group {
+    relation member: user | group#member
+}
+
+definition document {
+    relation owner: user
+    relation viewer: user | group | group#member | user:*
+    permission admin = owner
+    permission nothing = nil
+    permission view = viewer + admin
+}`)
+	g, err := NewGenerator(WithSchemaFile(f), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := findDef(t, s, "document")
+	viewer := doc.Relations[1]
+	assert.Equal(t, []SubjectType{
+		{Type: "user"},
+		{Type: "group"},
+		{Type: "group", Relation: "member"},
+		{Type: "user", Wildcard: true},
+	}, viewer.Types)
+	assert.Equal(t, []string{"user", "group", "group#member", "user:*"}, relationSubjects(viewer))
+
+	admin, nothing := doc.Permissions[0], doc.Permissions[1]
+	assert.Equal(t, "owner", admin.Expression)
+	assert.Equal(t, &ExprNode{Kind: ExprRelation, Relation: "owner"}, admin.Tree)
+	assert.Equal(t, [][]string{{"document#owner"}}, admin.Walks)
+	assert.Equal(t, []string{"user"}, admin.SubjectTypes)
+	assert.Equal(t, "nil", nothing.Expression)
+	assert.Equal(t, ExprNil, nothing.Tree.Kind)
+	assert.Empty(t, nothing.Walks)
+	assert.Empty(t, nothing.SubjectTypes)
+
+	outDir := t.TempDir()
+	g, err = NewGenerator(WithSchemaFile(f), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	raw, err := os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "\tGroup        []Group `json:\"group,omitempty\"`\n")
+	assert.Contains(t, string(raw), "\tGroupMember  []Group `json:\"group_member,omitempty\"`  // Subjects group#member\n")
+	assert.Contains(t, string(raw), `Types:       []string{"user", "group", "group#member"},`)
+}
+
+func TestParseSchema_PermissionWalks(t *testing.T) {
+	schema := `
+definition user {}
+
+definition org {
+    relation admin: user
+}
+
+definition folder {
+    relation org: org
+    relation parent: folder
+    relation viewer: user
+    permission org_admin = org->admin
+    permission view = viewer + parent->view
+}
+
+definition document {
+    relation parent: folder
+    relation owner: user
+    relation banned: user
+    permission admin = (parent->org_admin + owner) - banned
+    permission view = parent->view + admin
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := findDef(t, s, "document")
+	require.Len(t, doc.Permissions, 2)
+	assert.Equal(t, [][]string{
+		{"document#parent", "folder#org", "org#admin"},
+		{"document#owner"},
+	}, doc.Permissions[0].Walks)
+	assert.Equal(t, [][]string{{"document#banned"}}, doc.Permissions[0].Excludes, "subtracted relations do not grant")
+	// The recursive parent->view ends where it re-enters folder#view
+	assert.Equal(t, [][]string{
+		{"document#parent", "folder#viewer"},
+		{"document#parent", "folder#parent", "folder#view"},
+		{"document#parent", "folder#org", "org#admin"},
+		{"document#owner"},
+	}, doc.Permissions[1].Walks)
+	assert.Equal(t, [][]string{{"document#banned"}}, doc.Permissions[1].Excludes, "exclusions of referenced permissions carry over")
+
+	folder := findDef(t, s, "folder")
+	assert.Equal(t, [][]string{{"folder#org", "org#admin"}}, folder.Permissions[0].Walks)
+}
+
+func TestParseSchema_PermissionExcludes(t *testing.T) {
+	schema := `
+definition user {}
+
+definition folder {
+    relation blocked: user
+}
+
+definition document {
+    relation parent: folder
+    relation owner: user
+    relation approved: user
+    relation banned: user
+    permission edit = owner - parent->blocked
+    permission grouped = owner - (approved - banned)
+    permission both = (owner & approved) - banned
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := findDef(t, s, "document")
+	edit, grouped, both := doc.Permissions[0], doc.Permissions[1], doc.Permissions[2]
+	assert.Equal(t, [][]string{{"document#owner"}}, edit.Walks)
+	assert.Equal(t, [][]string{{"document#parent", "folder#blocked"}}, edit.Excludes)
+	assert.Equal(t, [][]string{{"document#owner"}}, grouped.Walks)
+	assert.Equal(t, [][]string{{"document#approved"}, {"document#banned"}}, grouped.Excludes, "the whole subtracted operand excludes")
+	assert.Equal(t, [][]string{{"document#owner"}, {"document#approved"}}, both.Walks)
+	assert.Equal(t, [][]string{{"document#banned"}}, both.Excludes)
+
+	assert.Contains(t, cacheDependencies(s.Definitions), cacheDependency{Type: "Folder", Dependents: []string{"Document", "Folder"}},
+		"a subtracted relation invalidates the permissions that subtract it")
+
+	outDir := t.TempDir()
+	g, err = NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	raw, err := os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `Walks: [][]string{
+			{"document#owner"},
+		},
+		Excludes: [][]string{
+			{"document#parent", "folder#blocked"},
+		},`)
+}
+
+func TestParseSchema_ChainedArrowRejected(t *testing.T) {
+	schema := `
+definition user {}
+definition org { relation admin: user }
+definition folder { relation org: org }
+definition document {
+    relation parent: folder
+    permission admin = parent->org->admin
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	_, err = g.ParseSchema()
+	assert.Error(t, err, "SpiceDB requires an intermediate permission for chained arrows")
+}
+
 // Generate (end-to-end)────
 
 const fullSchema = `
@@ -360,11 +763,14 @@ func TestGenerate_OutputContainsExpectedSymbols(t *testing.T) {
 	assert.Contains(t, doctype, `DoctypeReadPerm`)
 	assert.Contains(t, doctype, `DoctypeEditPerm`)
 	assert.Contains(t, doctype, `DoctypeDeletePerm`)
+	assert.Contains(t, doctype, "// DoctypeReadPerm is computed as: reader + triager + writer + maintainer + admin")
 
 	// union objects struct has both subject types
 	assert.Contains(t, doctype, "DoctypeAdminObjects")
-	assert.Contains(t, doctype, "User []User")
-	assert.Contains(t, doctype, "Team []Team")
+	assert.Contains(t, doctype, "\tUser       []User `json:\"user,omitempty\"`\n")
+	assert.Contains(t, doctype, "\tTeamMember []Team `json:\"team_member,omitempty\"` // Subjects team#member\n")
+	assert.Contains(t, doctype, "Subject:  &v1.SubjectReference{Object: obj.ResourceReference(), OptionalRelation: \"member\"},")
+	assert.Contains(t, doctype, "case subject.Object.ObjectType == string(TypeTeam) && subject.OptionalRelation == \"member\":")
 
 	// store struct, interface, constructor
 	assert.Contains(t, doctype, "type DoctypeStore struct")
@@ -433,3 +839,1172 @@ func TestGenerate_InvalidSchemaReturnsError(t *testing.T) {
 	require.NoError(t, err)
 	require.ErrorContains(t, g.Generate(), "failed to parse schema")
 }
+
+func TestGenerate_Caveats(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, caveatSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "caveats.gen.go"))
+	require.NoError(t, err)
+	caveats := string(raw)
+	assert.Contains(t, caveats, `const CaveatIpAllowlist Caveat = "ip_allowlist"`)
+	assert.Contains(t, caveats, `IpAllowlistUserIpParam        = "user_ip"        // ipaddress`)
+	assert.Contains(t, caveats, "func NewIpAllowlistCaveat(context map[string]any) CaveatContext {")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "type CaveatContext struct")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+	require.NoError(t, err)
+	doc := string(raw)
+	assert.Contains(t, doc, "func (s *DocumentStore) CreateViewerRelationsWithCaveat(ctx context.Context, id Document, objects DocumentViewerObjects, caveat CaveatContext) error {")
+	assert.Contains(t, doc, "OptionalCaveat: optionalCaveat,")
+	assert.NotContains(t, doc, "CreateOwnerRelationsWithCaveat")
+
+	t.Run("only on the subject types that allow it", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, `
+caveat ip_allowlist(user_ip ipaddress) {
+    user_ip.in_cidr('10.0.0.0/8')
+}
+
+definition user {}
+
+definition document {
+    relation reader: user | user:* | user with ip_allowlist
+}`)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		raw, err := os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+		require.NoError(t, err)
+		doc := string(raw)
+		i := strings.Index(doc, "func (s *DocumentStore) CreateReaderRelationsWithCaveat(")
+		require.Positive(t, i)
+		writer := doc[i:]
+		writer = writer[:strings.Index(writer, "\n}\n")]
+		assert.Contains(t, writer, `checkCaveat(string(DocumentReaderRel), "user", caveat.Name, "ip_allowlist")`)
+		assert.Contains(t, writer, `checkCaveat(string(DocumentReaderRel), "user:*", caveat.Name); err != nil {`)
+		assert.Equal(t, 1, strings.Count(writer, "OptionalCaveat: optionalCaveat,"), "the wildcard is written without the caveat")
+		assert.NotContains(t, writer, `ObjectId: "*"`)
+		assert.Contains(t, doc, `Caveats: map[string][]string{
+			"user": {"ip_allowlist"},
+		},`)
+
+		raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "func checkCaveat(relation, subject string, caveat Caveat, allowed ...Caveat) error {")
+	})
+
+	// Without caveats no caveats file is written
+	outDir = t.TempDir()
+	g, err = NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+	assert.NoFileExists(t, filepath.Join(outDir, "caveats.gen.go"))
+}
+
+func TestGenerate_WildcardRelation(t *testing.T) {
+	schema := `
+definition user {}
+
+definition post {
+    relation reader: user:* | user
+    relation author: user
+    permission read = reader + author
+}`
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "post.gen.go"))
+	require.NoError(t, err)
+	post := string(raw)
+	assert.Contains(t, post, "UserWildcard bool   `json:\"user_wildcard,omitempty\"` // Every user (user:*)")
+	assert.Contains(t, post, `ObjectType: string(TypeUser), ObjectId: "*"`)
+	assert.Contains(t, post, "result.UserWildcard = true")
+	assert.Contains(t, post, "var PostRelations = []RelationInfo{")
+	assert.Contains(t, post, "PublicTypes: []string{\"user\"},\n\t\tPublic:      true,")
+	assert.NotContains(t, post, "AuthorWildcard")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "type RelationInfo struct")
+}
+
+func TestGenerate_PermissionMetadata(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "organization.gen.go"))
+	require.NoError(t, err)
+	org := string(raw)
+	assert.Contains(t, org, "var OrganizationPermissions = []PermissionInfo{")
+	assert.Contains(t, org, `Expression: "staff + team->member",`)
+	assert.Contains(t, org, `{"organization#team", "team#direct_member"},`)
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "type PermissionInfo struct")
+}
+
+func TestGenerate_TypedClientMethods(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "doctype.gen.go"))
+	require.NoError(t, err)
+	doctype := string(raw)
+	assert.Contains(t, doctype, "func (c *Client) CanEditDoctype(ctx context.Context, subject Subjecter, id Doctype) (bool, error)")
+	assert.Contains(t, doctype, "func (c *Client) WriteDoctypeAdmin(ctx context.Context, id Doctype, subject Subjecter) error")
+	assert.Contains(t, doctype, "update, err := NewDoctypeAdminTuple(id, subject)")
+	assert.Contains(t, doctype, "update.Operation = v1.RelationshipUpdate_OPERATION_DELETE")
+	assert.Contains(t, doctype, "func (c *Client) DeleteDoctypeAdmin(ctx context.Context, id Doctype, subject Subjecter) error")
+	assert.Contains(t, doctype, "func (r Doctype) Subject() Subject")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "user.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "func (r User) Subject() Subject")
+	assert.NotContains(t, string(raw), "Client methods", "user has no relations or permissions")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "type Subjecter interface")
+}
+
+func TestParseSchema_PermissionSubjectTypes(t *testing.T) {
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, `
+definition user {}
+definition bot {}
+
+definition team {
+    relation member: user | bot
+}
+
+definition document {
+    relation team: team
+    relation viewer: user:* | team#member
+    permission view = viewer + team->member
+}`)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := s.Definitions[3]
+	assert.Equal(t, []string{"user", "bot"}, doc.Permissions[0].SubjectTypes)
+}
+
+func TestPlural(t *testing.T) {
+	for name, want := range map[string]string{
+		"Document": "Documents",
+		"Policy":   "Policies",
+		"Day":      "Days",
+		"Box":      "Boxes",
+		"Status":   "Statuses",
+		"Branch":   "Branches",
+	} {
+		assert.Equal(t, want, plural(name), name)
+	}
+}
+
+func TestGenerate_LookupMethods(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "doctype.gen.go"))
+	require.NoError(t, err)
+	doctype := string(raw)
+	assert.Contains(t, doctype, "func (c *Client) ListDoctypesUserCanEdit(ctx context.Context, subject User, page Page) ([]Doctype, string, error) {")
+	assert.Contains(t, doctype, "func (c *Client) ListUsersWhoCanEditDoctype(ctx context.Context, id Doctype, page Page) ([]User, string, error) {")
+	assert.NotContains(t, doctype, "ListTeamsWhoCan", "teams are subjects only through team#member")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	client := string(raw)
+	assert.Contains(t, client, "type Page struct {")
+	assert.Contains(t, client, "func (c *Client) lookupResources(")
+	assert.Contains(t, client, "func (c *Client) lookupSubjects(")
+}
+
+func TestGenerate_BulkCheck(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithFake(), WithCache("lru"))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "doctype.gen.go"))
+	require.NoError(t, err)
+	doctype := string(raw)
+	assert.Contains(t, doctype, "type DoctypeCheck struct {\n\tID         Doctype\n\tPermission PermissionDoctype\n}")
+	assert.Contains(t, doctype, "func (c *Client) CheckDoctypes(ctx context.Context, subject Subjecter, checks []DoctypeCheck) (map[DoctypeCheck]bool, error) {")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "user.gen.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "UserCheck", "user has no permissions")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "resp, err := c.CheckBulkPermissions(ctx, req)")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "fake.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "\tCheckDoctypes(ctx context.Context, subject Subjecter, checks []DoctypeCheck) (map[DoctypeCheck]bool, error)\n")
+	assert.Contains(t, string(raw), "func (f *Fake) CheckDoctypes(")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "cache.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "func (c *CachedClient) CheckDoctypes(")
+}
+
+func TestGenerate_TupleBuilders(t *testing.T) {
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "organization.gen.go"))
+	require.NoError(t, err)
+	org := string(raw)
+	assert.Contains(t, org, "func NewOrganizationStaffTuple(id Organization, subject Subjecter) (*v1.RelationshipUpdate, error) {\n\treturn newTuple(id.ResourceReference(), OrganizationRelations[0], subject)")
+	assert.Contains(t, org, "return newTuple(id.ResourceReference(), OrganizationRelations[1], subject)")
+	assert.Contains(t, org, "update, err := NewOrganizationTeamTuple(id, subject)")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+	require.NoError(t, err)
+	client := string(raw)
+	assert.Contains(t, client, "func (r RelationInfo) Allows(s Subject) bool")
+	assert.Contains(t, client, "var ErrSubjectNotAllowed")
+}
+
+func TestGenerate_Middleware(t *testing.T) {
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoFileExists(t, filepath.Join(outDir, "middleware.gen.go"))
+		assert.NoFileExists(t, filepath.Join(outDir, "middleware_echo.gen.go"))
+	})
+
+	t.Run("chi uses the net/http middleware", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithMiddleware("chi"))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		raw, err := os.ReadFile(filepath.Join(outDir, "middleware.gen.go"))
+		require.NoError(t, err)
+		mw := string(raw)
+		assert.Contains(t, mw, "func NewMiddleware(client *Client, subject SubjectResolver) *Middleware")
+		assert.Contains(t, mw, "func (m *Middleware) RequireDoctypeEdit(resolver ResourceIDResolver) func(http.Handler) http.Handler")
+		assert.Contains(t, mw, `m.require("doctype#edit", resolver,`)
+		assert.Contains(t, mw, "return m.client.CanEditDoctype(ctx, subject, Doctype(id))")
+		assert.Contains(t, mw, "func (m *Middleware) RequireTeamMember(")
+		assert.Contains(t, mw, "httputil.WriteError(w, e)")
+		assert.NotContains(t, mw, "RequireUser", "user has no permissions")
+		assert.NoFileExists(t, filepath.Join(outDir, "middleware_echo.gen.go"))
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithMiddleware("echo"))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		assert.FileExists(t, filepath.Join(outDir, "middleware.gen.go"))
+		raw, err := os.ReadFile(filepath.Join(outDir, "middleware_echo.gen.go"))
+		require.NoError(t, err)
+		mw := string(raw)
+		assert.Contains(t, mw, `"github.com/labstack/echo/v4"`)
+		assert.Contains(t, mw, "func (m *Middleware) EchoRequireDoctypeEdit(resolver EchoResourceIDResolver) echo.MiddlewareFunc")
+	})
+}
+
+// Validate ────
+
+func TestValidate(t *testing.T) {
+	t.Run("valid schema", func(t *testing.T) {
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)))
+		require.NoError(t, err)
+		require.NoError(t, g.Validate())
+	})
+
+	t.Run("reports every problem with its position", func(t *testing.T) {
+		schema := `definition user {}
+
+definition team {
+    relation member: user
+    permission view = member
+}
+
+definition document {
+    relation owner: user | ghost
+    relation editor: team#admin | user with missing
+    relation team: team
+    permission edit = owner + writer
+    permission view = team->viewer + edit->member
+}`
+		f := writeSchema(t, schema)
+		g, err := NewGenerator(WithSchemaFile(f))
+		require.NoError(t, err)
+
+		err = g.Validate()
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		messages := make([]string, len(verr.Problems))
+		for i, p := range verr.Problems {
+			assert.Equal(t, f, p.File)
+			messages[i] = fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+		}
+		assert.Equal(t, []string{
+			`9:28: relation document#owner: subject type "ghost" is not defined`,
+			`10:22: relation document#editor: subject team#admin: team has no relation or permission "admin"`,
+			`10:35: relation document#editor: caveat "missing" is not defined`,
+			`12:31: permission document#edit: "writer" is not a relation or permission of document`,
+			`13:23: permission document#view: arrow team->viewer: none of team has a relation or permission "viewer"`,
+			`13:38: permission document#view: arrow edit->member: "edit" is a permission; arrows must start from a relation`,
+		}, messages)
+		assert.Contains(t, err.Error(), "schema has 6 problem(s):\n"+f+":9:28: ")
+	})
+
+	t.Run("positions are reported per file", func(t *testing.T) {
+		dir := t.TempDir()
+		users := filepath.Join(dir, "users.zed")
+		docs := filepath.Join(dir, "docs.zed")
+		require.NoError(t, os.WriteFile(users, []byte("definition user {}\n"), 0o644))
+		require.NoError(t, os.WriteFile(docs, []byte("definition document {\n    relation owner: user | group\n}\n"), 0o644))
+		g, err := NewGenerator(WithSchemaFile(dir))
+		require.NoError(t, err)
+
+		var verr *ValidationError
+		require.ErrorAs(t, g.Validate(), &verr)
+		require.Len(t, verr.Problems, 1)
+		assert.Equal(t, Problem{File: docs, Line: 2, Column: 28, Message: `relation document#owner: subject type "group" is not defined`}, verr.Problems[0])
+	})
+}
+
+// Watch ────
+
+func TestDiffGenerated(t *testing.T) {
+	before := map[string]string{
+		"client.gen.go":   "a\nb\n",
+		"document.gen.go": "a\nb\nc\n",
+		"folder.gen.go":   "x\n",
+	}
+	after := map[string]string{
+		"client.gen.go":   "a\nb\n",
+		"document.gen.go": "c\na\nd\ne\n",
+		"user.gen.go":     "u\nv\n",
+	}
+	assert.Equal(t, []string{
+		"document.gen.go: +2 -1",
+		"folder.gen.go: removed",
+		"user.gen.go: new (2 lines)",
+	}, diffGenerated(before, after))
+	assert.Empty(t, diffGenerated(before, before))
+}
+
+func TestWatch_RegeneratesOnSchemaChange(t *testing.T) {
+	schemaFile := writeSchema(t, "definition user {}\n")
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(schemaFile), WithOutputDir(outDir))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- g.Watch(ctx, out) }()
+
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "Watching ") }, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, out.String(), "user.gen.go: new")
+
+	require.NoError(t, os.WriteFile(schemaFile, []byte("definition user {}\n\ndefinition document {\n    relation owner: user\n}\n"), 0o644))
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "document.gen.go: new") }, 5*time.Second, 10*time.Millisecond)
+	assert.FileExists(t, filepath.Join(outDir, "document.gen.go"))
+
+	require.NoError(t, os.WriteFile(schemaFile, []byte("definition user {\n"), 0o644))
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "Generation failed: ") }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of Watch and
+// reads of the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Fake ────
+
+func TestParseSchema_PermissionTree(t *testing.T) {
+	schema := `
+definition user {}
+
+definition folder {
+    relation viewer: user
+    permission view = viewer
+}
+
+definition document {
+    relation parent: folder
+    relation owner: user
+    relation banned: user
+    permission view = (owner + parent->view) - banned
+    permission all_view = parent.all(view)
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := findDef(t, s, "document")
+	assert.Equal(t, "document", doc.FullName)
+	assert.Equal(t, &ExprNode{Kind: ExprExclusion, Children: []*ExprNode{
+		{Kind: ExprUnion, Children: []*ExprNode{
+			{Kind: ExprRelation, Relation: "owner"},
+			{Kind: ExprArrow, Relation: "parent", Target: "view"},
+		}},
+		{Kind: ExprRelation, Relation: "banned"},
+	}}, doc.Permissions[0].Tree)
+	assert.Equal(t, &ExprNode{Kind: ExprArrow, Relation: "parent", Target: "view", Function: "all"}, doc.Permissions[1].Tree)
+	assert.Equal(t, `&fakeExpr{kind: "arrow", relation: "parent", target: "view", all: true}`, fakeExprSource(doc.Permissions[1].Tree))
+}
+
+func TestFakeCases(t *testing.T) {
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, `
+definition user {}
+
+definition team {
+    relation member: user
+}
+
+definition document {
+    relation team: team
+    relation owner: user
+    relation editor: team#member
+    relation banned: user
+    permission edit = owner + editor + team->member
+    permission view = edit - banned
+}`)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	cases := fakeCases(s.Definitions)
+	names := make([]string, len(cases))
+	for i, c := range cases {
+		names[i] = c.Name
+	}
+	assert.Equal(t, []string{
+		"document edit without relationships",
+		"document edit via document#owner",
+		"document edit via document#team > team#member",
+		"document view without relationships",
+	}, names, "editor only allows a subject relation; view has an exclusion")
+
+	assert.Equal(t, fakeCase{
+		Name:          "document edit via document#team > team#member",
+		Relationships: []string{"document:document-0#team@team:team-1", "team:team-1#member@user:subject"},
+		Definition:    "Document",
+		ResourceID:    "document-0",
+		Permission:    "edit",
+		SubjectType:   "user",
+		SubjectID:     "subject",
+		Allowed:       true,
+	}, cases[2])
+	assert.Equal(t, "user", cases[3].SubjectType)
+	assert.False(t, cases[3].Allowed)
+}
+
+func TestGenerate_Fake(t *testing.T) {
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoFileExists(t, filepath.Join(outDir, "fake.gen.go"))
+		assert.NoFileExists(t, filepath.Join(outDir, "fake_gen_test.go"))
+	})
+
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithFake())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "fake.gen.go"))
+	require.NoError(t, err)
+	fake := string(raw)
+	assert.Contains(t, fake, "type Authorizer interface {")
+	assert.Contains(t, fake, "\tCanEditDoctype(ctx context.Context, subject Subjecter, id Doctype) (bool, error)\n")
+	assert.Contains(t, fake, "\tWriteTeamDirectMember(ctx context.Context, id Team, subject Subjecter) error\n")
+	assert.Contains(t, fake, "_ Authorizer = (*Client)(nil)")
+	assert.Contains(t, fake, `"org_member": &fakeExpr{kind: "union", children: []*fakeExpr{&fakeExpr{kind: "relation", relation: "staff"}, &fakeExpr{kind: "arrow", relation: "team", target: "member"}}},`)
+	assert.Contains(t, fake, "func (f *Fake) CanEditDoctype(ctx context.Context, subject Subjecter, id Doctype) (bool, error) {")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "fake_gen_test.go"))
+	require.NoError(t, err)
+	test := string(raw)
+	assert.Contains(t, test, "func TestFakePermissions(t *testing.T) {")
+	assert.Contains(t, test, `name: "organization org_member via organization#team > team#direct_member",`)
+	assert.Contains(t, test, `"team:team-1#direct_member@user:subject",`)
+}
+
+// OpenFGA ────
+
+func TestTranslateOpenFGA(t *testing.T) {
+	t.Run("model", func(t *testing.T) {
+		got, err := translateOpenFGA(`model
+  schema 1.1
+
+# Users
+type user
+
+type document
+  relations
+    define owner: [user]
+    define parent: [folder]
+    define viewer: [user, user:*, group#member with in_office] or owner or viewer from parent # inherited
+    define can_view: (viewer and approved) but not blocked
+
+condition in_office(ip: ipaddress, cidrs: list<string>) {
+  ip.in_cidr(cidrs[0])
+}`)
+		require.NoError(t, err)
+		assert.Equal(t, `
+
+
+ // Users
+definition user {
+
+} definition document {
+
+relation owner: user
+relation parent: folder
+relation viewer_direct: user | user:* | group#member with in_office; permission viewer = viewer_direct + owner + parent->viewer // inherited
+permission can_view = (viewer & approved) - blocked
+
+} caveat in_office(ip ipaddress, cidrs list<string>) {
+  ip.in_cidr(cidrs[0])
+}`, got, "every statement stays on its line")
+	})
+
+	t.Run("closes the last type", func(t *testing.T) {
+		got, err := translateOpenFGA("model\n  schema 1.1\ntype user")
+		require.NoError(t, err)
+		assert.Equal(t, "\n\ndefinition user {\n}", got)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		model string
+		err   string
+	}{
+		{"missing schema", "model\ntype user", `missing "schema 1.1"`},
+		{"schema 1.0", "model\n  schema 1.0", `line 2: unsupported schema version "1.0"`},
+		{"mixed operators", "model\n schema 1.1\ntype doc\n relations\n  define view: a or b and c", "line 5: define view: mixing or, and, and but not requires parentheses"},
+		{"chained but not", "model\n schema 1.1\ntype doc\n relations\n  define view: a but not b but not c", "mixing or, and, and but not requires parentheses"},
+		{"direct types after an operand", "model\n schema 1.1\ntype doc\n relations\n  define view: owner or [user]", "direct types must come first"},
+		{"unclosed direct types", "model\n schema 1.1\ntype doc\n relations\n  define view: [user", `missing "]"`},
+		{"unclosed parenthesis", "model\n schema 1.1\ntype doc\n relations\n  define view: (a or b", `missing ")"`},
+		{"define outside a type", "model\n schema 1.1\n  define view: [user]", "line 3: define outside a type"},
+		{"modular model", "module docs\n", "modular models"},
+		{"unterminated condition", "model\n schema 1.1\ncondition c(x: int) {\n x < 1", "unterminated condition"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := translateOpenFGA(tc.model)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestParseSchema_OpenFGA(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.fga"), []byte(`model
+  schema 1.1
+
+type user
+
+type folder
+  relations
+    define viewer: [user]
+
+type document
+  relations
+    define parent: [folder]
+    define owner: [user]
+    define editor: [user with in_office] or owner
+    define can_view: editor or viewer from parent
+
+condition in_office(ip: ipaddress) {
+  ip.in_cidr('10.0.0.0/8')
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.zed"), []byte("definition ignored {}"), 0o644))
+
+	g, err := NewGenerator(WithSchemaFile(dir), WithDialect(DialectOpenFGA))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+	require.Len(t, s.Definitions, 3, "a directory stands for its .fga files")
+
+	doc := findDef(t, s, "document")
+	var relations, permissions []string
+	for _, r := range doc.Relations {
+		relations = append(relations, r.Name)
+	}
+	for _, p := range doc.Permissions {
+		permissions = append(permissions, p.Name+" = "+p.Expression)
+	}
+	assert.Equal(t, []string{"parent", "owner", "editor_direct"}, relations)
+	assert.Equal(t, []string{"editor = editor_direct + owner", "can_view = editor + parent->viewer"}, permissions)
+	assert.Equal(t, []string{"in_office"}, doc.Relations[2].Caveats())
+	require.Len(t, s.Caveats, 1)
+	assert.Equal(t, "in_office", s.Caveats[0].Name)
+
+	t.Run("problems point at the model's lines", func(t *testing.T) {
+		f := filepath.Join(t.TempDir(), "model.fga")
+		require.NoError(t, os.WriteFile(f, []byte("model\n  schema 1.1\n\ntype user\n\ntype document\n  relations\n    define owner: [usr]\n"), 0o644))
+		g, err := NewGenerator(WithSchemaFile(f), WithDialect(DialectOpenFGA))
+		require.NoError(t, err)
+
+		var verr *ValidationError
+		require.ErrorAs(t, g.Validate(), &verr)
+		require.Len(t, verr.Problems, 1)
+		assert.Equal(t, f, verr.Problems[0].File)
+		assert.Equal(t, 8, verr.Problems[0].Line)
+	})
+}
+
+// Diff ────
+
+func TestDiff(t *testing.T) {
+	parse := func(t *testing.T, schema string) *Schema {
+		t.Helper()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)))
+		require.NoError(t, err)
+		s, err := g.ParseSchema()
+		require.NoError(t, err)
+		return s
+	}
+
+	old := parse(t, `
+definition user {}
+definition team {}
+definition group {
+    relation member: user
+}
+caveat in_office(ip ipaddress) {
+    ip.in_cidr('10.0.0.0/8')
+}
+definition document {
+    relation owner: user
+    relation viewer: user | user:* | group#member with in_office
+    relation parent: group
+    relation editor: user | group#member
+    permission view = viewer + owner
+    permission delete = owner
+    permission manage = owner
+    permission share = editor
+}`)
+	cur := parse(t, `
+definition user {}
+definition group {
+    relation member: user
+    permission manage = member
+}
+definition document {
+    relation author: user
+    relation viewer: user | group#member
+    relation parent: group | user
+    permission editor = author
+    permission read = viewer + author
+    permission delete = author
+    permission share = author
+}`)
+
+	assert.Equal(t, []Change{
+		{Subject: "document#viewer", Message: "subject types narrowed: no longer allows user:*", Breaking: true},
+		{Subject: "document#viewer", Message: "caveats narrowed: group#member no longer allows in_office", Breaking: true},
+		{Subject: "document#parent", Message: "subject types widened: now allows user"},
+		{Subject: "document#editor", Message: "relation became a permission", Breaking: true},
+		{Subject: "document#delete", Message: `expression changed from "owner" to "author"`},
+		{Subject: "document#share", Message: `expression changed from "editor" to "author"`},
+		{Subject: "document#owner", Message: "relation renamed to author", Breaking: true},
+		{Subject: "document#view", Message: "permission renamed to read", Breaking: true},
+		{Subject: "document#manage", Message: "permission removed", Breaking: true},
+		{Subject: "group#manage", Message: "permission added"},
+		{Subject: "team", Message: "definition removed", Breaking: true},
+		{Subject: "caveat in_office", Message: "caveat removed", Breaking: true},
+	}, Diff(old, cur))
+
+	assert.Empty(t, Diff(cur, cur))
+
+	t.Run("ambiguous renames are removals and additions", func(t *testing.T) {
+		old := parse(t, "definition user {}\ndefinition document {\n relation owner: user\n relation editor: user\n}")
+		cur := parse(t, "definition user {}\ndefinition document {\n relation author: user\n}")
+		assert.Equal(t, []string{
+			"document#owner: relation removed (breaking)",
+			"document#editor: relation removed (breaking)",
+			"document#author: relation added",
+		}, changeStrings(Diff(old, cur)))
+	})
+}
+
+func changeStrings(changes []Change) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		out[i] = c.String()
+	}
+	return out
+}
+
+// Permission matrix ────
+
+func TestGenerate_PermissionsDoc(t *testing.T) {
+	schema := `
+definition user {}
+
+definition folder {
+    relation viewer: user | user:*
+    permission view = viewer
+}
+
+caveat in_office(ip ipaddress) {
+    ip.in_cidr('10.0.0.0/8')
+}
+
+definition document {
+    relation parent: folder
+    relation owner: user
+    relation approver: user with in_office
+    relation banned: user
+    permission edit = owner & approver
+    permission view = (parent->view + edit) - banned
+    permission audit = owner - (banned - approver)
+}`
+
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoFileExists(t, filepath.Join(outDir, "PERMISSIONS.md"))
+	})
+
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir), WithPermissionsDoc())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "PERMISSIONS.md"))
+	require.NoError(t, err)
+	doc := string(raw)
+	assert.Contains(t, doc, "| `approver` | `user` | `in_office` |\n")
+	assert.Contains(t, doc, "| `viewer` | `user`, `user:*` |  |\n")
+	assert.Contains(t, doc, "| Permission | Expression | `parent` | `owner` | `approver` | `banned` |\n")
+	assert.Contains(t, doc, "| `edit` | `owner & approver` |  | requires | requires |  |\n")
+	assert.Contains(t, doc, "| `view` | `(parent->view + edit) - banned` | grants | requires | requires | excludes |\n")
+	assert.Contains(t, doc, "| `audit` | `owner - (banned - approver)` |  | grants | grants | excludes |\n", "excluding an exclusion grants")
+	assert.Contains(t, doc, "- document#parent → folder#viewer (user, user:*)\n")
+	assert.Contains(t, doc, "## `user`\n\nNo relations or permissions.\n")
+}
+
+// Package name and file layout ────
+
+func TestGenerate_PackageNameAndFileLayout(t *testing.T) {
+	schema := `
+definition platform/user {}
+
+definition platform/document {
+    relation owner: platform/user
+    permission edit = owner
+}`
+
+	t.Run("package of the namespace prefix, file per definition", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		assert.NoFileExists(t, filepath.Join(outDir, "definitions.gen.go"))
+		raw, err := os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "package platform\n")
+	})
+
+	t.Run("chosen package, single file", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(
+			WithSchemaFile(writeSchema(t, schema)),
+			WithOutputDir(outDir),
+			WithPackageName("accessctl"),
+			WithFilePerDefinition(false),
+		)
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		assert.NoFileExists(t, filepath.Join(outDir, "document.gen.go"))
+		assert.NoFileExists(t, filepath.Join(outDir, "user.gen.go"))
+		raw, err := os.ReadFile(filepath.Join(outDir, "client.gen.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "package accessctl\n")
+
+		raw, err = os.ReadFile(filepath.Join(outDir, "definitions.gen.go"))
+		require.NoError(t, err)
+		code := string(raw)
+		assert.Contains(t, code, "package accessctl\n")
+		assert.Equal(t, 1, strings.Count(code, "\nimport ("), "one import block for all definitions")
+		assert.Contains(t, code, "\t\"context\"\n")
+		assert.Less(t, strings.Index(code, "const TypeDocument Type"), strings.Index(code, "const TypeUser Type"), "definitions in name order")
+	})
+}
+
+// Seed ────
+
+func TestGenerate_Seed(t *testing.T) {
+	schema := `
+caveat in_office(cidr string) {
+    cidr == '10.0.0.0/8'
+}
+
+definition user {}
+
+definition group {
+    relation member: user
+}
+
+definition document {
+    relation owner: user
+    relation viewer: user | user:* | group#member | user with in_office
+    permission view = viewer + owner
+}`
+
+	generate := func(t *testing.T, seed string) (string, error) {
+		t.Helper()
+		dir := t.TempDir()
+		seedFile := filepath.Join(dir, "seed.yaml")
+		require.NoError(t, os.WriteFile(seedFile, []byte(seed), 0o644))
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(dir), WithSeedFile(seedFile))
+		require.NoError(t, err)
+		if err := g.Generate(); err != nil {
+			return "", err
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, "seed.gen.go"))
+		require.NoError(t, err)
+		return string(raw), nil
+	}
+
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoFileExists(t, filepath.Join(outDir, "seed.gen.go"))
+	})
+
+	t.Run("relationships", func(t *testing.T) {
+		code, err := generate(t, `# Platform bootstrap
+relationships:
+  - document:handbook#owner@user:alice
+  - document:handbook#viewer@group:staff#member
+  - document:handbook#viewer@user:*
+  - document:handbook#viewer@user:bob[in_office:{"cidr":"10.0.0.0/8"}]
+`)
+		require.NoError(t, err)
+		assert.Contains(t, code, "// seedRelationships are the relationships of seed.yaml.\n")
+		assert.Contains(t, code, `Resource: &v1.ObjectReference{ObjectType: "document", ObjectId: "handbook"},`)
+		assert.Contains(t, code, `Object:           &v1.ObjectReference{ObjectType: "group", ObjectId: "staff"},
+			OptionalRelation: "member",`)
+		assert.Contains(t, code, `Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "*"},`)
+		assert.Contains(t, code, "CaveatName: \"in_office\",\n\t\t\tContext:    seedContext(`{\"cidr\":\"10.0.0.0/8\"}`),")
+		assert.Contains(t, code, "func (c *Client) Seed(ctx context.Context) error {")
+		assert.Contains(t, code, "Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,")
+		assert.Contains(t, code, "func seedContext(data string) *structpb.Struct {")
+	})
+
+	t.Run("no caveat context", func(t *testing.T) {
+		code, err := generate(t, "relationships:\n  - document:handbook#owner@user:alice\n")
+		require.NoError(t, err)
+		assert.NotContains(t, code, "structpb")
+	})
+
+	for _, tc := range []struct {
+		name string
+		seed string
+		err  string
+	}{
+		{"invalid syntax", "relationships:\n  - document:handbook@user:alice\n", `seed.yaml:2: invalid relationship "document:handbook@user:alice"`},
+		{"unknown type", "relationships:\n  - folder:root#owner@user:alice\n", `"folder" is not a definition of the schema`},
+		{"permission", "relationships:\n  - document:handbook#owner@user:alice\n  - document:handbook#view@user:alice\n", "seed.yaml:3: relationship \"document:handbook#view@user:alice\": view is a permission of document"},
+		{"unknown relation", "relationships:\n  - document:handbook#editor@user:alice\n", `document has no relation "editor"`},
+		{"subject not allowed", "relationships:\n  - document:handbook#owner@group:staff#member\n", "document#owner does not allow subject group#member"},
+		{"wildcard not allowed", "relationships:\n  - document:handbook#owner@user:*\n", "document#owner does not allow subject user:*"},
+		{"caveat not allowed", "relationships:\n  - document:handbook#owner@user:alice[in_office]\n", "document#owner does not allow caveat in_office"},
+		{"caveat not allowed on wildcard", "relationships:\n  - document:handbook#viewer@user:*[in_office]\n", "document#viewer does not allow caveat in_office on subject user:*"},
+		{"caveat not allowed on subject relation", "relationships:\n  - document:handbook#viewer@group:staff#member[in_office]\n", "does not allow caveat in_office on subject group#member"},
+		{"expiration", "relationships:\n  - document:handbook#owner@user:alice[expiration:2030-01-01T00:00:00Z]\n", "expiration is not supported"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generate(t, tc.seed)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+// Doc comments ────
+
+func TestGenerate_DocComments(t *testing.T) {
+	schema := `
+/** A person signed in to the platform. */
+definition user {}
+
+// A document in a workspace.
+// Only its owner may delete it.
+definition document {
+    /**
+     * owner created the document.
+     *
+     * There is exactly one.
+     */
+    relation owner: user // Not a doc comment of owner
+    relation viewer: user
+
+    /* Read access. */
+    permission view = viewer + owner
+}
+
+// Requests from the office network.
+caveat in_office(ip ipaddress) {
+    ip.in_cidr('10.0.0.0/8')
+}`
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, schema)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	doc := findDef(t, s, "document")
+	assert.Equal(t, "A document in a workspace.\nOnly its owner may delete it.", doc.Doc)
+	assert.Equal(t, "owner created the document.\n\nThere is exactly one.", doc.Relations[0].Doc)
+	assert.Empty(t, doc.Relations[1].Doc, "a trailing comment belongs to no one")
+	assert.Equal(t, "Read access.", doc.Permissions[0].Doc)
+	assert.Equal(t, "A person signed in to the platform.", findDef(t, s, "user").Doc)
+	require.Len(t, s.Caveats, 1)
+	assert.Equal(t, "Requests from the office network.", s.Caveats[0].Doc)
+
+	outDir := t.TempDir()
+	g, err = NewGenerator(WithSchemaFile(writeSchema(t, schema)), WithOutputDir(outDir), WithPermissionsDoc())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "document.gen.go"))
+	require.NoError(t, err)
+	code := string(raw)
+	assert.Contains(t, code, "// A document in a workspace.\n// Only its owner may delete it.\nconst TypeDocument Type = \"document\"\n")
+	assert.Contains(t, code, "// owner created the document.\n//\n// There is exactly one.\nconst DocumentOwnerRel RelationDocument = \"owner\"\n")
+	assert.Contains(t, code, "\nconst DocumentViewerRel RelationDocument")
+	assert.Contains(t, code, "// DocumentViewPerm is computed as: viewer + owner\n//\n// Read access.\nconst DocumentViewPerm")
+	assert.Contains(t, code, "// Document is the strongly-typed resource ID for a document object.\n//\n// A document in a workspace.\n// Only its owner may delete it.\ntype Document string\n")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "caveats.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "//\n// Requests from the office network.\nconst CaveatInOffice Caveat")
+
+	raw, err = os.ReadFile(filepath.Join(outDir, "PERMISSIONS.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "## `document`\n\nA document in a workspace.\nOnly its owner may delete it.\n\n| Relation |")
+}
+
+// Cache ────
+
+func TestCacheDependencies(t *testing.T) {
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	assert.Equal(t, []cacheDependency{
+		{Type: "Doctype", Dependents: []string{"Doctype"}},
+		{Type: "Organization", Dependents: []string{"Organization"}},
+		{Type: "Team", Dependents: []string{"Organization", "Team"}},
+		{Type: "User", Dependents: []string{"User"}},
+	}, cacheDependencies(s.Definitions))
+}
+
+func TestGenerate_Cache(t *testing.T) {
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoFileExists(t, filepath.Join(outDir, "cache.gen.go"))
+		assert.NoFileExists(t, filepath.Join(outDir, "cache_redis.gen.go"))
+	})
+
+	t.Run("lru", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithCache("lru"))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		raw, err := os.ReadFile(filepath.Join(outDir, "cache.gen.go"))
+		require.NoError(t, err)
+		cache := string(raw)
+		assert.Contains(t, cache, "type PermissionCache interface {")
+		assert.Contains(t, cache, "\tTypeTeam:         {TypeOrganization, TypeTeam},\n")
+		assert.Contains(t, cache, "func (c *CachedClient) CanEditDoctype(ctx context.Context, subject Subjecter, id Doctype) (bool, error) {")
+		assert.Contains(t, cache, "func (c *CachedClient) WriteTeamDirectMember(ctx context.Context, id Team, subject Subjecter) error {")
+		assert.Contains(t, cache, "func NewLRUCache(size int) *LRUCache {")
+		assert.NotContains(t, cache, "_ Authorizer = (*CachedClient)(nil)", "Authorizer is generated with the fake")
+		assert.NoFileExists(t, filepath.Join(outDir, "cache_redis.gen.go"))
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithCache("redis"), WithFake())
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		raw, err := os.ReadFile(filepath.Join(outDir, "cache.gen.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "_ Authorizer = (*CachedClient)(nil)")
+		assert.NotContains(t, string(raw), "LRUCache")
+
+		raw, err = os.ReadFile(filepath.Join(outDir, "cache_redis.gen.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "func NewRedisCache(client redis.UniversalClient, prefix string) *RedisCache {")
+	})
+}
+
+// Fixtures ────
+
+func TestFixtureRelationships(t *testing.T) {
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, `
+caveat in_office(cidr string) {
+    cidr == '10.0.0.0/8'
+}
+
+definition user {}
+
+definition team {
+    relation member: user
+}
+
+definition document {
+    relation viewer: user:* | team#member | user with in_office
+}`)))
+	require.NoError(t, err)
+	s, err := g.ParseSchema()
+	require.NoError(t, err)
+
+	assert.Equal(t, []fixtureRelationship{
+		{Method: "TeamMemberUser", ResourceType: "team", Relation: "member", SubjectType: "user"},
+		{Method: "DocumentViewerUserWildcard", ResourceType: "document", Relation: "viewer", SubjectType: "user", Wildcard: true},
+		{Method: "DocumentViewerTeamMember", ResourceType: "document", Relation: "viewer", SubjectType: "team", SubjectRelation: "member"},
+		{Method: "DocumentViewerUser", ResourceType: "document", Relation: "viewer", SubjectType: "user", Caveat: "in_office"},
+	}, fixtureRelationships([]Definition{findDef(t, s, "team"), findDef(t, s, "document")}))
+}
+
+func TestGenerate_Fixtures(t *testing.T) {
+	t.Run("not generated by default", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir))
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+		assert.NoDirExists(t, filepath.Join(outDir, "fixtures"))
+	})
+
+	outDir := t.TempDir()
+	g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir), WithFixtures())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "fixtures", "fixtures.gen.go"))
+	require.NoError(t, err)
+	fixtures := string(raw)
+	assert.Contains(t, fixtures, "\npackage fixtures\n")
+	assert.Contains(t, fixtures, "\t\"organization#staff\":   {\"user\", \"team#member\"},\n")
+	assert.Contains(t, fixtures, "\tb.OrganizationStaffTeamMember(b.NewID(\"organization\"), b.NewID(\"team\"))\n")
+	assert.Contains(t, fixtures, "func (b *Builder) DoctypeAdminTeamMember(id, subjectID string) *Builder {")
+	assert.Contains(t, fixtures, "func (b *Builder) Hierarchy(r *rand.Rand, root Object, levels ...Level) [][]Object {")
+	assert.Contains(t, fixtures, "func (b *Builder) Write(ctx context.Context, client v1.PermissionsServiceClient) error {")
+}
+
+// Plugins ────
+
+func TestGenerate_Plugin(t *testing.T) {
+	constants := func(s Schema) ([]GeneratedFile, error) {
+		var b strings.Builder
+		for _, def := range s.Definitions {
+			fmt.Fprintf(&b, "export const %sType = %q;\n", def.Name, def.FullName)
+		}
+		return []GeneratedFile{{Path: "ts/types.ts", Content: []byte(b.String())}}, nil
+	}
+
+	t.Run("writes files", func(t *testing.T) {
+		outDir := t.TempDir()
+		g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(outDir),
+			WithPlugin("typescript", constants),
+			WithPlugin("go", func(Schema) ([]GeneratedFile, error) {
+				return []GeneratedFile{{Path: "extra.gen.go", Content: []byte("package authz\nconst   Extra=1\n")}}, nil
+			}),
+		)
+		require.NoError(t, err)
+		require.NoError(t, g.Generate())
+
+		raw, err := os.ReadFile(filepath.Join(outDir, "ts", "types.ts"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "export const teamType = \"team\";\n")
+		raw, err = os.ReadFile(filepath.Join(outDir, "extra.gen.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package authz\n\nconst Extra = 1\n", string(raw))
+	})
+
+	t.Run("nil plugin", func(t *testing.T) {
+		_, err := NewGenerator(WithSchemaFile("schema.zed"), WithPlugin("broken", nil))
+		require.ErrorContains(t, err, `plugin "broken" is nil`)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		plugin Plugin
+		err    string
+	}{
+		{"error", func(Schema) ([]GeneratedFile, error) { return nil, fmt.Errorf("boom") }, "plugin p: boom"},
+		{"outside output dir", func(Schema) ([]GeneratedFile, error) {
+			return []GeneratedFile{{Path: "../escape.ts"}}, nil
+		}, `plugin p: invalid file path "../escape.ts"`},
+		{"absolute path", func(Schema) ([]GeneratedFile, error) {
+			return []GeneratedFile{{Path: "/tmp/escape.ts"}}, nil
+		}, `plugin p: invalid file path "/tmp/escape.ts"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGenerator(WithSchemaFile(writeSchema(t, fullSchema)), WithOutputDir(t.TempDir()), WithPlugin("p", tc.plugin))
+			require.NoError(t, err)
+			require.ErrorContains(t, g.Generate(), tc.err)
+		})
+	}
+}
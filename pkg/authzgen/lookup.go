@@ -0,0 +1,40 @@
+package authzgen
+
+import (
+	"slices"
+	"strings"
+)
+
+// subjectTypes returns the types of the objects perm may be granted to: the
+// direct subject types, wildcards included, of the relations its walks end
+// at, in the order the walks reach them.
+func subjectTypes(byName map[string]Definition, perm Permission) []string {
+	var types []string
+	for _, walk := range perm.Walks {
+		ns, name, _ := strings.Cut(walk[len(walk)-1], "#")
+		rel, ok := findDefRelation(byName[ns], name)
+		if !ok {
+			continue // A recursive walk
+		}
+		for _, t := range rel.Types {
+			if t.Relation == "" && !slices.Contains(types, t.Type) {
+				types = append(types, t.Type)
+			}
+		}
+	}
+	return types
+}
+
+// plural returns the English plural of a Go name, e.g. "Documents",
+// "Policies", or "Boxes", for the names of the lookup methods.
+func plural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
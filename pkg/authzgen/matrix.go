@@ -0,0 +1,138 @@
+package authzgen
+
+import (
+	"slices"
+	"strings"
+)
+
+// Roles a relation plays in a permission, shown in the permission matrix.
+const (
+	roleGrants   = "grants"   // Holding the relation is enough
+	roleRequires = "requires" // The relation is an operand of an intersection
+	roleExcludes = "excludes" // Holding the relation denies the permission
+)
+
+// matrixDefinition is a section of PERMISSIONS.md.
+type matrixDefinition struct {
+	Name        string
+	Doc         string
+	Relations   []matrixRelation
+	Columns     []string // Relation names, the columns of the matrix
+	Permissions []matrixPermission
+}
+
+type matrixRelation struct {
+	Name     string
+	Subjects []string // E.g. "user", "user:*", "group#member"
+	Caveats  []string
+}
+
+type matrixPermission struct {
+	Name       string
+	Expression string
+	Cells      []string // Roles of each column's relation, e.g. "grants, excludes"
+	Paths      []string // Walks, e.g. "document#parent → folder#viewer (user)"
+}
+
+// permissionMatrix describes every definition for PERMISSIONS.md: the
+// subjects of its relations, and for each permission the role every
+// relation plays in it and the paths it walks to subjects.
+func permissionMatrix(definitions []Definition) []matrixDefinition {
+	byName := make(map[string]Definition, len(definitions))
+	for _, def := range definitions {
+		byName[def.FullName] = def
+	}
+
+	var out []matrixDefinition
+	for _, def := range definitions {
+		md := matrixDefinition{Name: def.FullName, Doc: def.Doc}
+		for _, rel := range def.Relations {
+			md.Relations = append(md.Relations, matrixRelation{
+				Name:     rel.Name,
+				Subjects: relationSubjects(rel),
+				Caveats:  rel.Caveats(),
+			})
+			md.Columns = append(md.Columns, rel.Name)
+		}
+		for _, perm := range def.Permissions {
+			roles := make(map[string][]string)
+			relationRoles(def, perm.Tree, roleGrants, roles, map[string]bool{perm.Name: true})
+			mp := matrixPermission{Name: perm.Name, Expression: perm.Expression}
+			for _, rel := range def.Relations {
+				mp.Cells = append(mp.Cells, strings.Join(roles[rel.Name], ", "))
+			}
+			for _, walk := range perm.Walks {
+				mp.Paths = append(mp.Paths, formatPath(byName, walk))
+			}
+			md.Permissions = append(md.Permissions, mp)
+		}
+		out = append(out, md)
+	}
+	return out
+}
+
+// relationRoles records in roles the role each relation of def plays in
+// node, expanding the permissions of def it references. role is the role of
+// node itself in the permission.
+func relationRoles(def Definition, node *ExprNode, role string, roles map[string][]string, expanding map[string]bool) {
+	switch node.Kind {
+	case ExprUnion:
+		for _, child := range node.Children {
+			relationRoles(def, child, role, roles, expanding)
+		}
+	case ExprIntersection:
+		childRole := role
+		if role == roleGrants {
+			childRole = roleRequires
+		}
+		for _, child := range node.Children {
+			relationRoles(def, child, childRole, roles, expanding)
+		}
+	case ExprExclusion:
+		relationRoles(def, node.Children[0], role, roles, expanding)
+		// Excluding an exclusion grants again: in a - (b - c), c restores a
+		subtracted := roleExcludes
+		if role == roleExcludes {
+			subtracted = roleGrants
+		}
+		for _, child := range node.Children[1:] {
+			relationRoles(def, child, subtracted, roles, expanding)
+		}
+	case ExprRelation:
+		if i := slices.IndexFunc(def.Permissions, func(p Permission) bool { return p.Name == node.Relation }); i != -1 {
+			if !expanding[node.Relation] {
+				expanding[node.Relation] = true
+				relationRoles(def, def.Permissions[i].Tree, role, roles, expanding)
+				delete(expanding, node.Relation)
+			}
+			return
+		}
+		addRole(roles, node.Relation, role)
+	case ExprArrow:
+		addRole(roles, node.Relation, role)
+	}
+}
+
+func addRole(roles map[string][]string, relation, role string) {
+	if slices.Contains(roles[relation], role) {
+		return
+	}
+	roles[relation] = append(roles[relation], role)
+	order := []string{roleGrants, roleRequires, roleExcludes}
+	slices.SortFunc(roles[relation], func(a, b string) int {
+		return slices.Index(order, a) - slices.Index(order, b)
+	})
+}
+
+// formatPath renders a walk with the subjects of the relation it ends at,
+// e.g. "document#parent → folder#viewer (user, group#member)". A walk that
+// ends at a permission being expanded is recursive.
+func formatPath(byName map[string]Definition, walk []string) string {
+	path := strings.Join(walk, " → ")
+	ns, name, _ := strings.Cut(walk[len(walk)-1], "#")
+	rel, ok := findDefRelation(byName[ns], name)
+	if !ok {
+		return path + " (recursive)"
+	}
+	return path + " (" + strings.Join(relationSubjects(rel), ", ") + ")"
+}
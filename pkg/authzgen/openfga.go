@@ -0,0 +1,337 @@
+package authzgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Schema dialects accepted by WithDialect.
+const (
+	DialectSpiceDB = "spicedb" // SpiceDB schema language, in .zed files
+	DialectOpenFGA = "openfga" // OpenFGA modeling language (schema 1.1), in .fga files
+)
+
+// translateOpenFGA translates an OpenFGA model into the equivalent SpiceDB
+// schema, so both dialects share the compiler and the Schema model. The
+// translation keeps every statement on its line, so errors and validation
+// problems point at the right line of the model.
+//
+// A type becomes a definition. A relation defined only by direct types
+// ("define owner: [user]") becomes a relation, and one defined only by other
+// relations becomes a permission. A relation combining both
+// ("define viewer: [user] or editor") becomes a relation named <name>_direct
+// holding the direct types and a permission <name> replacing them with that
+// relation, so tuples are written to viewer_direct and checked on viewer.
+// "or", "and", "but not", and "x from y" become +, &, -, and y->x, and a
+// condition becomes a caveat.
+func translateOpenFGA(model string) (string, error) {
+	var (
+		out       []string
+		openType  bool // A definition is open until the next type or condition
+		depth     int  // Brace depth inside a condition's body
+		sawSchema bool
+	)
+	for i, line := range strings.Split(model, "\n") {
+		if depth > 0 {
+			// CEL is the same in both dialects
+			out = append(out, line)
+			depth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+
+		code, comment := splitFGAComment(line)
+		fields := strings.Fields(code)
+		var stmt string
+		if len(fields) > 0 {
+			var err error
+			switch fields[0] {
+			case "model":
+				if len(fields) != 1 {
+					err = fmt.Errorf("unexpected %q after model", fields[1])
+				}
+			case "schema":
+				if len(fields) != 2 || fields[1] != "1.1" {
+					err = fmt.Errorf("unsupported schema version %q (want 1.1)", strings.Join(fields[1:], " "))
+				}
+				sawSchema = true
+			case "type":
+				if len(fields) != 2 {
+					err = fmt.Errorf("type declaration must be \"type <name>\"")
+					break
+				}
+				if openType {
+					stmt = "} "
+				}
+				stmt += "definition " + fields[1] + " {"
+				openType = true
+			case "relations":
+				if !openType || len(fields) != 1 {
+					err = fmt.Errorf("relations must follow a type declaration")
+				}
+			case "define":
+				if !openType {
+					err = fmt.Errorf("define outside a type")
+					break
+				}
+				stmt, err = translateFGADefine(strings.TrimPrefix(strings.TrimSpace(code), "define"))
+			case "condition":
+				if openType {
+					stmt = "} "
+					openType = false
+				}
+				var caveat string
+				caveat, err = translateFGACondition(code)
+				stmt += caveat
+				depth = strings.Count(caveat, "{") - strings.Count(caveat, "}")
+			case "module", "extend":
+				err = fmt.Errorf("modular models (module, extend type) are not supported")
+			default:
+				err = fmt.Errorf("unexpected %q", fields[0])
+			}
+			if err != nil {
+				return "", fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+		if comment != "" {
+			stmt += " //" + comment
+		}
+		out = append(out, stmt)
+	}
+
+	if !sawSchema {
+		return "", fmt.Errorf("missing \"schema 1.1\" declaration")
+	}
+	if depth > 0 {
+		return "", fmt.Errorf("unterminated condition")
+	}
+	if openType {
+		out = append(out, "}")
+	}
+	return strings.Join(out, "\n"), nil
+}
+
+// splitFGAComment splits a line at the "#" starting a comment. A "#" inside
+// a word, as in group#member, is not a comment.
+func splitFGAComment(line string) (code, comment string) {
+	for i, r := range line {
+		if r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i], line[i+1:]
+		}
+	}
+	return line, ""
+}
+
+// translateFGADefine translates the "<name>: <expression>" of a define.
+func translateFGADefine(def string) (string, error) {
+	name, expr, ok := strings.Cut(def, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", fmt.Errorf("define must be \"define <name>: <expression>\"")
+	}
+
+	p := &fgaParser{tokens: tokenizeFGA(expr), direct: name + "_direct"}
+	operands, op, err := p.parseExpr(true)
+	if err == nil && p.peek() != "" {
+		err = fmt.Errorf("unexpected %q", p.peek())
+	}
+	if err != nil {
+		return "", fmt.Errorf("define %s: %w", name, err)
+	}
+
+	switch {
+	case p.types == "":
+		return "permission " + name + " = " + strings.Join(operands, op), nil
+	case len(operands) == 1:
+		return "relation " + name + ": " + p.types, nil
+	default:
+		return "relation " + p.direct + ": " + p.types + "; permission " + name + " = " + strings.Join(operands, op), nil
+	}
+}
+
+// fgaParser parses the expression of a define. Operators may not be mixed
+// without parentheses, and direct types may only be the first operand of
+// the expression, as in OpenFGA.
+type fgaParser struct {
+	tokens []string
+	pos    int
+	direct string // Relation standing for the direct types in the expression
+	types  string // The direct types, in SpiceDB syntax
+}
+
+func (p *fgaParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *fgaParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// parseExpr parses operands joined by one operator and returns them with
+// the SpiceDB operator, e.g. " + ".
+func (p *fgaParser) parseExpr(top bool) ([]string, string, error) {
+	var operands []string
+	if top && p.peek() == "[" {
+		p.next()
+		types, err := p.parseTypes()
+		if err != nil {
+			return nil, "", err
+		}
+		p.types = types
+		operands = append(operands, p.direct)
+	} else {
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, "", err
+		}
+		operands = append(operands, operand)
+	}
+
+	op := ""
+	for tok := p.peek(); tok != "" && tok != ")"; tok = p.peek() {
+		var next string
+		switch p.next() {
+		case "or":
+			next = " + "
+		case "and":
+			next = " & "
+		case "but":
+			if p.next() != "not" {
+				return nil, "", fmt.Errorf(`expected "not" after "but"`)
+			}
+			next = " - "
+		default:
+			return nil, "", fmt.Errorf("unexpected %q", tok)
+		}
+		if op != "" && (op != next || next == " - ") {
+			return nil, "", fmt.Errorf("mixing or, and, and but not requires parentheses")
+		}
+		op = next
+
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, "", err
+		}
+		operands = append(operands, operand)
+	}
+	return operands, op, nil
+}
+
+// parseTerm parses a relation, "x from y", or a parenthesized expression.
+func (p *fgaParser) parseTerm() (string, error) {
+	switch tok := p.next(); tok {
+	case "":
+		return "", fmt.Errorf("missing operand")
+	case "[":
+		return "", fmt.Errorf("direct types must come first in the expression")
+	case "(":
+		operands, op, err := p.parseExpr(false)
+		if err != nil {
+			return "", err
+		}
+		if p.next() != ")" {
+			return "", fmt.Errorf(`missing ")"`)
+		}
+		if len(operands) == 1 {
+			return operands[0], nil
+		}
+		return "(" + strings.Join(operands, op) + ")", nil
+	case ")", "]", ",", "or", "and", "but", "from":
+		return "", fmt.Errorf("unexpected %q", tok)
+	default:
+		if p.peek() != "from" {
+			return tok, nil
+		}
+		p.next()
+		tupleset := p.next()
+		if !fgaIdentifier.MatchString(tupleset) {
+			return "", fmt.Errorf("%s from: missing relation", tok)
+		}
+		return tupleset + "->" + tok, nil
+	}
+}
+
+// parseTypes parses the direct types after "[", e.g.
+// "user, user:*, group#member with in_office]".
+func (p *fgaParser) parseTypes() (string, error) {
+	var types []string
+	for {
+		t := p.next()
+		if t == "" || t == "]" || t == "," {
+			return "", fmt.Errorf("missing type in direct types")
+		}
+		if p.peek() == "with" {
+			p.next()
+			caveat := p.next()
+			if !fgaIdentifier.MatchString(caveat) {
+				return "", fmt.Errorf("%s with: missing condition", t)
+			}
+			t += " with " + caveat
+		}
+		types = append(types, t)
+
+		switch p.next() {
+		case "]":
+			return strings.Join(types, " | "), nil
+		case ",":
+		default:
+			return "", fmt.Errorf(`missing "]" after direct types`)
+		}
+	}
+}
+
+// fgaIdentifier matches the name of a relation or condition.
+var fgaIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// tokenizeFGA splits an expression into words and the punctuation [ ] ( ) ,.
+func tokenizeFGA(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range expr {
+		switch r {
+		case ' ', '\t', '\r':
+			flush()
+		case '[', ']', '(', ')', ',':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// fgaCondition matches a condition declaration up to its body.
+var fgaCondition = regexp.MustCompile(`^\s*condition\s+(\S+)\s*\(([^)]*)\)\s*(\{.*)$`)
+
+// translateFGACondition translates a condition's declaration, e.g.
+// "condition in_office(ip: ipaddress, cidrs: list<string>) {", into the
+// equivalent caveat declaration, keeping whatever follows the "{".
+func translateFGACondition(line string) (string, error) {
+	m := fgaCondition.FindStringSubmatch(line)
+	if m == nil {
+		return "", fmt.Errorf("condition must be \"condition <name>(<param>: <type>, ...) {\"")
+	}
+	var params []string
+	for _, param := range strings.Split(m[2], ",") {
+		name, typ, ok := strings.Cut(param, ":")
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+		if !ok || name == "" || typ == "" {
+			return "", fmt.Errorf("condition %s: parameter %q must be \"<name>: <type>\"", m[1], strings.TrimSpace(param))
+		}
+		params = append(params, name+" "+typ)
+	}
+	return "caveat " + m[1] + "(" + strings.Join(params, ", ") + ") " + m[3], nil
+}
@@ -0,0 +1,76 @@
+package authzgen
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+)
+
+// GeneratedFile is a file a Plugin emits.
+type GeneratedFile struct {
+	// Path is relative to the output directory, e.g. "permissions.ts" or
+	// "terraform/spicedb.tf"; missing directories are created.
+	Path    string
+	Content []byte
+}
+
+// Plugin emits additional files from the parsed schema, e.g. TypeScript
+// constants of the definitions and permissions or Terraform resources of
+// the schema, in the same run as the Go code. Go files it emits are
+// formatted with go/format.
+type Plugin func(Schema) ([]GeneratedFile, error)
+
+// namedPlugin is a Plugin registered with WithPlugin.
+type namedPlugin struct {
+	name   string
+	plugin Plugin
+}
+
+// WithPlugin runs plugin after the Go code is generated, writing the files it
+// emits to the output directory. The name identifies the plugin in logs and
+// errors. Repeat it to run several plugins, in the order given.
+func WithPlugin(name string, plugin Plugin) Option {
+	return func(g *Generator) {
+		g.plugins = append(g.plugins, namedPlugin{name: name, plugin: plugin})
+	}
+}
+
+// runPlugins runs the registered plugins on schema and writes their files,
+// returning how many were written.
+func (g *Generator) runPlugins(schema Schema) (int, error) {
+	written := 0
+	for _, p := range g.plugins {
+		files, err := p.plugin(schema)
+		if err != nil {
+			return written, fmt.Errorf("plugin %s: %w", p.name, err)
+		}
+		for _, file := range files {
+			if err := g.writePluginFile(file); err != nil {
+				return written, fmt.Errorf("plugin %s: %w", p.name, err)
+			}
+		}
+		g.logger.Debug("plugin run", "plugin", p.name, "files", len(files))
+		written += len(files)
+	}
+	return written, nil
+}
+
+// writePluginFile writes file below the output directory, rejecting paths
+// that would leave it.
+func (g *Generator) writePluginFile(file GeneratedFile) error {
+	if !filepath.IsLocal(file.Path) {
+		return fmt.Errorf("invalid file path %q: want a path inside the output directory", file.Path)
+	}
+	outPath := filepath.Join(g.outputDir, file.Path)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return err
+	}
+	content := file.Content
+	if filepath.Ext(outPath) == ".go" {
+		if formatted, err := format.Source(content); err == nil {
+			content = formatted
+		}
+	}
+	return os.WriteFile(outPath, content, 0o644)
+}
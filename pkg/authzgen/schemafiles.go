@@ -0,0 +1,172 @@
+package authzgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/generator"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"google.golang.org/protobuf/proto"
+)
+
+// resolveSchemaFiles expands the configured schema paths into files. A
+// directory stands for the schema files directly inside it, in name order.
+func (g *Generator) resolveSchemaFiles() ([]string, error) {
+	var files []string
+	for _, path := range g.schemaFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*"+g.schemaExt()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list schema directory %s: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no %s files in schema directory %s", g.schemaExt(), path)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// schemaSource holds the definitions and caveats declared in one schema
+// file, with their positions in that file.
+type schemaSource struct {
+	file string
+	defs []compiler.SchemaDefinition
+}
+
+// compileSchema compiles the schema files into one schema. Each file may
+// import others with SpiceDB's "use import" syntax, resolved relative to the
+// file. Several files are compiled separately, merged, and compiled again
+// as a whole, so they may reference each other's definitions; a definition
+// or caveat declared differently in two files is an error. The sources
+// list what each file declares.
+func (g *Generator) compileSchema() (*compiler.CompiledSchema, []schemaSource, error) {
+	files, err := g.resolveSchemaFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files) == 1 {
+		content, err := g.readSchemaFile(files[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		compiled, err := compileSchemaFile(files[0], content)
+		if err != nil {
+			return nil, nil, err
+		}
+		return compiled, []schemaSource{{file: files[0], defs: compiled.OrderedDefinitions}}, nil
+	}
+
+	var (
+		merged   []compiler.SchemaDefinition
+		sources  []schemaSource
+		declared = make(map[string]string) // Definition or caveat name -> file
+		byName   = make(map[string]compiler.SchemaDefinition)
+	)
+	for _, file := range files {
+		content, err := g.readSchemaFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		compiled, err := compileSchemaFile(file, content, compiler.SkipValidation())
+		if err != nil {
+			return nil, nil, err
+		}
+		source := schemaSource{file: file}
+		for _, def := range compiled.OrderedDefinitions {
+			key := schemaDefinitionKey(def)
+			if first, ok := declared[key]; ok {
+				// The same definition reached through imports in both files
+				if proto.Equal(byName[key], def) {
+					continue
+				}
+				return nil, nil, fmt.Errorf("%s is declared in both %s and %s", key, first, file)
+			}
+			declared[key] = file
+			byName[key] = def
+			merged = append(merged, def)
+			source.defs = append(source.defs, def)
+		}
+		sources = append(sources, source)
+	}
+
+	text, _, err := generator.GenerateSchema(context.Background(), merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge schema files: %w", err)
+	}
+	compiled, err := compiler.Compile(
+		compiler.InputSchema{
+			Source:       input.Source(strings.Join(files, ", ")),
+			SchemaString: text,
+		},
+		compiler.AllowUnprefixedObjectType(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return compiled, sources, nil
+}
+
+func (g *Generator) readSchemaFile(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema file: %w", err)
+	}
+	g.logger.Debug("schema file read", "file", file, "size_bytes", len(content))
+	if g.dialect == DialectOpenFGA {
+		schema, err := translateOpenFGA(string(content))
+		if err != nil {
+			return "", fmt.Errorf("failed to translate OpenFGA model %s: %w", file, err)
+		}
+		return schema, nil
+	}
+	return string(content), nil
+}
+
+// schemaExt returns the extension of the dialect's schema files.
+func (g *Generator) schemaExt() string {
+	if g.dialect == DialectOpenFGA {
+		return ".fga"
+	}
+	return ".zed"
+}
+
+// compileSchemaFile compiles one schema file, resolving its imports relative
+// to the file.
+func compileSchemaFile(file, content string, opts ...compiler.Option) (*compiler.CompiledSchema, error) {
+	opts = append(opts, compiler.SourceFolder(filepath.Dir(file)))
+	compiled, err := compiler.Compile(
+		compiler.InputSchema{
+			Source:       input.Source(file),
+			SchemaString: content,
+		},
+		compiler.AllowUnprefixedObjectType(),
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// schemaDefinitionKey names def in duplicate errors, e.g. "definition document".
+func schemaDefinitionKey(def compiler.SchemaDefinition) string {
+	if _, ok := def.(*corev1.CaveatDefinition); ok {
+		return fmt.Sprintf("caveat %q", def.GetName())
+	}
+	return fmt.Sprintf("definition %q", def.GetName())
+}
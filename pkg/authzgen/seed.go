@@ -0,0 +1,123 @@
+package authzgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/authzed/spicedb/pkg/tuple"
+	"gopkg.in/yaml.v3"
+)
+
+// seedFile is the layout of a seed file: relationships in SpiceDB's syntax,
+// e.g. "platform:main#admin@user:alice" or
+// "document:handbook#viewer@group:staff#member[in_office:{\"cidr\":\"10.0.0.0/8\"}]".
+type seedFile struct {
+	Relationships []yaml.Node `yaml:"relationships"`
+}
+
+// seedRelationship is a relationship of the seed file, as seedTemplate
+// renders it.
+type seedRelationship struct {
+	ResourceType    string
+	ResourceID      string
+	Relation        string
+	SubjectType     string
+	SubjectID       string
+	SubjectRelation string // Empty for the subject object itself
+	Caveat          string
+	CaveatContext   string // JSON object of the caveat's context, if any
+}
+
+// loadSeed reads the seed file and checks every relationship against the
+// schema: the resource type must be a definition, the relation one of its
+// relations, and the subject and caveat allowed on that relation. Errors
+// name the line of the offending relationship.
+func (g *Generator) loadSeed(definitions []Definition) ([]seedRelationship, error) {
+	content, err := os.ReadFile(g.seedFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	var file seedFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse seed file %s: %w", g.seedFile, err)
+	}
+
+	byName := make(map[string]Definition, len(definitions))
+	for _, def := range definitions {
+		byName[def.FullName] = def
+	}
+
+	var seeds []seedRelationship
+	for _, node := range file.Relationships {
+		seed, err := parseSeedRelationship(byName, node.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", g.seedFile, node.Line, err)
+		}
+		seeds = append(seeds, seed)
+	}
+	g.logger.Debug("seed file read", "file", g.seedFile, "relationships", len(seeds))
+	return seeds, nil
+}
+
+// parseSeedRelationship parses one relationship of the seed file.
+func parseSeedRelationship(byName map[string]Definition, s string) (seedRelationship, error) {
+	rel, err := tuple.Parse(s)
+	if err != nil {
+		return seedRelationship{}, fmt.Errorf("invalid relationship %q: %w", s, err)
+	}
+	if rel.OptionalExpiration != nil {
+		return seedRelationship{}, fmt.Errorf("relationship %q: expiration is not supported in seed files", s)
+	}
+
+	seed := seedRelationship{
+		ResourceType: rel.Resource.ObjectType,
+		ResourceID:   rel.Resource.ObjectID,
+		Relation:     rel.Resource.Relation,
+		SubjectType:  rel.Subject.ObjectType,
+		SubjectID:    rel.Subject.ObjectID,
+	}
+	if rel.Subject.Relation != tuple.Ellipsis {
+		seed.SubjectRelation = rel.Subject.Relation
+	}
+
+	def, ok := byName[seed.ResourceType]
+	if !ok {
+		return seedRelationship{}, fmt.Errorf("relationship %q: %q is not a definition of the schema", s, seed.ResourceType)
+	}
+	relation, ok := findDefRelation(def, seed.Relation)
+	if !ok {
+		if slices.ContainsFunc(def.Permissions, func(p Permission) bool { return p.Name == seed.Relation }) {
+			return seedRelationship{}, fmt.Errorf("relationship %q: %s is a permission of %s; relationships are written to relations", s, seed.Relation, def.FullName)
+		}
+		return seedRelationship{}, fmt.Errorf("relationship %q: %s has no relation %q", s, def.FullName, seed.Relation)
+	}
+
+	subject := SubjectType{Type: seed.SubjectType, Relation: seed.SubjectRelation}
+	if seed.SubjectID == tuple.PublicWildcard {
+		subject = SubjectType{Type: seed.SubjectType, Wildcard: true}
+		if seed.SubjectRelation != "" {
+			return seedRelationship{}, fmt.Errorf("relationship %q: a wildcard subject cannot have a relation", s)
+		}
+	}
+	allowed, ok := relation.FindType(subject)
+	if !ok {
+		return seedRelationship{}, fmt.Errorf("relationship %q: %s#%s does not allow subject %s", s, def.FullName, seed.Relation, subject)
+	}
+
+	if c := rel.OptionalCaveat; c != nil {
+		if !slices.Contains(allowed.Caveats, c.CaveatName) {
+			return seedRelationship{}, fmt.Errorf("relationship %q: %s#%s does not allow caveat %s on subject %s", s, def.FullName, seed.Relation, c.CaveatName, subject)
+		}
+		seed.Caveat = c.CaveatName
+		if fields := c.Context.AsMap(); len(fields) > 0 {
+			context, err := json.Marshal(fields)
+			if err != nil {
+				return seedRelationship{}, fmt.Errorf("relationship %q: caveat context: %w", s, err)
+			}
+			seed.CaveatContext = string(context)
+		}
+	}
+	return seed, nil
+}
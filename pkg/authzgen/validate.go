@@ -0,0 +1,190 @@
+package authzgen
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// Problem is a mistake in a schema found by Validate.
+type Problem struct {
+	File    string
+	Line    int // 1-based
+	Column  int // 1-based
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+// ValidationError lists every problem Validate found.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return fmt.Sprintf("schema has %d problem(s):\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// Validate parses the schema and checks its cross-references without
+// generating code: every identifier in a permission names a relation or
+// permission of the definition, every arrow starts from a relation and
+// reaches a relation or permission on one of its subject types, and every
+// subject type, subject relation, and caveat of a relation is declared. It
+// returns a *ValidationError listing all problems found.
+func (g *Generator) Validate() error {
+	compiled, sources, err := g.compileSchema()
+	if err != nil {
+		return err
+	}
+
+	v := &validator{
+		namespaces: make(map[string]*corev1.NamespaceDefinition),
+		caveats:    make(map[string]bool),
+	}
+	for _, ns := range compiled.ObjectDefinitions {
+		v.namespaces[ns.Name] = ns
+	}
+	for _, cd := range compiled.CaveatDefinitions {
+		v.caveats[cd.Name] = true
+	}
+	for _, source := range sources {
+		v.file = source.file
+		for _, def := range source.defs {
+			if ns, ok := def.(*corev1.NamespaceDefinition); ok {
+				v.checkNamespace(ns)
+			}
+		}
+	}
+
+	g.logger.Info("schema validated", "definitions", len(compiled.ObjectDefinitions), "problems", len(v.problems))
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects the problems of the definitions of one file at a time.
+type validator struct {
+	namespaces map[string]*corev1.NamespaceDefinition
+	caveats    map[string]bool
+	file       string
+	problems   []Problem
+}
+
+func (v *validator) report(pos *corev1.SourcePosition, format string, args ...any) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    int(pos.GetZeroIndexedLineNumber()) + 1,
+		Column:  int(pos.GetZeroIndexedColumnPosition()) + 1,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) checkNamespace(ns *corev1.NamespaceDefinition) {
+	for _, rel := range ns.Relation {
+		if rel.UsersetRewrite == nil {
+			v.checkRelation(ns, rel)
+		} else {
+			v.checkRewrite(ns, rel, rel.UsersetRewrite)
+		}
+	}
+}
+
+// checkRelation checks the subject types of a relation.
+func (v *validator) checkRelation(ns *corev1.NamespaceDefinition, rel *corev1.Relation) {
+	for _, ar := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+		pos := ar.GetSourcePosition()
+		if pos == nil {
+			pos = rel.GetSourcePosition()
+		}
+		target, ok := v.namespaces[ar.Namespace]
+		switch {
+		case !ok:
+			v.report(pos, "relation %s#%s: subject type %q is not defined", ns.Name, rel.Name, ar.Namespace)
+		case ar.GetRelation() != "" && ar.GetRelation() != tuple.Ellipsis && findRelation(target, ar.GetRelation()) == nil:
+			v.report(pos, "relation %s#%s: subject %s#%s: %s has no relation or permission %q", ns.Name, rel.Name, ar.Namespace, ar.GetRelation(), ar.Namespace, ar.GetRelation())
+		}
+		if c := ar.GetRequiredCaveat(); c != nil && !v.caveats[c.CaveatName] {
+			v.report(pos, "relation %s#%s: caveat %q is not defined", ns.Name, rel.Name, c.CaveatName)
+		}
+	}
+}
+
+// checkRewrite checks the identifiers and arrows of permission perm.
+func (v *validator) checkRewrite(ns *corev1.NamespaceDefinition, perm *corev1.Relation, rewrite *corev1.UsersetRewrite) {
+	var set *corev1.SetOperation
+	switch {
+	case rewrite.GetUnion() != nil:
+		set = rewrite.GetUnion()
+	case rewrite.GetIntersection() != nil:
+		set = rewrite.GetIntersection()
+	case rewrite.GetExclusion() != nil:
+		set = rewrite.GetExclusion()
+	}
+
+	for _, child := range set.GetChild() {
+		pos := child.GetSourcePosition()
+		if pos == nil {
+			pos = perm.GetSourcePosition()
+		}
+		switch c := child.GetChildType().(type) {
+		case *corev1.SetOperation_Child_ComputedUserset:
+			name := c.ComputedUserset.GetRelation()
+			if findRelation(ns, name) == nil {
+				v.report(pos, "permission %s#%s: %q is not a relation or permission of %s", ns.Name, perm.Name, name, ns.Name)
+			}
+		case *corev1.SetOperation_Child_TupleToUserset:
+			v.checkArrow(ns, perm, pos, c.TupleToUserset.GetTupleset().GetRelation(), c.TupleToUserset.GetComputedUserset().GetRelation())
+		case *corev1.SetOperation_Child_FunctionedTupleToUserset:
+			v.checkArrow(ns, perm, pos, c.FunctionedTupleToUserset.GetTupleset().GetRelation(), c.FunctionedTupleToUserset.GetComputedUserset().GetRelation())
+		case *corev1.SetOperation_Child_UsersetRewrite:
+			v.checkRewrite(ns, perm, c.UsersetRewrite)
+		}
+	}
+}
+
+// checkArrow checks tupleset->computed in permission perm.
+func (v *validator) checkArrow(ns *corev1.NamespaceDefinition, perm *corev1.Relation, pos *corev1.SourcePosition, tupleset, computed string) {
+	rel := findRelation(ns, tupleset)
+	switch {
+	case rel == nil:
+		v.report(pos, "permission %s#%s: arrow %s->%s: %q is not a relation of %s", ns.Name, perm.Name, tupleset, computed, tupleset, ns.Name)
+		return
+	case rel.UsersetRewrite != nil:
+		v.report(pos, "permission %s#%s: arrow %s->%s: %q is a permission; arrows must start from a relation", ns.Name, perm.Name, tupleset, computed, tupleset)
+		return
+	}
+
+	var types []string
+	for _, ar := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+		target, ok := v.namespaces[ar.Namespace]
+		if !ok {
+			continue // Reported on the relation
+		}
+		if findRelation(target, computed) != nil {
+			return
+		}
+		types = append(types, ar.Namespace)
+	}
+	if len(types) > 0 {
+		v.report(pos, "permission %s#%s: arrow %s->%s: none of %s has a relation or permission %q", ns.Name, perm.Name, tupleset, computed, strings.Join(types, ", "), computed)
+	}
+}
+
+// findRelation returns the relation or permission name of ns, or nil.
+func findRelation(ns *corev1.NamespaceDefinition, name string) *corev1.Relation {
+	for _, rel := range ns.Relation {
+		if rel.Name == name {
+			return rel
+		}
+	}
+	return nil
+}
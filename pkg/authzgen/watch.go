@@ -0,0 +1,182 @@
+package authzgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits after the last schema change before
+// regenerating, so an editor's burst of writes triggers one run.
+const watchDebounce = 200 * time.Millisecond
+
+// Watch generates the code, then regenerates it whenever a schema file in the
+// directories of the schema files changes, until ctx is done. After each run
+// it writes a summary of the changed generated files to out. A failed run is
+// reported to out and watching continues.
+func (g *Generator) Watch(ctx context.Context, out io.Writer) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	dirs, err := g.watchDirs()
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	g.regenerate(out)
+	fmt.Fprintf(out, "Watching %s for schema changes\n", strings.Join(dirs, ", "))
+
+	var (
+		timer   *time.Timer
+		trigger <-chan time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Ext(event.Name) != g.schemaExt() || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(watchDebounce)
+			trigger = timer.C
+		case <-trigger:
+			trigger = nil
+			g.regenerate(out)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			g.logger.Warn("schema watcher error", "error", err)
+		}
+	}
+}
+
+// watchDirs returns the directories holding the schema files. Watching the
+// directory rather than the file survives editors that save by renaming,
+// and picks up imported files next to the schema.
+func (g *Generator) watchDirs() ([]string, error) {
+	var dirs []string
+	for _, path := range g.schemaFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// regenerate runs Generate and writes what changed in the generated files.
+func (g *Generator) regenerate(out io.Writer) {
+	before := g.readGenerated()
+	if err := g.Generate(); err != nil {
+		fmt.Fprintf(out, "Generation failed: %v\n", err)
+		return
+	}
+	changes := diffGenerated(before, g.readGenerated())
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "Regenerated: no changes")
+		return
+	}
+	fmt.Fprintf(out, "Regenerated: %d file(s) changed\n", len(changes))
+	for _, c := range changes {
+		fmt.Fprintf(out, "  %s\n", c)
+	}
+}
+
+// readGenerated returns the contents of the generated files in the output
+// directory by file name.
+func (g *Generator) readGenerated() map[string]string {
+	files := make(map[string]string)
+	matches, _ := filepath.Glob(filepath.Join(g.outputDir, "*.gen.go"))
+	for _, path := range matches {
+		if content, err := os.ReadFile(path); err == nil {
+			files[filepath.Base(path)] = string(content)
+		}
+	}
+	return files
+}
+
+// diffGenerated summarizes the differences between two sets of generated
+// files, one line per changed file in name order, e.g.
+// "document.gen.go: +12 -3". Lines are counted as a multiset, so moved lines
+// are not changes.
+func diffGenerated(before, after map[string]string) []string {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, name := range sorted {
+		old, hadOld := before[name]
+		cur, hasCur := after[name]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("%s: new (%d lines)", name, strings.Count(cur, "\n")))
+		case !hasCur:
+			changes = append(changes, fmt.Sprintf("%s: removed", name))
+		case old != cur:
+			added, removed := lineDiff(old, cur)
+			changes = append(changes, fmt.Sprintf("%s: +%d -%d", name, added, removed))
+		}
+	}
+	return changes
+}
+
+// lineDiff counts the lines of cur missing from old and of old missing
+// from cur.
+func lineDiff(old, cur string) (added, removed int) {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(old, "\n") {
+		counts[line]++
+	}
+	for _, line := range strings.Split(cur, "\n") {
+		counts[line]--
+	}
+	for _, n := range counts {
+		if n < 0 {
+			added -= n
+		} else {
+			removed += n
+		}
+	}
+	return added, removed
+}
@@ -2,6 +2,7 @@
 package codegen
 
 import (
+	"strings"
 	"unicode"
 )
 
@@ -24,3 +25,121 @@ func Uncapitalize(s string) string {
 	runes[0] = unicode.ToLower(runes[0])
 	return string(runes)
 }
+
+// commonInitialisms are rendered fully upper-case by ToPascalCase, following
+// the Go naming convention (UserID, not UserId).
+var commonInitialisms = map[string]bool{
+	"API": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// ToPascalCase converts snake_case, kebab-case, space separated, or camelCase
+// input to PascalCase (e.g. "check_in" -> "CheckIn", "user_id" -> "UserID").
+func ToPascalCase(s string) string {
+	var b strings.Builder
+	for _, word := range splitWords(s) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(Capitalize(strings.ToLower(word)))
+	}
+	return b.String()
+}
+
+// ToSnakeCase converts PascalCase, camelCase, kebab-case, or space separated
+// input to snake_case (e.g. "CheckIn" -> "check_in").
+func ToSnakeCase(s string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, "_")
+}
+
+// ToKebabCase converts PascalCase, camelCase, snake_case, or space separated
+// input to kebab-case (e.g. "CheckIn" -> "check-in").
+func ToKebabCase(s string) string {
+	return strings.ReplaceAll(ToSnakeCase(s), "_", "-")
+}
+
+// ToCamelCase converts snake_case, kebab-case, space separated, or PascalCase
+// input to camelCase (e.g. "check_in" -> "checkIn", "id" -> "id").
+func ToCamelCase(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + ToPascalCase(strings.Join(words[1:], "_"))
+}
+
+// Pluralize returns the English plural of a singular noun using the regular
+// rules ("item" -> "items", "category" -> "categories", "box" -> "boxes").
+// Case is preserved for the unchanged prefix.
+func Pluralize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return ""
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
+
+// Singularize reverses Pluralize for regular plurals ("items" -> "item",
+// "categories" -> "category", "boxes" -> "box"). Words that do not look
+// plural are returned unchanged.
+func Singularize(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "zes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"):
+		return s
+	case strings.HasSuffix(lower, "s") && len(lower) > 1:
+		return s[:len(s)-1]
+	default:
+		return s
+	}
+}
+
+// splitWords breaks an identifier into words on separators ('_', '-', ' ',
+// '.') and on lower-to-upper case transitions. Runs of capitals are kept
+// together so "HTTPServer" becomes ["HTTP", "Server"].
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(current) > 0:
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
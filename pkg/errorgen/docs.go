@@ -0,0 +1,96 @@
+package errorgen
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// uncategorized is the catalog section of errors without a category.
+const uncategorized = "uncategorized"
+
+// docCategory is a section of the error catalog: the errors of a category,
+// in definition order.
+type docCategory struct {
+	Name   string
+	Errors []ErrorDefinition
+}
+
+// docCategories groups the errors of config by category, in name order,
+// with uncategorized errors last.
+func docCategories(config *ErrorConfig) []docCategory {
+	var categories []docCategory
+	for _, e := range config.Errors {
+		name := e.Category
+		if name == "" {
+			name = uncategorized
+		}
+		i := slices.IndexFunc(categories, func(c docCategory) bool { return c.Name == name })
+		if i == -1 {
+			categories = append(categories, docCategory{Name: name})
+			i = len(categories) - 1
+		}
+		categories[i].Errors = append(categories[i].Errors, e)
+	}
+	slices.SortFunc(categories, func(a, b docCategory) int {
+		switch {
+		case a.Name == b.Name:
+			return 0
+		case a.Name == uncategorized:
+			return 1
+		case b.Name == uncategorized:
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return categories
+}
+
+// generateDocs writes the error catalog to the docs file: HTML for .html
+// and .htm files, Markdown otherwise.
+func (g *Generator) generateDocs(config *ErrorConfig) error {
+	data := struct {
+		Package    string
+		Categories []docCategory
+	}{config.Package, docCategories(config)}
+
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(g.config.docsFile)) {
+	case ".html", ".htm":
+		tmpl, err := htmltemplate.ParseFS(Templates, "templates/docs.html.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed to parse docs template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to execute docs template: %w", err)
+		}
+	default:
+		funcMap := template.FuncMap{
+			"cell": func(s string) string {
+				return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+			},
+		}
+		tmpl, err := template.New("docs.md.tmpl").Funcs(funcMap).ParseFS(Templates, "templates/docs.md.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed to parse docs template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to execute docs template: %w", err)
+		}
+	}
+	return g.writeFile(g.config.docsFile, buf.Bytes())
+}
+
+// generateOpenAPI writes the OpenAPI schemas of the error envelope to the
+// OpenAPI file.
+func (g *Generator) generateOpenAPI(config *ErrorConfig) error {
+	tmpl, err := template.ParseFS(Templates, "templates/openapi.yaml.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI template: %w", err)
+	}
+	return g.writeTemplate(tmpl, tmpl.Name(), g.config.openAPIFile, config)
+}
@@ -2,6 +2,24 @@ package errors
 
 package: "errors"
 
+// Every code must start with codePrefix and match codePattern when set
+codePrefix?:  string
+codePattern?: string
+
+// The categories errors may have, when set
+categories?: [...string]
+
+// Fields errors inherit when they leave them unset: those of their category,
+// then those of every error
+#Defaults: {
+	severity?:   "critical" | "high" | "medium" | "low"
+	httpStatus?: int & >=100 & <=599
+	grpcCode?:   string
+}
+defaults?: #Defaults & {
+	categories?: [string]: #Defaults
+}
+
 #Error: {
 	code:        string & =~"^ERR-[0-9]{3,4}$"
 	name:        string & =~"^Err[A-Z][a-zA-Z0-9]+$"
@@ -10,6 +28,12 @@ package: "errors"
 	description: *message | string
 	severity:    *"medium" | "critical" | "high" | "low"
 	parameters:  *[] | [...string]
+	parameterTypes?: [string]: "string" | "int" | "uuid" | "duration"
+	retryable:      *false | bool
+	exposeToClient: *false | bool
+	deprecated:     *false | bool
+	replacedBy?:    string
+	grpcCode?:   "Canceled" | "Unknown" | "InvalidArgument" | "DeadlineExceeded" | "NotFound" | "AlreadyExists" | "PermissionDenied" | "ResourceExhausted" | "FailedPrecondition" | "Aborted" | "OutOfRange" | "Unimplemented" | "Internal" | "Unavailable" | "DataLoss" | "Unauthenticated"
 }
 
 // Category-specific error types
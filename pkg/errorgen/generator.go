@@ -1,37 +1,88 @@
 package errorgen
 
 import (
+	"bytes"
 	"embed"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/load"
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed templates/*.tmpl
 var Templates embed.FS
 
-// ErrorDefinition represents a single error definition.
+// ErrorDefinition represents a single error definition. The tags name its
+// fields in YAML and JSON definition files, as in CUE.
 type ErrorDefinition struct {
-	Name        string
-	Code        string
-	Message     string
-	Category    string
-	HTTPStatus  int
-	Severity    string
-	Description string
-	Parameters  []string
+	Name        string   `json:"name" yaml:"name"`
+	Code        string   `json:"code" yaml:"code"`
+	Message     string   `json:"message" yaml:"message"`
+	Category    string   `json:"category" yaml:"category"`
+	HTTPStatus  int      `json:"httpStatus" yaml:"httpStatus"`
+	Severity    string   `json:"severity" yaml:"severity"`
+	Description string   `json:"description" yaml:"description"`
+	Parameters  []string `json:"parameters" yaml:"parameters"`
+	// ParameterTypes declares the type of parameters, by name: "string",
+	// the default, "int", "uuid", or "duration". The constructors of the
+	// error take each parameter with its Go type.
+	ParameterTypes map[string]string `json:"parameterTypes" yaml:"parameterTypes"`
+	// GRPCCode is the name of the gRPC status code, as in
+	// google.golang.org/grpc/codes (e.g. "NotFound"). When empty it is
+	// derived from HTTPStatus.
+	GRPCCode string `json:"grpcCode" yaml:"grpcCode"`
+	// Retryable marks errors after which the operation may succeed if
+	// tried again, e.g. timeouts or lost optimistic locks.
+	Retryable bool `json:"retryable" yaml:"retryable"`
+	// ExposeToClient marks errors whose message may be shown to clients;
+	// others are reported with a generic message.
+	ExposeToClient bool `json:"exposeToClient" yaml:"exposeToClient"`
+	// Deprecated marks errors kept for compatibility: their constructors
+	// get Deprecated doc comments and log a warning when called.
+	Deprecated bool `json:"deprecated" yaml:"deprecated"`
+	// ReplacedBy is the code of the error replacing a deprecated one.
+	ReplacedBy string `json:"replacedBy" yaml:"replacedBy"`
 }
 
 // ErrorConfig holds all error definitions.
 type ErrorConfig struct {
-	Package string
-	Errors  []ErrorDefinition
+	Package string `json:"package" yaml:"package"`
+	// CodePrefix namespaces the catalog: every code must start with it,
+	// e.g. "BOOKING_".
+	CodePrefix string `json:"codePrefix" yaml:"codePrefix"`
+	// CodePattern is a regular expression every code must match, e.g.
+	// "^BOOKING_[A-Z_]+$", to keep codes consistent across services.
+	CodePattern string `json:"codePattern" yaml:"codePattern"`
+	// Categories, when set, are the categories errors may have.
+	Categories []string `json:"categories" yaml:"categories"`
+	// Defaults are inherited by the errors that leave the fields unset.
+	Defaults Defaults          `json:"defaults" yaml:"defaults"`
+	Errors   []ErrorDefinition `json:"errors" yaml:"errors"`
+}
+
+// ErrorDefaults are the fields an error inherits when it leaves them unset.
+type ErrorDefaults struct {
+	Severity   string `json:"severity" yaml:"severity"`
+	HTTPStatus int    `json:"httpStatus" yaml:"httpStatus"`
+	GRPCCode   string `json:"grpcCode" yaml:"grpcCode"`
+}
+
+// Defaults are the defaults of a catalog: those of every error, overridden
+// for the errors of a category by the defaults of the category.
+type Defaults struct {
+	ErrorDefaults `yaml:",inline"`
+	// Categories holds the defaults of categories, by category.
+	Categories map[string]ErrorDefaults `json:"categories" yaml:"categories"`
 }
 
 // GeneratorConfig holds configuration for the error generator.
@@ -40,12 +91,27 @@ type GeneratorConfig struct {
 	outputFile   string
 	templateFile string
 	packageName  string
+	docsFile     string
+	language     string
+	openAPIFile  string
+	tests        bool
+	// catalogHandler generates CatalogHandler
+	catalogHandler bool
+	// stackTraces makes the errors capture stack traces
+	stackTraces bool
+	// httpUtil generates the pkg/httputil bridge
+	httpUtil   bool
+	autoPrefix bool
+	// splitByCategory writes each category's errors to a file of their own
+	splitByCategory bool
 }
 
 // GeneratorOption is a functional option for configuring the generator.
 type GeneratorOption func(*GeneratorConfig)
 
-// WithInputFile sets the input CUE file or directory.
+// WithInputFile sets the input file or directory. The format of a file is
+// detected by its extension: .yaml or .yml for YAML, .json for JSON, and CUE
+// otherwise; a directory is loaded as a CUE package.
 func WithInputFile(path string) GeneratorOption {
 	return func(c *GeneratorConfig) {
 		c.inputFile = path
@@ -73,17 +139,106 @@ func WithPackageName(name string) GeneratorOption {
 	}
 }
 
+// WithDocsFile also writes a catalog of the errors, grouped by category, to
+// path: HTML for .html files and Markdown otherwise, e.g. "errors.md".
+func WithDocsFile(path string) GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.docsFile = path
+	}
+}
+
+// Languages the output file can be generated in.
+const (
+	LanguageGo         = "go"
+	LanguageTypeScript = "ts"
+)
+
+// languageTemplates are the embedded templates of each language.
+var languageTemplates = map[string]string{
+	LanguageGo:         "error.go.tmpl",
+	LanguageTypeScript: "errors.ts.tmpl",
+}
+
+// WithLanguage sets the language of the output file: LanguageGo, the
+// default, or LanguageTypeScript, which writes the error codes as a const map
+// and the catalog as a typed record for frontends.
+func WithLanguage(lang string) GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.language = lang
+	}
+}
+
+// WithOpenAPIFile also writes an OpenAPI components/schemas snippet to path,
+// with the error codes as an enum and the httputil error envelope
+// referencing it, to merge into the service's API description.
+func WithOpenAPIFile(path string) GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.openAPIFile = path
+	}
+}
+
+// WithAutoPrefix prepends the catalog's code prefix to the codes that do not
+// start with it, so definitions can omit it, instead of rejecting them.
+func WithAutoPrefix() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.autoPrefix = true
+	}
+}
+
+// WithCatalogHandler generates CatalogHandler, an http.Handler serving the
+// catalog as JSON, so tooling and API gateways can introspect the error
+// codes of a running service.
+func WithCatalogHandler() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.catalogHandler = true
+	}
+}
+
+// WithStackTraces makes the generated errors capture the stack where they are
+// constructed, exposed by their StackTrace method for error reporters such as
+// Sentry. The generated CaptureStackTraces variable turns capturing off at
+// runtime.
+func WithStackTraces() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.stackTraces = true
+	}
+}
+
+// WithHTTPUtil generates ToHTTPError, converting errors into
+// httputil.HTTPError, and WriteHTTP, writing any error in the httputil
+// response envelope, so handlers need no adapters of their own. The
+// generated code then imports github.com/ianmuhia/kit/pkg/httputil.
+func WithHTTPUtil() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.httpUtil = true
+	}
+}
+
+// WithTests also writes a test file next to the output file, e.g.
+// "errors_test.go" for "errors.go", asserting that errors.Is and errors.As
+// match every definition through wrapping and only its own code.
+func WithTests() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.tests = true
+	}
+}
+
 // defaultGeneratorConfig returns sensible defaults.
 func defaultGeneratorConfig() *GeneratorConfig {
 	return &GeneratorConfig{
 		inputFile:  "errors.cue",
 		outputFile: "errors.go",
+		language:   LanguageGo,
 	}
 }
 
 // Generator handles error code generation.
 type Generator struct {
 	config *GeneratorConfig
+	// checking makes writeFile record stale files instead of writing, for
+	// Check
+	checking bool
+	stale    []string
 }
 
 // NewGenerator creates a new error generator.
@@ -103,15 +258,27 @@ func NewGenerator(opts ...GeneratorOption) (*Generator, error) {
 		return nil, fmt.Errorf("output file is required")
 	}
 
+	if _, ok := languageTemplates[config.language]; !ok {
+		return nil, fmt.Errorf("unsupported language %q (want %s or %s)", config.language, LanguageGo, LanguageTypeScript)
+	}
+
+	if config.tests && config.language != LanguageGo {
+		return nil, fmt.Errorf("tests can only be generated for Go")
+	}
+
+	if config.splitByCategory && (config.language != LanguageGo || config.templateFile != "") {
+		return nil, fmt.Errorf("splitting by category requires the embedded Go template")
+	}
+
 	return &Generator{config: config}, nil
 }
 
-// Generate generates error code from CUE definitions.
+// Generate generates error code from the error definitions.
 func (g *Generator) Generate() error {
-	// Load CUE configuration
-	errorConfig, err := g.loadCUEConfig()
+	// Load the definitions in the input's format
+	errorConfig, err := g.loadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load CUE config: %w", err)
+		return fmt.Errorf("failed to load error definitions: %w", err)
 	}
 
 	// Override package name if specified
@@ -119,6 +286,14 @@ func (g *Generator) Generate() error {
 		errorConfig.Package = g.config.packageName
 	}
 
+	// Fill in the fields errors inherit
+	errorConfig.applyDefaults()
+
+	// Prefix the codes that lack the code prefix if requested
+	if g.config.autoPrefix {
+		errorConfig.applyCodePrefix()
+	}
+
 	// Validate config
 	if err := errorConfig.validate(); err != nil {
 		return fmt.Errorf("validation error: %w", err)
@@ -129,10 +304,73 @@ func (g *Generator) Generate() error {
 		return fmt.Errorf("failed to generate code: %w", err)
 	}
 
+	// Test the generated errors if requested
+	if g.config.tests {
+		if err := g.generateTests(errorConfig); err != nil {
+			return fmt.Errorf("failed to generate tests: %w", err)
+		}
+	}
+
+	// Describe the error envelope for OpenAPI if requested
+	if g.config.openAPIFile != "" {
+		if err := g.generateOpenAPI(errorConfig); err != nil {
+			return fmt.Errorf("failed to generate OpenAPI schemas: %w", err)
+		}
+	}
+
+	// Document the catalog if requested
+	if g.config.docsFile != "" {
+		if err := g.generateDocs(errorConfig); err != nil {
+			return fmt.Errorf("failed to generate docs: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// loadCUEConfig loads error definitions from a CUE file.
+// loadConfig loads the error definitions in the format of the input file.
+func (g *Generator) loadConfig() (*ErrorConfig, error) {
+	switch strings.ToLower(filepath.Ext(g.config.inputFile)) {
+	case ".yaml", ".yml", ".json":
+		return g.loadDataConfig()
+	default:
+		return g.loadCUEConfig()
+	}
+}
+
+// loadDataConfig loads error definitions from a YAML or JSON file. Unknown
+// fields are rejected, so a misspelled field is not silently ignored.
+func (g *Generator) loadDataConfig() (*ErrorConfig, error) {
+	data, err := os.ReadFile(g.config.inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	config := &ErrorConfig{}
+	if strings.EqualFold(filepath.Ext(g.config.inputFile), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(config); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON file %s: %w", g.config.inputFile, err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(config); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML file %s: %w", g.config.inputFile, err)
+		}
+	}
+
+	if config.Errors == nil {
+		return nil, fmt.Errorf("errors field not found in %s", g.config.inputFile)
+	}
+	if config.Package == "" {
+		config.Package = "errors" // default
+	}
+	return config, nil
+}
+
+// loadCUEConfig loads error definitions from a CUE file or package.
 func (g *Generator) loadCUEConfig() (*ErrorConfig, error) {
 	inputPath := g.config.inputFile
 	if !filepath.IsAbs(inputPath) {
@@ -190,6 +428,31 @@ func (g *Generator) loadCUEConfig() (*ErrorConfig, error) {
 		config.Package = "errors" // default
 	}
 
+	// Get the code constraints
+	for field, dst := range map[string]*string{"codePrefix": &config.CodePrefix, "codePattern": &config.CodePattern} {
+		if v := value.LookupPath(cue.ParsePath(field)); v.Exists() {
+			str, err := v.String()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read '%s' field: %w", field, err)
+			}
+			*dst = str
+		}
+	}
+
+	// Get the allowed categories
+	if categories := value.LookupPath(cue.ParsePath("categories")); categories.Exists() {
+		if err := categories.Decode(&config.Categories); err != nil {
+			return nil, fmt.Errorf("failed to read 'categories' field: %w", err)
+		}
+	}
+
+	// Get the defaults errors inherit
+	if defaults := value.LookupPath(cue.ParsePath("defaults")); defaults.Exists() {
+		if err := defaults.Decode(&config.Defaults); err != nil {
+			return nil, fmt.Errorf("failed to read 'defaults' field: %w", err)
+		}
+	}
+
 	// Get errors array
 	errorsValue := value.LookupPath(cue.ParsePath("errors"))
 	if !errorsValue.Exists() {
@@ -245,6 +508,31 @@ func (g *Generator) loadCUEConfig() (*ErrorConfig, error) {
 				errorDef.Severity = str
 			}
 		}
+		if grpcCode := errVal.LookupPath(cue.ParsePath("grpcCode")); grpcCode.Exists() {
+			if str, err := grpcCode.String(); err == nil {
+				errorDef.GRPCCode = str
+			}
+		}
+		if retryable := errVal.LookupPath(cue.ParsePath("retryable")); retryable.Exists() {
+			if b, err := retryable.Bool(); err == nil {
+				errorDef.Retryable = b
+			}
+		}
+		if expose := errVal.LookupPath(cue.ParsePath("exposeToClient")); expose.Exists() {
+			if b, err := expose.Bool(); err == nil {
+				errorDef.ExposeToClient = b
+			}
+		}
+		if deprecated := errVal.LookupPath(cue.ParsePath("deprecated")); deprecated.Exists() {
+			if b, err := deprecated.Bool(); err == nil {
+				errorDef.Deprecated = b
+			}
+		}
+		if replacedBy := errVal.LookupPath(cue.ParsePath("replacedBy")); replacedBy.Exists() {
+			if str, err := replacedBy.String(); err == nil {
+				errorDef.ReplacedBy = str
+			}
+		}
 		if description := errVal.LookupPath(cue.ParsePath("description")); description.Exists() {
 			if str, err := description.String(); err == nil {
 				errorDef.Description = str
@@ -260,6 +548,20 @@ func (g *Generator) loadCUEConfig() (*ErrorConfig, error) {
 				}
 			}
 		}
+		if parameterTypes := errVal.LookupPath(cue.ParsePath("parameterTypes")); parameterTypes.Exists() {
+			fields, err := parameterTypes.Fields()
+			if err != nil {
+				return nil, fmt.Errorf("error[%d]: 'parameterTypes' must be a struct: %w", i, err)
+			}
+			errorDef.ParameterTypes = make(map[string]string)
+			for fields.Next() {
+				typ, err := fields.Value().String()
+				if err != nil {
+					return nil, fmt.Errorf("error[%d]: failed to read type of parameter %s: %w", i, fields.Selector(), err)
+				}
+				errorDef.ParameterTypes[fields.Selector().Unquoted()] = typ
+			}
+		}
 
 		config.Errors = append(config.Errors, errorDef)
 	}
@@ -280,9 +582,41 @@ func (g *Generator) generateCode(config *ErrorConfig) error {
 		"codeConstName": func(name string) string {
 			return "Code" + strings.TrimPrefix(name, "Err")
 		},
+		"trimErr": func(name string) string {
+			return strings.TrimPrefix(name, "Err")
+		},
+		"grpcCode": grpcCodeFor,
 		"paramName": func(param string) string {
 			return strings.ToLower(param)
 		},
+		"paramGoType": func(e ErrorDefinition, param string) string {
+			return parameterTypes[e.parameterType(param)].goType
+		},
+		"paramString": func(e ErrorDefinition, param string) string {
+			return fmt.Sprintf(parameterTypes[e.parameterType(param)].format, strings.ToLower(param))
+		},
+		"usesParamType": func(errors []ErrorDefinition, typ string) bool {
+			for _, e := range errors {
+				for _, param := range e.Parameters {
+					if e.parameterType(param) == typ {
+						return true
+					}
+				}
+			}
+			return false
+		},
+		"splitByCategory": func() bool {
+			return g.config.splitByCategory
+		},
+		"catalogHandler": func() bool {
+			return g.config.catalogHandler
+		},
+		"stackTraces": func() bool {
+			return g.config.stackTraces
+		},
+		"httpUtil": func() bool {
+			return g.config.httpUtil
+		},
 		"sanitizeName": func(name string) string {
 			return strings.ReplaceAll(strings.ReplaceAll(name, " ", "_"), "-", "_")
 		},
@@ -307,12 +641,13 @@ func (g *Generator) generateCode(config *ErrorConfig) error {
 		// Use custom template file
 		tmpl, err = template.New(filepath.Base(g.config.templateFile)).Funcs(funcMap).ParseFiles(g.config.templateFile)
 	} else {
-		// Use embedded template
-		tmplContent, readErr := Templates.ReadFile("templates/error.go.tmpl")
+		// Use the embedded template of the language
+		name := languageTemplates[g.config.language]
+		tmplContent, readErr := Templates.ReadFile("templates/" + name)
 		if readErr != nil {
 			return fmt.Errorf("failed to read embedded template: %w", readErr)
 		}
-		tmpl, err = template.New("error.go.tmpl").Funcs(funcMap).Parse(string(tmplContent))
+		tmpl, err = template.New(name).Funcs(funcMap).Parse(string(tmplContent))
 	}
 
 	if err != nil {
@@ -326,22 +661,56 @@ func (g *Generator) generateCode(config *ErrorConfig) error {
 		outputPath = filepath.Join(wd, outputPath)
 	}
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	if err := g.writeTemplate(tmpl, tmpl.Name(), outputPath, config); err != nil {
+		return err
 	}
 
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	// Write each category's errors next to the shared file if requested
+	if g.config.splitByCategory {
+		return g.generateCategoryFiles(tmpl, outputPath, config)
 	}
+	return nil
+}
 
-	if err := tmpl.Execute(outFile, config); err != nil {
-		outFile.Close()
+// writeTemplate executes the named template with data into the file at
+// path.
+func (g *Generator) writeTemplate(tmpl *template.Template, name, path string, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
 		return fmt.Errorf("failed to execute template: %w", err)
 	}
+	return g.writeFile(path, buf.Bytes())
+}
+
+// writeFile writes a generated file, creating its directory. While checking
+// it records the file as stale instead when it is missing or differs.
+func (g *Generator) writeFile(path string, content []byte) error {
+	if g.checking {
+		if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, content) {
+			g.stale = append(g.stale, path)
+		}
+		return nil
+	}
 
-	return outFile.Close()
+	// Ensure output directory exists
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// generateTests writes the tests of the generated errors next to the output
+// file.
+func (g *Generator) generateTests(config *ErrorConfig) error {
+	tmpl, err := template.ParseFS(Templates, "templates/error_test.go.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	path := strings.TrimSuffix(g.config.outputFile, ".go") + "_test.go"
+	return g.writeTemplate(tmpl, tmpl.Name(), path, config)
 }
 
 // validate ensures the error config is valid.
@@ -354,6 +723,21 @@ func (c *ErrorConfig) validate() error {
 		return fmt.Errorf("errors list must not be empty")
 	}
 
+	var codePattern *regexp.Regexp
+	if c.CodePattern != "" {
+		var err error
+		if codePattern, err = regexp.Compile(c.CodePattern); err != nil {
+			return fmt.Errorf("invalid code pattern %q: %w", c.CodePattern, err)
+		}
+	}
+
+	for category := range c.Defaults.Categories {
+		if len(c.Categories) > 0 && !slices.Contains(c.Categories, category) {
+			return fmt.Errorf("defaults declared for invalid category %s; must be one of: %s",
+				category, strings.Join(c.Categories, ", "))
+		}
+	}
+
 	seenCodes := make(map[string]bool)
 	seenNames := make(map[string]bool)
 
@@ -374,8 +758,21 @@ func (c *ErrorConfig) validate() error {
 			return fmt.Errorf("error code %q must be UPPER_SNAKE_CASE (e.g. NOT_FOUND, INVALID_INPUT)", e.Code)
 		}
 
+		if !strings.HasPrefix(e.Code, c.CodePrefix) {
+			return fmt.Errorf("error code %q of %s must start with the code prefix %q", e.Code, e.Name, c.CodePrefix)
+		}
+
+		if codePattern != nil && !codePattern.MatchString(e.Code) {
+			return fmt.Errorf("error code %q of %s does not match the code pattern %q", e.Code, e.Name, c.CodePattern)
+		}
+
 		if e.HTTPStatus != 0 && !isValidHTTPStatus(e.HTTPStatus) {
-			return fmt.Errorf("invalid HTTP status %d for error %s; must be between 100 and 599", e.HTTPStatus, e.Name)
+			return fmt.Errorf("invalid HTTP status %d for error %s; must be a standard HTTP status code", e.HTTPStatus, e.Name)
+		}
+
+		if e.Category != "" && len(c.Categories) > 0 && !slices.Contains(c.Categories, e.Category) {
+			return fmt.Errorf("invalid category %s for error %s; must be one of: %s",
+				e.Category, e.Name, strings.Join(c.Categories, ", "))
 		}
 
 		if e.Severity != "" && !isValidSeverity(e.Severity) {
@@ -383,6 +780,11 @@ func (c *ErrorConfig) validate() error {
 				e.Severity, e.Name)
 		}
 
+		if e.GRPCCode != "" && !isValidGRPCCode(e.GRPCCode) {
+			return fmt.Errorf("invalid gRPC code %s for error %s; must be a google.golang.org/grpc/codes name other than OK, e.g. NotFound",
+				e.GRPCCode, e.Name)
+		}
+
 		if len(e.Parameters) > 0 {
 			for _, param := range e.Parameters {
 				if !strings.Contains(e.Message, "{"+param+"}") {
@@ -391,13 +793,95 @@ func (c *ErrorConfig) validate() error {
 			}
 		}
 
+		for _, m := range placeholderPattern.FindAllStringSubmatch(e.Message, -1) {
+			if !slices.Contains(e.Parameters, m[1]) {
+				return fmt.Errorf("placeholder {%s} in message of error %s is not a parameter", m[1], e.Name)
+			}
+		}
+
+		for param, typ := range e.ParameterTypes {
+			if !slices.Contains(e.Parameters, param) {
+				return fmt.Errorf("type declared for unknown parameter %s in error %s", param, e.Name)
+			}
+			if _, ok := parameterTypes[typ]; !ok {
+				return fmt.Errorf("invalid type %s for parameter %s in error %s; must be one of: string, int, uuid, duration",
+					typ, param, e.Name)
+			}
+		}
+
+		if e.ReplacedBy != "" && !e.Deprecated {
+			return fmt.Errorf("error %s is replaced by %s but not deprecated", e.Name, e.ReplacedBy)
+		}
+
 		seenCodes[e.Code] = true
 		seenNames[e.Name] = true
 	}
 
+	for _, e := range c.Errors {
+		if e.ReplacedBy == "" {
+			continue
+		}
+		if !seenCodes[e.ReplacedBy] || e.ReplacedBy == e.Code {
+			return fmt.Errorf("error %s is replaced by %s, which is not another error code of the catalog", e.Name, e.ReplacedBy)
+		}
+	}
+
 	return nil
 }
 
+// applyDefaults sets the fields errors leave unset to the defaults of their
+// category or, failing that, of the catalog.
+func (c *ErrorConfig) applyDefaults() {
+	for i := range c.Errors {
+		e := &c.Errors[i]
+		for _, d := range []ErrorDefaults{c.Defaults.Categories[e.Category], c.Defaults.ErrorDefaults} {
+			if e.Severity == "" {
+				e.Severity = d.Severity
+			}
+			if e.HTTPStatus == 0 {
+				e.HTTPStatus = d.HTTPStatus
+			}
+			if e.GRPCCode == "" {
+				e.GRPCCode = d.GRPCCode
+			}
+		}
+	}
+}
+
+// applyCodePrefix prepends the code prefix to the codes that do not start
+// with it.
+func (c *ErrorConfig) applyCodePrefix() {
+	for i, e := range c.Errors {
+		if !strings.HasPrefix(e.Code, c.CodePrefix) {
+			c.Errors[i].Code = c.CodePrefix + e.Code
+		}
+	}
+}
+
+// parameterType describes how a parameter type is declared in the generated
+// constructors and formatted into the message: format is applied to the
+// parameter's name.
+type parameterType struct {
+	goType string
+	format string
+}
+
+// parameterTypes are the types a parameter may be declared with.
+var parameterTypes = map[string]parameterType{
+	"string":   {goType: "string", format: "%s"},
+	"int":      {goType: "int", format: "strconv.Itoa(%s)"},
+	"uuid":     {goType: "uuid.UUID", format: "%s.String()"},
+	"duration": {goType: "time.Duration", format: "%s.String()"},
+}
+
+// parameterType returns the declared type of param, or "string".
+func (e ErrorDefinition) parameterType(param string) string {
+	if typ, ok := e.ParameterTypes[param]; ok {
+		return typ
+	}
+	return "string"
+}
+
 // isUpperSnakeCase returns true when s contains only uppercase letters, digits,
 // and underscores and does not start or end with an underscore.
 func isUpperSnakeCase(s string) bool {
@@ -412,9 +896,67 @@ func isUpperSnakeCase(s string) bool {
 	return true
 }
 
-// isValidHTTPStatus returns true for standard HTTP status codes (100–599).
+// isValidHTTPStatus returns true for standard HTTP status codes, those
+// net/http has a status text for.
 func isValidHTTPStatus(code int) bool {
-	return code >= 100 && code <= 599
+	return http.StatusText(code) != ""
+}
+
+// placeholderPattern matches the parameter placeholders of a message, e.g.
+// "{bookingID}".
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// grpcCodes are the names of the gRPC status codes an error may have: those of
+// google.golang.org/grpc/codes except OK.
+var grpcCodes = []string{
+	"Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable",
+	"DataLoss", "Unauthenticated",
+}
+
+func isValidGRPCCode(code string) bool {
+	return slices.Contains(grpcCodes, code)
+}
+
+// grpcCodeFor returns the gRPC status code of e: its GRPCCode, or the code
+// conventionally used for its HTTP status, as in the gRPC-HTTP mapping of
+// google.rpc.Code.
+func grpcCodeFor(e ErrorDefinition) string {
+	if e.GRPCCode != "" {
+		return e.GRPCCode
+	}
+	switch e.HTTPStatus {
+	case 400:
+		return "InvalidArgument"
+	case 401:
+		return "Unauthenticated"
+	case 403:
+		return "PermissionDenied"
+	case 404:
+		return "NotFound"
+	case 409:
+		return "AlreadyExists"
+	case 412:
+		return "FailedPrecondition"
+	case 429:
+		return "ResourceExhausted"
+	case 499:
+		return "Canceled"
+	case 501:
+		return "Unimplemented"
+	case 503:
+		return "Unavailable"
+	case 504:
+		return "DeadlineExceeded"
+	}
+	switch {
+	case e.HTTPStatus >= 400 && e.HTTPStatus < 500:
+		return "FailedPrecondition"
+	case e.HTTPStatus >= 500:
+		return "Internal"
+	}
+	return "Unknown"
 }
 
 func isValidSeverity(severity string) bool {
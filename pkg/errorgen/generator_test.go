@@ -1,10 +1,21 @@
 package errorgen
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestIsUpperSnakeCase(t *testing.T) {
@@ -22,9 +33,12 @@ func TestIsUpperSnakeCase(t *testing.T) {
 func TestIsValidHTTPStatus(t *testing.T) {
 	assert.True(t, isValidHTTPStatus(200))
 	assert.True(t, isValidHTTPStatus(100))
-	assert.True(t, isValidHTTPStatus(599))
+	assert.True(t, isValidHTTPStatus(404))
+	assert.True(t, isValidHTTPStatus(503))
 	assert.False(t, isValidHTTPStatus(0))
 	assert.False(t, isValidHTTPStatus(99))
+	assert.False(t, isValidHTTPStatus(499))
+	assert.False(t, isValidHTTPStatus(599))
 	assert.False(t, isValidHTTPStatus(600))
 }
 
@@ -37,6 +51,26 @@ func TestIsValidSeverity(t *testing.T) {
 	assert.False(t, isValidSeverity("severe"))
 }
 
+func TestGRPCCodeFor(t *testing.T) {
+	for _, tc := range []struct {
+		def  ErrorDefinition
+		want string
+	}{
+		{ErrorDefinition{GRPCCode: "Aborted", HTTPStatus: 409}, "Aborted"},
+		{ErrorDefinition{HTTPStatus: 400}, "InvalidArgument"},
+		{ErrorDefinition{HTTPStatus: 401}, "Unauthenticated"},
+		{ErrorDefinition{HTTPStatus: 404}, "NotFound"},
+		{ErrorDefinition{HTTPStatus: 409}, "AlreadyExists"},
+		{ErrorDefinition{HTTPStatus: 429}, "ResourceExhausted"},
+		{ErrorDefinition{HTTPStatus: 422}, "FailedPrecondition"},
+		{ErrorDefinition{HTTPStatus: 503}, "Unavailable"},
+		{ErrorDefinition{HTTPStatus: 500}, "Internal"},
+		{ErrorDefinition{}, "Unknown"},
+	} {
+		assert.Equal(t, tc.want, grpcCodeFor(tc.def), "%+v", tc.def)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	t.Run("missing package", func(t *testing.T) {
 		c := &ErrorConfig{Errors: []ErrorDefinition{{Name: "ErrFoo", Code: "FOO", Message: "foo"}}}
@@ -92,6 +126,8 @@ func TestValidate(t *testing.T) {
 			Errors:  []ErrorDefinition{{Name: "ErrFoo", Code: "FOO", Message: "foo", HTTPStatus: 999}},
 		}
 		require.ErrorContains(t, c.validate(), "invalid HTTP status")
+		c.Errors[0].HTTPStatus = 432
+		require.ErrorContains(t, c.validate(), "invalid HTTP status 432")
 	})
 
 	t.Run("invalid severity", func(t *testing.T) {
@@ -102,6 +138,16 @@ func TestValidate(t *testing.T) {
 		require.ErrorContains(t, c.validate(), "invalid severity")
 	})
 
+	t.Run("invalid grpc code", func(t *testing.T) {
+		c := &ErrorConfig{
+			Package: "errs",
+			Errors:  []ErrorDefinition{{Name: "ErrFoo", Code: "FOO", Message: "foo", GRPCCode: "NOT_FOUND"}},
+		}
+		require.ErrorContains(t, c.validate(), "invalid gRPC code NOT_FOUND")
+		c.Errors[0].GRPCCode = "OK"
+		require.ErrorContains(t, c.validate(), "invalid gRPC code OK")
+	})
+
 	t.Run("parameter not in message", func(t *testing.T) {
 		c := &ErrorConfig{
 			Package: "errs",
@@ -112,6 +158,49 @@ func TestValidate(t *testing.T) {
 		require.ErrorContains(t, c.validate(), "not found in message")
 	})
 
+	t.Run("placeholder not a parameter", func(t *testing.T) {
+		c := &ErrorConfig{
+			Package: "errs",
+			Errors: []ErrorDefinition{
+				{Name: "ErrFoo", Code: "FOO", Message: "item {id} of {owner} not found", Parameters: []string{"id"}},
+			},
+		}
+		require.ErrorContains(t, c.validate(), "placeholder {owner} in message of error ErrFoo is not a parameter")
+	})
+
+	t.Run("category not allowed", func(t *testing.T) {
+		c := &ErrorConfig{
+			Package:    "errs",
+			Categories: []string{"not_found", "conflict"},
+			Errors:     []ErrorDefinition{{Name: "ErrFoo", Code: "FOO", Message: "foo", Category: "notfound"}},
+		}
+		require.ErrorContains(t, c.validate(), "invalid category notfound for error ErrFoo; must be one of: not_found, conflict")
+		c.Errors[0].Category = "not_found"
+		require.NoError(t, c.validate())
+		c.Errors[0].Category = ""
+		require.NoError(t, c.validate())
+	})
+
+	t.Run("type of unknown parameter", func(t *testing.T) {
+		c := &ErrorConfig{
+			Package: "errs",
+			Errors: []ErrorDefinition{
+				{Name: "ErrFoo", Code: "FOO", Message: "item {id} not found", Parameters: []string{"id"}, ParameterTypes: map[string]string{"ID": "uuid"}},
+			},
+		}
+		require.ErrorContains(t, c.validate(), "type declared for unknown parameter ID")
+	})
+
+	t.Run("invalid parameter type", func(t *testing.T) {
+		c := &ErrorConfig{
+			Package: "errs",
+			Errors: []ErrorDefinition{
+				{Name: "ErrFoo", Code: "FOO", Message: "item {id} not found", Parameters: []string{"id"}, ParameterTypes: map[string]string{"id": "float"}},
+			},
+		}
+		require.ErrorContains(t, c.validate(), "invalid type float for parameter id")
+	})
+
 	t.Run("valid config", func(t *testing.T) {
 		c := &ErrorConfig{
 			Package: "errs",
@@ -147,3 +236,720 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, "myerrs", g.config.packageName)
 	})
 }
+
+// generate writes the input file name with content to a temp dir, runs the
+// generator on it, and returns the generated code.
+func generate(t *testing.T, name, content string, opts ...GeneratorOption) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	input := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(input, []byte(content), 0o644))
+	output := filepath.Join(dir, "errors.go")
+	g, err := NewGenerator(append([]GeneratorOption{WithInputFile(input), WithOutputFile(output)}, opts...)...)
+	require.NoError(t, err)
+	if err := g.Generate(); err != nil {
+		return "", err
+	}
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	return string(code), nil
+}
+
+func TestGenerate_InputFormats(t *testing.T) {
+	inputs := map[string]string{
+		"errors.cue": `package errors
+
+package: "booking"
+errors: [{
+	name:       "ErrBookingNotFound"
+	code:       "BOOKING_NOT_FOUND"
+	message:    "booking {id} not found"
+	category:   "not_found"
+	httpStatus: 404
+	severity:   "low"
+	parameters: ["id"]
+}]
+`,
+		"errors.yaml": `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking {id} not found
+    category: not_found
+    httpStatus: 404
+    severity: low
+    parameters: [id]
+`,
+		"errors.json": `{
+  "package": "booking",
+  "errors": [{
+    "name": "ErrBookingNotFound",
+    "code": "BOOKING_NOT_FOUND",
+    "message": "booking {id} not found",
+    "category": "not_found",
+    "httpStatus": 404,
+    "severity": "low",
+    "parameters": ["id"]
+  }]
+}
+`,
+	}
+
+	want, err := generate(t, "errors.cue", inputs["errors.cue"])
+	require.NoError(t, err)
+	assert.Contains(t, want, "package booking\n")
+	assert.Contains(t, want, `CodeBookingNotFound = "BOOKING_NOT_FOUND"`)
+
+	for _, name := range []string{"errors.yaml", "errors.json"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := generate(t, name, inputs[name])
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := generate(t, "errors.yaml", "errors:\n  - name: ErrFoo\n    code: FOO\n    mesage: foo\n")
+		require.ErrorContains(t, err, "field mesage not found")
+		_, err = generate(t, "errors.json", `{"errors": [{"name": "ErrFoo", "code": "FOO", "mesage": "foo"}]}`)
+		require.ErrorContains(t, err, `unknown field "mesage"`)
+	})
+
+	t.Run("missing errors", func(t *testing.T) {
+		_, err := generate(t, "errors.yml", "package: booking\n")
+		require.ErrorContains(t, err, "errors field not found")
+	})
+
+	t.Run("validated like CUE", func(t *testing.T) {
+		_, err := generate(t, "errors.json", `{"errors": [{"name": "ErrFoo", "code": "foo", "message": "foo"}]}`)
+		require.ErrorContains(t, err, "UPPER_SNAKE_CASE")
+	})
+}
+
+func TestGenerate_GRPC(t *testing.T) {
+	code, err := generate(t, "errors.yaml", `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+    httpStatus: 404
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking is locked
+    httpStatus: 409
+    grpcCode: Aborted
+`)
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (e *Error) GRPCStatus() *status.Status {")
+	assert.Contains(t, code, "\tCodeBookingNotFound: codes.NotFound,\n")
+	assert.Contains(t, code, "\tCodeBookingLocked: codes.Aborted,\n")
+	assert.Contains(t, code, "\tGRPCCode:   codes.NotFound,\n")
+	assert.Contains(t, code, "func ToGRPCError(err error) error {")
+}
+
+// TestGenerate_GRPCStatusHidesInternalErrors runs the generated tests, which
+// check that the gRPC status of an error not exposed to clients has neither
+// its message nor its context.
+func TestGenerate_GRPCStatusHidesInternalErrors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and tests the generated package")
+	}
+	// Inside the module so the generated package builds with its
+	// dependencies; go commands skip testdata unless named
+	require.NoError(t, os.MkdirAll("testdata", 0o755))
+	dir, err := os.MkdirTemp("testdata", "grpc")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		os.Remove("testdata")
+	})
+
+	input := filepath.Join(dir, "errors.yaml")
+	require.NoError(t, os.WriteFile(input, []byte(`package: booking
+errors:
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking is locked
+    exposeToClient: true
+  - name: ErrLedgerCorrupt
+    code: LEDGER_CORRUPT
+    message: ledger checksum mismatch
+`), 0o644))
+	g, err := NewGenerator(WithInputFile(input), WithOutputFile(filepath.Join(dir, "errors.go")), WithTests())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	out, err := exec.Command("go", "test", "-run", "TestErrors_GRPCStatusHidesInternalErrors", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
+
+func TestGenerate_TypedParameters(t *testing.T) {
+	code, err := generate(t, "errors.cue", `package errors
+
+errors: [{
+	name:           "ErrBookingExpired"
+	code:           "BOOKING_EXPIRED"
+	message:        "booking {bookingID} expired {age} ago after {renewals} renewals by {owner}"
+	parameters:     ["bookingID", "age", "renewals", "owner"]
+	parameterTypes: {bookingID: "uuid", age: "duration", renewals: "int"}
+}]
+`)
+	require.NoError(t, err)
+	assert.Contains(t, code, "\t\"github.com/google/uuid\"\n")
+	assert.Contains(t, code, "\t\"strconv\"\n")
+	assert.Contains(t, code, "func NewErrBookingExpired(ctx context.Context, errCtx *ErrorContext, bookingid uuid.UUID, age time.Duration, renewals int, owner string) *Error {")
+	assert.Contains(t, code, "err.setParams(bookingid.String(), age.String(), strconv.Itoa(renewals), owner)")
+	assert.Contains(t, code, "func WrapErrBookingExpired(err error, bookingid uuid.UUID, age time.Duration, renewals int, owner string) *Error {")
+
+	code, err = generate(t, "errors.yaml", "errors:\n  - {name: ErrFoo, code: FOO, message: 'foo {id}', parameters: [id]}\n")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "uuid")
+	assert.NotContains(t, code, "strconv")
+	assert.Contains(t, code, "func NewErrFoo(ctx context.Context, errCtx *ErrorContext, id string) *Error {")
+}
+
+func TestGenerate_LogValue(t *testing.T) {
+	code, err := generate(t, "errors.yaml", `errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking {id} not found
+    category: not_found
+    parameters: [id]
+`)
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (e *Error) LogValue() slog.Value {")
+	assert.Contains(t, code, "\tCategory:   \"not_found\",\n")
+	assert.Contains(t, code, "\terr.setParams(id)\n")
+	assert.Contains(t, code, "\tnewErr.setParams(id)\n")
+}
+
+func TestGenerate_RetryableAndPublic(t *testing.T) {
+	code, err := generate(t, "errors.cue", `package errors
+
+errors: [{
+	name:           "ErrBookingLocked"
+	code:           "BOOKING_LOCKED"
+	message:        "booking is locked"
+	retryable:      true
+	exposeToClient: true
+}, {
+	name:    "ErrLedgerCorrupt"
+	code:    "LEDGER_CORRUPT"
+	message: "ledger checksum mismatch"
+}]
+`)
+	require.NoError(t, err)
+	assert.Contains(t, code, "\tRetryable:  true,\n\tPublic:     true,\n")
+	assert.Contains(t, code, "\tRetryable:  false,\n\tPublic:     false,\n")
+	assert.Contains(t, code, "func IsRetryable(err error) bool {")
+	assert.Contains(t, code, "func PublicMessage(err error) string {")
+}
+
+func TestDocCategories(t *testing.T) {
+	config := &ErrorConfig{Errors: []ErrorDefinition{
+		{Name: "ErrA", Category: "validation"},
+		{Name: "ErrB"},
+		{Name: "ErrC", Category: "auth"},
+		{Name: "ErrD", Category: "validation"},
+	}}
+	var got []string
+	for _, c := range docCategories(config) {
+		var names []string
+		for _, e := range c.Errors {
+			names = append(names, e.Name)
+		}
+		got = append(got, c.Name+": "+strings.Join(names, ", "))
+	}
+	assert.Equal(t, []string{"auth: ErrC", "validation: ErrA, ErrD", "uncategorized: ErrB"}, got)
+}
+
+func TestGenerate_Docs(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking {id} not found
+    category: not_found
+    httpStatus: 404
+    severity: low
+    description: Check the booking ID | it may have been deleted
+    parameters: [id]
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking is <locked>
+    category: conflict
+    httpStatus: 409
+`
+	docs := func(t *testing.T, name string) string {
+		t.Helper()
+		docsFile := filepath.Join(t.TempDir(), name)
+		_, err := generate(t, "errors.yaml", input, WithDocsFile(docsFile))
+		require.NoError(t, err)
+		raw, err := os.ReadFile(docsFile)
+		require.NoError(t, err)
+		return string(raw)
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		md := docs(t, "errors.md")
+		assert.Contains(t, md, "# booking error catalog\n")
+		assert.Contains(t, md, "| `BOOKING_NOT_FOUND` | booking {id} not found | 404 | low | Check the booking ID \\| it may have been deleted |\n")
+		assert.Less(t, strings.Index(md, "## conflict"), strings.Index(md, "## not_found"))
+	})
+
+	t.Run("html", func(t *testing.T) {
+		html := docs(t, "errors.html")
+		assert.Contains(t, html, "<h2 id=\"conflict\">conflict</h2>")
+		assert.Contains(t, html, "<td>booking is &lt;locked&gt;</td>")
+	})
+}
+
+func TestGenerate_TypeScriptAndOpenAPI(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking {id} not found
+    category: not_found
+    httpStatus: 404
+    parameters: [id]
+    retryable: true
+`
+	t.Run("typescript", func(t *testing.T) {
+		code, err := generate(t, "errors.yaml", input, WithLanguage(LanguageTypeScript))
+		require.NoError(t, err)
+		assert.Contains(t, code, "  BookingNotFound: \"BOOKING_NOT_FOUND\",\n")
+		assert.Contains(t, code, "export type ErrorCode = (typeof ErrorCode)[keyof typeof ErrorCode];")
+		assert.Contains(t, code, "    httpStatus: 404,\n")
+		assert.Contains(t, code, "    parameters: [\"id\"],\n")
+		assert.Contains(t, code, "    retryable: true,\n")
+		assert.NotContains(t, code, "package booking")
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		openAPIFile := filepath.Join(t.TempDir(), "errors.yaml")
+		_, err := generate(t, "errors.yaml", input, WithOpenAPIFile(openAPIFile))
+		require.NoError(t, err)
+		raw, err := os.ReadFile(openAPIFile)
+		require.NoError(t, err)
+
+		var doc struct {
+			Components struct {
+				Schemas map[string]struct {
+					Enum []string `yaml:"enum"`
+				} `yaml:"schemas"`
+			} `yaml:"components"`
+		}
+		require.NoError(t, yaml.Unmarshal(raw, &doc))
+		assert.Equal(t, []string{"BOOKING_NOT_FOUND"}, doc.Components.Schemas["ErrorCode"].Enum)
+		assert.Contains(t, doc.Components.Schemas, "ErrorResponse")
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		_, err := NewGenerator(WithLanguage("rust"))
+		assert.ErrorContains(t, err, `unsupported language "rust"`)
+	})
+}
+
+func TestCategoryFileName(t *testing.T) {
+	assert.Equal(t, "not_found_errors.go", categoryFileName("/app/errors.go", "not_found"))
+	assert.Equal(t, "rate_limit_errors.gen.go", categoryFileName("errors.gen.go", "Rate-Limit"))
+	assert.Equal(t, "test_errors.go", categoryFileName("errors.go", "test"))
+}
+
+func TestGenerate_SplitByCategory(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking {id} not found
+    category: not_found
+    parameters: [id]
+    parameterTypes: {id: int}
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking is locked
+`
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "errors.yaml")
+	require.NoError(t, os.WriteFile(inputFile, []byte(input), 0o644))
+	g, err := NewGenerator(WithInputFile(inputFile), WithOutputFile(filepath.Join(dir, "errors.go")), WithSplitByCategory())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	read := func(name string) string {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		_, err = format.Source(raw)
+		require.NoError(t, err, "%s is not valid Go", name)
+		return string(raw)
+	}
+
+	shared := read("errors.go")
+	assert.Contains(t, shared, "func ToGRPCError(err error) error {")
+	assert.Contains(t, shared, "CodeBookingNotFound: codes.")
+	assert.NotContains(t, shared, "var ErrBookingNotFound")
+	assert.NotContains(t, shared, `"strconv"`)
+
+	notFound := read("not_found_errors.go")
+	assert.Contains(t, notFound, `CodeBookingNotFound = "BOOKING_NOT_FOUND"`)
+	assert.Contains(t, notFound, "func NewErrBookingNotFound(ctx context.Context, errCtx *ErrorContext, id int) *Error {")
+	assert.Contains(t, notFound, `"strconv"`)
+	assert.NotContains(t, notFound, "ErrBookingLocked")
+
+	assert.Contains(t, read("uncategorized_errors.go"), "var ErrBookingLocked = &Error{")
+
+	t.Run("custom template", func(t *testing.T) {
+		_, err := NewGenerator(WithTemplateFile("errors.tmpl"), WithSplitByCategory())
+		assert.ErrorContains(t, err, "requires the embedded Go template")
+	})
+}
+
+func TestGenerate_Registry(t *testing.T) {
+	code, err := generate(t, "errors.yaml", `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+`)
+	require.NoError(t, err)
+	assert.Contains(t, code, "var Registry = map[string]*Error{\n\tCodeBookingNotFound: ErrBookingNotFound,\n}")
+	assert.Contains(t, code, "func FromCode(code string) (*Error, bool) {")
+	assert.Contains(t, code, `panic(fmt.Sprintf("booking: unknown error code %q", code))`)
+}
+
+func TestGenerate_Tests(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "errors.yaml")
+	require.NoError(t, os.WriteFile(inputFile, []byte(`package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+`), 0o644))
+	g, err := NewGenerator(WithInputFile(inputFile), WithOutputFile(filepath.Join(dir, "errors.gen.go")), WithTests())
+	require.NoError(t, err)
+	require.NoError(t, g.Generate())
+
+	code, err := os.ReadFile(filepath.Join(dir, "errors.gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(code), "func (e *Error) Is(target error) bool {")
+
+	tests, err := os.ReadFile(filepath.Join(dir, "errors.gen_test.go"))
+	require.NoError(t, err)
+	_, err = format.Source(tests)
+	require.NoError(t, err)
+	assert.Contains(t, string(tests), "func TestErrors_IsAs(t *testing.T) {")
+	assert.Contains(t, string(tests), `{"ErrBookingNotFound", ErrBookingNotFound},`)
+	assert.Contains(t, string(tests), "func TestErrors_GRPCStatusHidesInternalErrors(t *testing.T) {")
+
+	_, err = NewGenerator(WithLanguage(LanguageTypeScript), WithTests())
+	assert.ErrorContains(t, err, "tests can only be generated for Go")
+}
+
+func TestGenerate_CodePrefix(t *testing.T) {
+	input := `package: booking
+codePrefix: BOOKING_
+codePattern: ^[A-Z]+_[A-Z_]{3,}$
+errors:
+  - name: ErrBookingNotFound
+    code: %s
+    message: booking not found
+`
+	tests := []struct {
+		name    string
+		code    string
+		opts    []GeneratorOption
+		want    string
+		wantErr string
+	}{
+		{name: "prefixed", code: "BOOKING_NOT_FOUND", want: `CodeBookingNotFound = "BOOKING_NOT_FOUND"`},
+		{name: "missing prefix", code: "NOT_FOUND", wantErr: `error code "NOT_FOUND" of ErrBookingNotFound must start with the code prefix "BOOKING_"`},
+		{name: "auto prefix", code: "NOT_FOUND", opts: []GeneratorOption{WithAutoPrefix()}, want: `CodeBookingNotFound = "BOOKING_NOT_FOUND"`},
+		{name: "auto prefix keeps prefixed", code: "BOOKING_GONE", opts: []GeneratorOption{WithAutoPrefix()}, want: `CodeBookingNotFound = "BOOKING_GONE"`},
+		{name: "pattern mismatch", code: "BOOKING_NO", wantErr: `does not match the code pattern`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := generate(t, "errors.yaml", fmt.Sprintf(input, tt.code), tt.opts...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, code, tt.want)
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := generate(t, "errors.json", `{"package": "booking", "codePattern": "([A-Z", "errors": [{"name": "ErrGone", "code": "GONE", "message": "gone"}]}`)
+		assert.ErrorContains(t, err, "invalid code pattern")
+	})
+
+	t.Run("cue", func(t *testing.T) {
+		_, err := generate(t, "errors.cue", `package errors
+
+package:    "booking"
+codePrefix: "BOOKING_"
+errors: [{name: "ErrGone", code: "GONE", message: "gone"}]
+`)
+		assert.ErrorContains(t, err, "must start with the code prefix")
+	})
+}
+
+func TestGenerate_Deprecated(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingCancelled
+    code: BOOKING_CANCELLED
+    message: booking cancelled
+    deprecated: true
+    replacedBy: %s
+  - name: ErrBookingGone
+    code: BOOKING_GONE
+    message: booking gone
+`
+	code, err := generate(t, "errors.yaml", fmt.Sprintf(input, "BOOKING_GONE"))
+	require.NoError(t, err)
+	assert.Contains(t, code, "//\n// Deprecated: use the BOOKING_GONE error instead.\nvar ErrBookingCancelled = &Error{")
+	assert.Contains(t, code, "// Deprecated: use the BOOKING_GONE error instead.\nfunc NewErrBookingCancelled(")
+	assert.Contains(t, code, "\twarnDeprecated(ctx, CodeBookingCancelled)\n")
+	assert.Contains(t, code, "\tCodeBookingCancelled: \"BOOKING_GONE\",\n")
+	assert.NotContains(t, code, "warnDeprecated(ctx, CodeBookingGone)")
+
+	_, err = generate(t, "errors.yaml", fmt.Sprintf(input, "BOOKING_MISSING"))
+	assert.ErrorContains(t, err, "replaced by BOOKING_MISSING, which is not another error code of the catalog")
+
+	_, err = generate(t, "errors.yaml", strings.Replace(fmt.Sprintf(input, "BOOKING_GONE"), "deprecated: true", "deprecated: false", 1))
+	assert.ErrorContains(t, err, "but not deprecated")
+}
+
+func TestGenerate_CatalogHandler(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking "{id}" not found
+    category: not_found
+    httpStatus: 404
+    parameters: [id]
+`
+	code, err := generate(t, "errors.yaml", input)
+	require.NoError(t, err)
+	assert.NotContains(t, code, "CatalogHandler")
+	assert.NotContains(t, code, `"net/http"`)
+
+	code, err = generate(t, "errors.yaml", input, WithCatalogHandler())
+	require.NoError(t, err)
+	assert.Contains(t, code, "\t\"net/http\"\n")
+	assert.Contains(t, code, "func CatalogHandler() http.Handler {")
+	assert.Contains(t, code, `		Message:     "booking \"{id}\" not found",`)
+	assert.Contains(t, code, `		GRPCCode:    "NotFound",`)
+	assert.Contains(t, code, `		Parameters:  []string{"id"},`)
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "errors.yaml")
+	outputFile := filepath.Join(dir, "errors.go")
+	docsFile := filepath.Join(dir, "errors.md")
+	writeInput := func(message string) {
+		require.NoError(t, os.WriteFile(inputFile, []byte(`package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: `+message+`
+`), 0o644))
+	}
+	writeInput("booking not found")
+	g, err := NewGenerator(WithInputFile(inputFile), WithOutputFile(outputFile), WithDocsFile(docsFile))
+	require.NoError(t, err)
+
+	stale, err := g.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []string{outputFile, docsFile}, stale)
+	assert.NoFileExists(t, outputFile)
+
+	require.NoError(t, g.Generate())
+	stale, err = g.Check()
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	writeInput("booking missing")
+	stale, err = g.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []string{outputFile, docsFile}, stale)
+}
+
+func TestWatch_RegeneratesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "errors.yaml")
+	outputFile := filepath.Join(dir, "errors.go")
+	input := "package: booking\nerrors:\n  - name: ErrBookingNotFound\n    code: BOOKING_NOT_FOUND\n    message: %s\n"
+	require.NoError(t, os.WriteFile(inputFile, fmt.Appendf(nil, input, "booking not found"), 0o644))
+	g, err := NewGenerator(WithInputFile(inputFile), WithOutputFile(outputFile))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- g.Watch(ctx, out) }()
+
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "Watching ") }, 5*time.Second, 10*time.Millisecond)
+	assert.FileExists(t, outputFile)
+
+	require.NoError(t, os.WriteFile(inputFile, fmt.Appendf(nil, input, "booking missing"), 0o644))
+	require.Eventually(t, func() bool {
+		code, err := os.ReadFile(outputFile)
+		return err == nil && strings.Contains(string(code), "booking missing")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(inputFile, []byte("errors: [\n"), 0o644))
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "Generation failed: ") }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of Watch and
+// reads of the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestGenerate_CUECategories(t *testing.T) {
+	_, err := generate(t, "errors.cue", `package errors
+
+package:    "booking"
+categories: ["not_found"]
+errors: [{name: "ErrGone", code: "GONE", message: "gone", category: "gone"}]
+`)
+	assert.ErrorContains(t, err, "invalid category gone for error ErrGone; must be one of: not_found")
+}
+
+func TestGenerate_Defaults(t *testing.T) {
+	inputs := map[string]string{
+		"errors.yaml": `package: booking
+defaults:
+  severity: medium
+  httpStatus: 500
+  categories:
+    not_found:
+      httpStatus: 404
+      severity: low
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+    category: not_found
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking locked
+    category: not_found
+    severity: high
+  - name: ErrBookingFailed
+    code: BOOKING_FAILED
+    message: booking failed
+`,
+		"errors.json": `{
+  "package": "booking",
+  "defaults": {"severity": "medium", "httpStatus": 500, "categories": {"not_found": {"httpStatus": 404, "severity": "low"}}},
+  "errors": [
+    {"name": "ErrBookingNotFound", "code": "BOOKING_NOT_FOUND", "message": "booking not found", "category": "not_found"},
+    {"name": "ErrBookingLocked", "code": "BOOKING_LOCKED", "message": "booking locked", "category": "not_found", "severity": "high"},
+    {"name": "ErrBookingFailed", "code": "BOOKING_FAILED", "message": "booking failed"}
+  ]
+}`,
+		"errors.cue": `package errors
+
+package: "booking"
+defaults: {
+	severity:   "medium"
+	httpStatus: 500
+	categories: not_found: {httpStatus: 404, severity: "low"}
+}
+errors: [
+	{name: "ErrBookingNotFound", code: "BOOKING_NOT_FOUND", message: "booking not found", category: "not_found"},
+	{name: "ErrBookingLocked", code: "BOOKING_LOCKED", message: "booking locked", category: "not_found", severity: "high"},
+	{name: "ErrBookingFailed", code: "BOOKING_FAILED", message: "booking failed"},
+]
+`,
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			code, err := generate(t, name, input)
+			require.NoError(t, err)
+			assert.Contains(t, code, "Code:       CodeBookingNotFound,\n\tMessage:    \"booking not found\",\n\tHTTPStatus: 404,\n\tGRPCCode:   codes.NotFound,\n\tCategory:   \"not_found\",\n\tSeverity:   \"low\",")
+			assert.Contains(t, code, "Code:       CodeBookingLocked,\n\tMessage:    \"booking locked\",\n\tHTTPStatus: 404,\n\tGRPCCode:   codes.NotFound,\n\tCategory:   \"not_found\",\n\tSeverity:   \"high\",")
+			assert.Contains(t, code, "Code:       CodeBookingFailed,\n\tMessage:    \"booking failed\",\n\tHTTPStatus: 500,\n\tGRPCCode:   codes.Internal,\n\tCategory:   \"\",\n\tSeverity:   \"medium\",")
+		})
+	}
+
+	t.Run("defaults of a category not allowed", func(t *testing.T) {
+		_, err := generate(t, "errors.yaml", `package: booking
+categories: [conflict]
+defaults:
+  categories:
+    notfound: {httpStatus: 404}
+errors:
+  - name: ErrBookingLocked
+    code: BOOKING_LOCKED
+    message: booking locked
+`)
+		assert.ErrorContains(t, err, "defaults declared for invalid category notfound")
+	})
+}
+
+func TestGenerate_StackTraces(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+`
+	code, err := generate(t, "errors.yaml", input)
+	require.NoError(t, err)
+	assert.NotContains(t, code, "StackTrace")
+	assert.NotContains(t, code, `"runtime"`)
+
+	code, err = generate(t, "errors.yaml", input, WithStackTraces())
+	require.NoError(t, err)
+	assert.Contains(t, code, "\t\"runtime\"\n")
+	assert.Contains(t, code, "var CaptureStackTraces = true")
+	assert.Contains(t, code, "func (e *Error) StackTrace() []uintptr {")
+	assert.Equal(t, 2, strings.Count(code, "\tnewErr.stack = callers()\n"), "Wrap and WithContext capture the stack")
+}
+
+func TestGenerate_HTTPUtil(t *testing.T) {
+	input := `package: booking
+errors:
+  - name: ErrBookingNotFound
+    code: BOOKING_NOT_FOUND
+    message: booking not found
+    httpStatus: 404
+`
+	code, err := generate(t, "errors.yaml", input)
+	require.NoError(t, err)
+	assert.NotContains(t, code, "kit/pkg/httputil")
+	assert.Contains(t, code, "}\n\n// grpcCodes maps")
+
+	code, err = generate(t, "errors.yaml", input, WithHTTPUtil())
+	require.NoError(t, err)
+	assert.Contains(t, code, "\t\"github.com/ianmuhia/kit/pkg/httputil\"\n")
+	assert.Contains(t, code, "func (e *Error) ToHTTPError() *httputil.HTTPError {")
+	assert.Contains(t, code, "func WriteHTTP(w http.ResponseWriter, err error) {")
+}
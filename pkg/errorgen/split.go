@@ -0,0 +1,64 @@
+package errorgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// WithSplitByCategory writes the errors of each category, with their code
+// constants and constructors, to a file of their own next to the output
+// file, e.g. "not_found_errors.go" for "errors.go", keeping Error, the
+// helpers, and the code mappings shared by all categories in the output file.
+// Uncategorized errors go to "uncategorized_errors.go". It requires the
+// embedded Go template.
+func WithSplitByCategory() GeneratorOption {
+	return func(c *GeneratorConfig) {
+		c.splitByCategory = true
+	}
+}
+
+// categoryFileName returns the name of the file of a category's errors: the
+// category, in snake case, before the name of the shared file, so that no
+// category turns it into a test or platform-specific file.
+func categoryFileName(sharedFile, category string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, category)
+	return name + "_" + filepath.Base(sharedFile)
+}
+
+// generateCategoryFiles writes the errors of each category of config to
+// their own file in the directory of the shared file at sharedPath, with
+// the constants and definitions templates of tmpl.
+func (g *Generator) generateCategoryFiles(tmpl *template.Template, sharedPath string, config *ErrorConfig) error {
+	content, err := Templates.ReadFile("templates/category.go.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded template: %w", err)
+	}
+	if tmpl, err = tmpl.New("category.go.tmpl").Parse(string(content)); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for _, category := range docCategories(config) {
+		name := categoryFileName(sharedPath, category.Name)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("categories %q and %q would both be written to %s", other, category.Name, name)
+		}
+		seen[name] = category.Name
+
+		data := &ErrorConfig{Package: config.Package, Errors: category.Errors}
+		if err := g.writeTemplate(tmpl, "category.go.tmpl", filepath.Join(filepath.Dir(sharedPath), name), data); err != nil {
+			return fmt.Errorf("category %s: %w", category.Name, err)
+		}
+	}
+	return nil
+}
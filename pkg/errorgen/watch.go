@@ -0,0 +1,104 @@
+package errorgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits after the last change of the
+// definitions before regenerating, so an editor's burst of writes triggers
+// one run.
+const watchDebounce = 200 * time.Millisecond
+
+// Check generates the code in memory and returns the generated files that
+// are missing or differ from the files on disk, without writing any, so CI
+// can fail when the committed files drift from the definitions.
+func (g *Generator) Check() ([]string, error) {
+	g.checking, g.stale = true, nil
+	defer func() { g.checking = false }()
+
+	if err := g.Generate(); err != nil {
+		return nil, err
+	}
+	return g.stale, nil
+}
+
+// Watch generates the code, then regenerates it whenever the input file, or
+// a CUE file of the input directory, changes, until ctx is done. Each run is
+// reported to out; a failed run does not stop watching.
+func (g *Generator) Watch(ctx context.Context, out io.Writer) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	info, err := os.Stat(g.config.inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat input path: %w", err)
+	}
+	// Watch the directory rather than the file, which survives editors that
+	// save by renaming
+	dir := g.config.inputFile
+	if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	changed := func(name string) bool {
+		if info.IsDir() {
+			return filepath.Ext(name) == ".cue"
+		}
+		return filepath.Base(name) == filepath.Base(g.config.inputFile)
+	}
+
+	g.regenerate(out)
+	fmt.Fprintf(out, "Watching %s for changes\n", g.config.inputFile)
+
+	var (
+		timer   *time.Timer
+		trigger <-chan time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !changed(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(watchDebounce)
+			trigger = timer.C
+		case <-trigger:
+			trigger = nil
+			g.regenerate(out)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(out, "Watcher error: %v\n", err)
+		}
+	}
+}
+
+// regenerate runs Generate and reports the result to out.
+func (g *Generator) regenerate(out io.Writer) {
+	if err := g.Generate(); err != nil {
+		fmt.Fprintf(out, "Generation failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Regenerated %s\n", g.config.outputFile)
+}
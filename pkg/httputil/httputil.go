@@ -0,0 +1,198 @@
+// Package httputil provides the JSON response envelope shared by HTTP
+// services and the mapping of errors to HTTP statuses and error codes.
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Response is the envelope of every JSON response. Successful responses carry
+// Data and, for lists, Meta; failed responses carry Error.
+type Response struct {
+	Success bool       `json:"success"`
+	Data    any        `json:"data,omitempty"`
+	Error   *ErrorBody `json:"error,omitempty"`
+	Meta    *Meta      `json:"meta,omitempty"`
+}
+
+// ErrorBody is the machine-readable code and the message of a failed request.
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"` // E.g. the message of each invalid field
+}
+
+// Meta carries information about the response besides its data.
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a list response.
+type Pagination struct {
+	Total       int    `json:"total"`
+	Page        int    `json:"page"`
+	PageSize    int    `json:"page_size"`
+	TotalPages  int    `json:"total_pages"`
+	HasNext     bool   `json:"has_next"`
+	HasPrevious bool   `json:"has_previous"`
+	NextCursor  string `json:"next_cursor,omitempty"` // Opaque token of the next page, set for cursor pagination
+}
+
+// NewPagination describes page (1-based) of pageSize items out of total.
+func NewPagination(total, page, pageSize int) *Pagination {
+	p := &Pagination{Total: total, Page: page, PageSize: pageSize, HasPrevious: page > 1}
+	if pageSize > 0 {
+		p.TotalPages = (total + pageSize - 1) / pageSize
+	}
+	p.HasNext = page < p.TotalPages
+	return p
+}
+
+// Success returns the envelope of a successful response. meta may be nil.
+func Success(data any, meta *Meta) Response {
+	return Response{Success: true, Data: data, Meta: meta}
+}
+
+// ErrorResponse returns the envelope of a failed response.
+func ErrorResponse(code, message string) Response {
+	return Response{Error: &ErrorBody{Code: code, Message: message}}
+}
+
+// HTTPError is an error reported to the client with an HTTP status and an
+// error code. It satisfies huma.StatusError.
+type HTTPError struct {
+	Status  int
+	Code    string
+	Message string
+	Details any   // Sent as ErrorBody.Details
+	Err     error // Underlying error; not sent to the client
+}
+
+// NewHTTPError returns an HTTPError without an underlying error.
+func NewHTTPError(status int, code, message string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+// Unwrap returns the underlying error for errors.Is and errors.As.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// GetStatus returns the HTTP status.
+func (e *HTTPError) GetStatus() int {
+	return e.Status
+}
+
+// Response returns the envelope reporting e.
+func (e *HTTPError) Response() Response {
+	resp := ErrorResponse(e.Code, e.Message)
+	resp.Error.Details = e.Details
+	return resp
+}
+
+// statusError is implemented by errors that know their HTTP status, such as
+// the errors generated by errorgen and huma.StatusError.
+type statusError interface {
+	error
+	GetStatus() int
+}
+
+// catalogError is implemented by errors that carry an error code and a
+// message, such as the errors generated by errorgen.
+type catalogError interface {
+	GetCode() string
+	GetMessage() string
+}
+
+// publicError is implemented by catalog errors that tell whether their
+// message may be shown to clients, such as the errors generated by errorgen.
+type publicError interface {
+	GetPublic() bool
+}
+
+// AsHTTPError finds the HTTPError in err's chain, or builds one from the first
+// error that knows its HTTP status. Errors from an error catalog keep their
+// code, and their message when they report that it is public; for others the
+// code is the status text in upper snake case ("NOT_FOUND"). The message is
+// otherwise the status text, so wrapped internal details never reach the
+// client. It returns false when err carries no status; the caller should
+// then report an internal error without leaking err.
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+
+	var se statusError
+	if !errors.As(err, &se) || se.GetStatus() == 0 {
+		return nil, false
+	}
+	status := se.GetStatus()
+	httpErr = &HTTPError{Status: status, Code: statusCode(status), Message: statusMessage(status), Err: err}
+	if ce, ok := se.(catalogError); ok && ce.GetCode() != "" {
+		httpErr.Code = ce.GetCode()
+		if pe, ok := se.(publicError); ok && pe.GetPublic() {
+			httpErr.Message = ce.GetMessage()
+		}
+	}
+	return httpErr, true
+}
+
+// InternalError is reported for errors AsHTTPError does not recognize.
+var InternalError = NewHTTPError(http.StatusInternalServerError, "INTERNAL_ERROR", "an internal error occurred")
+
+// WriteJSON writes v as JSON with status.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// WriteSuccess writes the Success envelope of data with status.
+func WriteSuccess(w http.ResponseWriter, status int, data any, meta *Meta) {
+	WriteJSON(w, status, Success(data, meta))
+}
+
+// WriteError writes the ErrorResponse envelope of e with its status.
+func WriteError(w http.ResponseWriter, e *HTTPError) {
+	WriteJSON(w, e.Status, e.Response())
+}
+
+// statusMessage returns the message reported for status when the error
+// carries none fit for clients: its status text, e.g. "Not Found".
+func statusMessage(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return "Error"
+}
+
+// statusCode turns a status into an error code: 404 -> "NOT_FOUND".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+	code := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c >= 'a' && c <= 'z':
+			code = append(code, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			code = append(code, c)
+		case c == ' ' || c == '-':
+			code = append(code, '_')
+		}
+	}
+	return string(code)
+}
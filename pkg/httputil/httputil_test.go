@@ -0,0 +1,110 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorgenError mimics an error generated by errorgen.
+type errorgenError struct {
+	code   string
+	status int
+	public bool
+}
+
+func (e *errorgenError) Error() string      { return e.code + ": something failed: db password rejected" }
+func (e *errorgenError) GetStatus() int     { return e.status }
+func (e *errorgenError) GetCode() string    { return e.code }
+func (e *errorgenError) GetMessage() string { return "something failed" }
+func (e *errorgenError) GetPublic() bool    { return e.public }
+
+type statusOnlyError struct{}
+
+func (statusOnlyError) Error() string  { return "gone" }
+func (statusOnlyError) GetStatus() int { return http.StatusGone }
+
+func TestAsHTTPError(t *testing.T) {
+	notFound := NewHTTPError(http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+	got, ok := AsHTTPError(fmt.Errorf("get order: %w", notFound))
+	require.True(t, ok)
+	assert.Same(t, notFound, got)
+
+	catalog := &errorgenError{code: "ORDER_ALREADY_EXISTS", status: http.StatusConflict, public: true}
+	got, ok = AsHTTPError(fmt.Errorf("create: %w", catalog))
+	require.True(t, ok)
+	assert.Equal(t, http.StatusConflict, got.Status)
+	assert.Equal(t, "ORDER_ALREADY_EXISTS", got.Code)
+	assert.Equal(t, "something failed", got.Message)
+	assert.ErrorIs(t, got, catalog)
+
+	internal := &errorgenError{code: "LEDGER_CORRUPT", status: http.StatusInternalServerError}
+	got, ok = AsHTTPError(fmt.Errorf("settle: %w", internal))
+	require.True(t, ok)
+	assert.Equal(t, "LEDGER_CORRUPT", got.Code)
+	assert.Equal(t, "Internal Server Error", got.Message, "the message of a non-public catalog error is not reported")
+
+	got, ok = AsHTTPError(fmt.Errorf("fetch archive: %w", statusOnlyError{}))
+	require.True(t, ok)
+	assert.Equal(t, "GONE", got.Code)
+	assert.Equal(t, "Gone", got.Message, "the error text, wrapping included, is not reported")
+
+	_, ok = AsHTTPError(errors.New("connection refused"))
+	assert.False(t, ok)
+	_, ok = AsHTTPError(&errorgenError{code: "UNSET"})
+	assert.False(t, ok, "a zero status is not an HTTP status")
+}
+
+func TestStatusCode(t *testing.T) {
+	assert.Equal(t, "NOT_FOUND", statusCode(http.StatusNotFound))
+	assert.Equal(t, "UNPROCESSABLE_ENTITY", statusCode(http.StatusUnprocessableEntity))
+	assert.Equal(t, "NON_AUTHORITATIVE_INFORMATION", statusCode(http.StatusNonAuthoritativeInfo))
+	assert.Equal(t, "ERROR", statusCode(599))
+}
+
+func TestWriteSuccess(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteSuccess(rec, http.StatusOK, []string{"a", "b"}, &Meta{Pagination: NewPagination(45, 2, 20)})
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, true, body["success"])
+	assert.Equal(t, []any{"a", "b"}, body["data"])
+	assert.Equal(t, map[string]any{"pagination": map[string]any{
+		"total": 45.0, "page": 2.0, "page_size": 20.0, "total_pages": 3.0, "has_next": true, "has_previous": true,
+	}}, body["meta"])
+	assert.NotContains(t, body, "error")
+}
+
+func TestPagination_nextCursor(t *testing.T) {
+	p := NewPagination(0, 1, 20)
+	raw, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "next_cursor")
+
+	p.NextCursor = "eyJrIjo0MiwiZCI6ImYifQ"
+	raw, err = json.Marshal(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"next_cursor":"eyJrIjo0MiwiZCI6ImYifQ"`)
+}
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, InternalError)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"code":"INTERNAL_ERROR","message":"an internal error occurred"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	invalid := NewHTTPError(http.StatusUnprocessableEntity, "VALIDATION_FAILED", "name: cannot be blank.")
+	invalid.Details = map[string]string{"name": "cannot be blank"}
+	WriteError(rec, invalid)
+	assert.JSONEq(t, `{"success":false,"error":{"code":"VALIDATION_FAILED","message":"name: cannot be blank.","details":{"name":"cannot be blank"}}}`, rec.Body.String())
+}
@@ -0,0 +1,91 @@
+package nullable
+
+import "github.com/oapi-codegen/nullable"
+
+// Slice and map conversions
+
+// ToNullableSlice converts a []T to nullable.Nullable[[]T].
+// Returns an unspecified Nullable if the slice is nil; an empty slice is a
+// specified empty array.
+//
+// Example:
+//
+//	n := ToNullableSlice([]string{"go"}) // Specified with value ["go"]
+//	n = ToNullableSlice([]string{})      // Specified with value []
+//	n = ToNullableSlice[string](nil)     // Unspecified
+func ToNullableSlice[T any](s []T) nullable.Nullable[[]T] {
+	if s == nil {
+		return nullable.Nullable[[]T]{}
+	}
+	return nullable.NewNullableWithValue(s)
+}
+
+// FromNullableSlice converts nullable.Nullable[[]T] to []T.
+// Returns nil if the Nullable is unspecified or null.
+func FromNullableSlice[T any](n nullable.Nullable[[]T]) []T {
+	return ValueOr(n, nil)
+}
+
+// MapSpecified converts the value of n with f, keeping an unspecified or
+// null Nullable as it is.
+//
+// Example:
+//
+//	// Nullable[[]AddressRequest] to Nullable[[]Address]
+//	addrs := MapSpecified(req.Addresses, toAddresses)
+func MapSpecified[T, U any](n nullable.Nullable[T], f func(T) U) nullable.Nullable[U] {
+	switch {
+	case !n.IsSpecified():
+		return nullable.Nullable[U]{}
+	case n.IsNull():
+		return SetNull[U]()
+	default:
+		return nullable.NewNullableWithValue(f(n.MustGet()))
+	}
+}
+
+// ToNullables converts []*T to []nullable.Nullable[T], e.g. for a JSON array
+// with null elements. Nil elements are null, since array elements cannot be
+// absent. A nil slice stays nil.
+func ToNullables[T any](s []*T) []nullable.Nullable[T] {
+	if s == nil {
+		return nil
+	}
+	out := make([]nullable.Nullable[T], len(s))
+	for i, v := range s {
+		if v == nil {
+			out[i] = SetNull[T]()
+		} else {
+			out[i] = nullable.NewNullableWithValue(*v)
+		}
+	}
+	return out
+}
+
+// FromNullables converts []nullable.Nullable[T] to []*T. Null and unspecified
+// elements are nil. A nil slice stays nil.
+func FromNullables[T any](s []nullable.Nullable[T]) []*T {
+	if s == nil {
+		return nil
+	}
+	out := make([]*T, len(s))
+	for i, n := range s {
+		out[i] = FromNullable(n)
+	}
+	return out
+}
+
+// ToNullableMap converts a map[K]V to nullable.Nullable[map[K]V].
+// Returns an unspecified Nullable if the map is nil.
+func ToNullableMap[K comparable, V any](m map[K]V) nullable.Nullable[map[K]V] {
+	if m == nil {
+		return nullable.Nullable[map[K]V]{}
+	}
+	return nullable.NewNullableWithValue(m)
+}
+
+// FromNullableMap converts nullable.Nullable[map[K]V] to map[K]V.
+// Returns nil if the Nullable is unspecified or null.
+func FromNullableMap[K comparable, V any](n nullable.Nullable[map[K]V]) map[K]V {
+	return ValueOr(n, nil)
+}
@@ -0,0 +1,56 @@
+package nullable
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullableSlice(t *testing.T) {
+	assert.Equal(t, []string{"go"}, ToNullableSlice([]string{"go"}).MustGet())
+	assert.Equal(t, []string{}, ToNullableSlice([]string{}).MustGet())
+	assert.False(t, ToNullableSlice[string](nil).IsSpecified())
+
+	assert.Equal(t, []int{1}, FromNullableSlice(nullable.NewNullableWithValue([]int{1})))
+	assert.Nil(t, FromNullableSlice(SetNull[[]int]()))
+	assert.Nil(t, FromNullableSlice(nullable.Nullable[[]int]{}))
+}
+
+func TestNullableMap(t *testing.T) {
+	assert.Equal(t, map[string]int{"a": 1}, ToNullableMap(map[string]int{"a": 1}).MustGet())
+	assert.False(t, ToNullableMap[string, int](nil).IsSpecified())
+
+	assert.Equal(t, map[string]int{"a": 1}, FromNullableMap(nullable.NewNullableWithValue(map[string]int{"a": 1})))
+	assert.Nil(t, FromNullableMap(SetNull[map[string]int]()))
+}
+
+func TestMapSpecified(t *testing.T) {
+	itoa := strconv.Itoa
+
+	assert.Equal(t, "42", MapSpecified(nullable.NewNullableWithValue(42), itoa).MustGet())
+	assert.True(t, MapSpecified(SetNull[int](), itoa).IsNull())
+	assert.False(t, MapSpecified(nullable.Nullable[int]{}, itoa).IsSpecified())
+}
+
+func TestNullables(t *testing.T) {
+	s := ToNullables([]*string{Ptr("a"), nil})
+	require.Len(t, s, 2)
+	assert.Equal(t, "a", s[0].MustGet())
+	assert.True(t, s[1].IsNull())
+	assert.Nil(t, ToNullables[string](nil))
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a", null]`, string(data))
+
+	ptrs := FromNullables([]nullable.Nullable[string]{nullable.NewNullableWithValue("a"), SetNull[string](), {}})
+	require.Len(t, ptrs, 3)
+	assert.Equal(t, "a", *ptrs[0])
+	assert.Nil(t, ptrs[1])
+	assert.Nil(t, ptrs[2])
+	assert.Nil(t, FromNullables[string](nil))
+}
@@ -0,0 +1,81 @@
+package nullable
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/oapi-codegen/nullable"
+)
+
+// Database conversions
+//
+// These functions convert between nullable.Nullable[T] and the nullable
+// types of database/sql without an intermediate pointer. A NULL column
+// becomes an explicit null. Converting back reports whether the Nullable
+// was specified, so PATCH updates can keep unspecified columns:
+//
+//	// UPDATE users SET nickname = CASE WHEN $2 THEN $1 ELSE nickname END
+//	nickname, set := NullStringFromNullable(req.Nickname)
+//	_, err := db.ExecContext(ctx, query, nickname, set)
+//
+// The conversions for the pgtype types, which used to live here, are in
+// pgxutil: pgxutil.TextFromNullable and pgxutil.NullableFromText,
+// Int4FromNullable, Int8FromNullable, BoolFromNullable, Float8FromNullable,
+// TimestamptzFromNullable, UUIDFromNullable, NumericFromNullable and their
+// NullableFrom counterparts.
+
+// nullableFromValid returns value as a Nullable, or an explicit null when
+// it is not valid.
+func nullableFromValid[T any](valid bool, value T) nullable.Nullable[T] {
+	if !valid {
+		return SetNull[T]()
+	}
+	return nullable.NewNullableWithValue(value)
+}
+
+// validFromNullable returns the value of n, whether it is a value rather
+// than null, and whether it is specified.
+func validFromNullable[T any](n nullable.Nullable[T]) (value T, valid, set bool) {
+	value, err := n.Get()
+	return value, err == nil, n.IsSpecified()
+}
+
+// NullableFromNullString converts sql.NullString to nullable.Nullable[string].
+// Returns an explicit null for NULL.
+func NullableFromNullString(s sql.NullString) nullable.Nullable[string] {
+	return nullableFromValid(s.Valid, s.String)
+}
+
+// NullStringFromNullable converts nullable.Nullable[string] to sql.NullString,
+// reporting whether the Nullable was specified. Null and unspecified are both
+// NULL.
+func NullStringFromNullable(n nullable.Nullable[string]) (sql.NullString, bool) {
+	v, valid, set := validFromNullable(n)
+	return sql.NullString{String: v, Valid: valid}, set
+}
+
+// NullableFromNullInt64 converts sql.NullInt64 to nullable.Nullable[int64].
+// Returns an explicit null for NULL.
+func NullableFromNullInt64(i sql.NullInt64) nullable.Nullable[int64] {
+	return nullableFromValid(i.Valid, i.Int64)
+}
+
+// NullInt64FromNullable converts nullable.Nullable[int64] to sql.NullInt64,
+// reporting whether the Nullable was specified.
+func NullInt64FromNullable(n nullable.Nullable[int64]) (sql.NullInt64, bool) {
+	v, valid, set := validFromNullable(n)
+	return sql.NullInt64{Int64: v, Valid: valid}, set
+}
+
+// NullableFromNullTime converts sql.NullTime to nullable.Nullable[time.Time].
+// Returns an explicit null for NULL.
+func NullableFromNullTime(t sql.NullTime) nullable.Nullable[time.Time] {
+	return nullableFromValid(t.Valid, t.Time)
+}
+
+// NullTimeFromNullable converts nullable.Nullable[time.Time] to sql.NullTime,
+// reporting whether the Nullable was specified.
+func NullTimeFromNullable(n nullable.Nullable[time.Time]) (sql.NullTime, bool) {
+	v, valid, set := validFromNullable(n)
+	return sql.NullTime{Time: v, Valid: valid}, set
+}
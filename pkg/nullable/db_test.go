@@ -0,0 +1,48 @@
+package nullable
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLConversions(t *testing.T) {
+	t.Run("NullString", func(t *testing.T) {
+		assert.Equal(t, "a", NullableFromNullString(sql.NullString{String: "a", Valid: true}).MustGet())
+		assert.True(t, NullableFromNullString(sql.NullString{}).IsNull())
+
+		s, set := NullStringFromNullable(nullable.NewNullableWithValue("a"))
+		assert.True(t, set)
+		assert.Equal(t, sql.NullString{String: "a", Valid: true}, s)
+
+		s, set = NullStringFromNullable(SetNull[string]())
+		assert.True(t, set)
+		assert.False(t, s.Valid)
+
+		s, set = NullStringFromNullable(nullable.Nullable[string]{})
+		assert.False(t, set)
+		assert.False(t, s.Valid)
+	})
+
+	t.Run("NullInt64", func(t *testing.T) {
+		assert.Equal(t, int64(0), NullableFromNullInt64(sql.NullInt64{Int64: 0, Valid: true}).MustGet())
+		assert.True(t, NullableFromNullInt64(sql.NullInt64{}).IsNull())
+
+		i, set := NullInt64FromNullable(nullable.NewNullableWithValue[int64](5))
+		assert.True(t, set)
+		assert.Equal(t, sql.NullInt64{Int64: 5, Valid: true}, i)
+	})
+
+	t.Run("NullTime", func(t *testing.T) {
+		now := time.Now()
+		assert.Equal(t, now, NullableFromNullTime(sql.NullTime{Time: now, Valid: true}).MustGet())
+		assert.True(t, NullableFromNullTime(sql.NullTime{}).IsNull())
+
+		tm, set := NullTimeFromNullable(SetNull[time.Time]())
+		assert.True(t, set)
+		assert.False(t, tm.Valid)
+	})
+}
@@ -0,0 +1,126 @@
+package nullable
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSON merge patch and JSON patch
+//
+// A patch struct, e.g. a decoded PATCH request body, tells unspecified
+// fields from null ones. MergePatch and JSONPatch encode it back for
+// proxying the request downstream, with the same field rules as
+// ApplyPatch, and ParseMergePatch decodes a merge patch into one.
+
+// Operations of a JSON patch.
+const (
+	OpAdd    = "add"
+	OpRemove = "remove"
+)
+
+// PatchOperation is an operation of an RFC 6902 JSON patch.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch encodes the patch struct as an RFC 7386 JSON merge patch: an
+// object with a member for every specified field, null for the null ones.
+// Unspecified Nullable fields and nil pointers are omitted, as are fields
+// of other types. Members are named by their JSON names, honoring json
+// tags, and those of embedded structs are inlined.
+//
+// Example:
+//
+//	req := UpdateUserRequest{
+//	    Nickname: nullable.NewNullNullable[string](),
+//	    Email:    Ptr("ann@example.com"),
+//	}
+//	doc, err := MergePatch(req) // {"email":"ann@example.com","nickname":null}
+func MergePatch(patch any) ([]byte, error) {
+	pv, err := patchStruct("MergePatch", patch)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]json.RawMessage)
+	err = walkPatch(pv, func(name string, _, value reflect.Value) error {
+		raw, err := marshalPatchValue(value)
+		if err != nil {
+			return fmt.Errorf("nullable: MergePatch field %q: %w", name, err)
+		}
+		members[name] = raw
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// JSONPatch encodes the patch struct as the operations of an RFC 6902 JSON
+// patch, in field order: an add of the value of every specified field, which
+// creates or replaces the member, and a remove of every null one, mirroring
+// a merge patch. Fields are selected and named as by MergePatch.
+//
+// A remove fails on a target without the member, so a downstream API that
+// keeps null members may need a replace with null instead.
+func JSONPatch(patch any) ([]PatchOperation, error) {
+	pv, err := patchStruct("JSONPatch", patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []PatchOperation
+	err = walkPatch(pv, func(name string, _, value reflect.Value) error {
+		path := "/" + escapePointer(name)
+		if !value.IsValid() {
+			ops = append(ops, PatchOperation{Op: OpRemove, Path: path})
+			return nil
+		}
+		raw, err := marshalPatchValue(value)
+		if err != nil {
+			return fmt.Errorf("nullable: JSONPatch field %q: %w", name, err)
+		}
+		ops = append(ops, PatchOperation{Op: OpAdd, Path: path, Value: raw})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// ParseMergePatch decodes the RFC 7386 JSON merge patch data into the patch
+// struct v points to: members set their Nullable fields, null members set
+// them null, and absent members leave them unspecified. Pointer fields
+// cannot tell null from absent and stay nil for both. It returns an error
+// when data is not a JSON object.
+func ParseMergePatch(data []byte, v any) error {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return fmt.Errorf("nullable: ParseMergePatch: want a JSON object")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("nullable: ParseMergePatch: %w", err)
+	}
+	return nil
+}
+
+// marshalPatchValue encodes value, or null when it is invalid.
+func marshalPatchValue(value reflect.Value) (json.RawMessage, error) {
+	if !value.IsValid() {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(value.Interface())
+}
+
+// escapePointer escapes name as a reference token of an RFC 6901 JSON
+// pointer.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,101 @@
+package nullable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mergePatchMeta struct {
+	Labels nullable.Nullable[map[string]string] `json:"labels"`
+}
+
+type mergePatchRequest struct {
+	mergePatchMeta
+	Name     nullable.Nullable[string] `json:"name"`
+	Nickname nullable.Nullable[string] `json:"nickname"`
+	Email    *string                   `json:"email"`
+	Age      nullable.Nullable[int]    `json:"age"`
+	Path     nullable.Nullable[string] `json:"a/b~c"`
+	Version  int                       `json:"version"`
+}
+
+func TestMergePatch(t *testing.T) {
+	req := mergePatchRequest{
+		Name:     nullable.NewNullableWithValue("Ann"),
+		Nickname: nullable.NewNullNullable[string](),
+		Email:    Ptr("ann@example.com"),
+		Version:  3,
+	}
+	req.Labels = nullable.NewNullableWithValue(map[string]string{"team": "core"})
+
+	doc, err := MergePatch(&req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Ann","nickname":null,"email":"ann@example.com","labels":{"team":"core"}}`, string(doc))
+
+	doc, err = MergePatch(mergePatchRequest{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(doc))
+
+	_, err = MergePatch("patch")
+	require.Error(t, err)
+}
+
+func TestParseMergePatch(t *testing.T) {
+	var req mergePatchRequest
+	require.NoError(t, ParseMergePatch([]byte(` {"name":"Ann","nickname":null,"email":null,"labels":{"team":"core"}}`), &req))
+
+	assert.Equal(t, "Ann", req.Name.MustGet())
+	assert.True(t, req.Nickname.IsNull())
+	assert.Nil(t, req.Email)
+	assert.False(t, req.Age.IsSpecified())
+	assert.Equal(t, map[string]string{"team": "core"}, req.Labels.MustGet())
+
+	t.Run("round trip", func(t *testing.T) {
+		doc, err := MergePatch(req)
+		require.NoError(t, err)
+
+		var parsed mergePatchRequest
+		require.NoError(t, ParseMergePatch(doc, &parsed))
+		assert.Equal(t, req, parsed)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		for _, data := range []string{``, `null`, `[]`, `"name"`} {
+			require.Error(t, ParseMergePatch([]byte(data), &req), data)
+		}
+		require.Error(t, ParseMergePatch([]byte(`{"age":"x"}`), &req))
+	})
+}
+
+func TestJSONPatch(t *testing.T) {
+	req := mergePatchRequest{
+		Name:     nullable.NewNullableWithValue("Ann"),
+		Nickname: nullable.NewNullNullable[string](),
+		Age:      nullable.NewNullableWithValue(30),
+		Path:     nullable.NewNullableWithValue("x"),
+	}
+
+	ops, err := JSONPatch(req)
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOperation{
+		{Op: OpAdd, Path: "/name", Value: json.RawMessage(`"Ann"`)},
+		{Op: OpRemove, Path: "/nickname"},
+		{Op: OpAdd, Path: "/age", Value: json.RawMessage(`30`)},
+		{Op: OpAdd, Path: "/a~1b~0c", Value: json.RawMessage(`"x"`)},
+	}, ops)
+
+	doc, err := json.Marshal(ops[:2])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op":"add","path":"/name","value":"Ann"},{"op":"remove","path":"/nickname"}]`, string(doc))
+
+	ops, err = JSONPatch(mergePatchRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+
+	_, err = JSONPatch(42)
+	require.Error(t, err)
+}
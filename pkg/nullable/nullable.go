@@ -25,39 +25,37 @@
 package nullable
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/oapi-codegen/nullable"
 	"github.com/shopspring/decimal"
 )
 
+// ErrOverflow is returned when a value does not fit in the target type of a
+// numeric conversion.
+var ErrOverflow = errors.New("nullable: numeric overflow")
+
 // Decimal conversions
 
 // ToNullableDecimal converts a *decimal.Decimal to nullable.Nullable[decimal.Decimal].
 // Returns an unspecified Nullable if the pointer is nil.
 func ToNullableDecimal(d *decimal.Decimal) nullable.Nullable[decimal.Decimal] {
-	if d == nil {
-		return nullable.Nullable[decimal.Decimal]{}
-	}
-	return nullable.NewNullableWithValue(*d)
+	return ToNullable(d)
 }
 
 // FromNullableDecimal converts nullable.Nullable[decimal.Decimal] to *decimal.Decimal.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 func FromNullableDecimal(n nullable.Nullable[decimal.Decimal]) *decimal.Decimal {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// DecimalValue returns the decimal.Decimal value or the provided default if unspecified.
+// DecimalValue returns the decimal.Decimal value or the provided default if unspecified or null.
 func DecimalValue(n nullable.Nullable[decimal.Decimal], defaultVal decimal.Decimal) decimal.Decimal {
-	if !n.IsSpecified() {
-		return defaultVal
-	}
-	return n.MustGet()
+	return ValueOr(n, defaultVal)
 }
 
 // String conversions
@@ -71,41 +69,31 @@ func DecimalValue(n nullable.Nullable[decimal.Decimal], defaultVal decimal.Decim
 //	n := ToNullableString(&name) // Specified with value "Alice"
 //	n = ToNullableString(nil)    // Unspecified
 func ToNullableString(s *string) nullable.Nullable[string] {
-	if s == nil {
-		return nullable.Nullable[string]{}
-	}
-	return nullable.NewNullableWithValue(*s)
+	return ToNullable(s)
 }
 
 // FromNullableString converts nullable.Nullable[string] to *string.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 //
 // Example:
 //
 //	n := nullable.NewNullableWithValue("Bob")
 //	s := FromNullableString(n) // *string pointing to "Bob"
 func FromNullableString(n nullable.Nullable[string]) *string {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// StringValue returns the string value or the provided default if unspecified.
+// StringValue returns the string value or the provided default if unspecified or null.
 //
 // Example:
 //
 //	n := nullable.Nullable[string]{}
 //	s := StringValue(n, "default") // Returns "default"
 func StringValue(n nullable.Nullable[string], defaultVal string) string {
-	if !n.IsSpecified() {
-		return defaultVal
-	}
-	return n.MustGet()
+	return ValueOr(n, defaultVal)
 }
 
-// StringOrEmpty returns the string value or empty string if unspecified.
+// StringOrEmpty returns the string value or empty string if unspecified or null.
 //
 // Example:
 //
@@ -126,10 +114,7 @@ func StringOrEmpty(n nullable.Nullable[string]) string {
 //	n := ToNullableInt(&age) // Specified with value 25
 //	n = ToNullableInt(nil)   // Unspecified
 func ToNullableInt(i *int) nullable.Nullable[int] {
-	if i == nil {
-		return nullable.Nullable[int]{}
-	}
-	return nullable.NewNullableWithValue(*i)
+	return ToNullable(i)
 }
 
 // Boolean conversions
@@ -143,119 +128,122 @@ func ToNullableInt(i *int) nullable.Nullable[int] {
 //	n := ToNullableBool(&active) // Specified with value true
 //	n = ToNullableBool(nil)      // Unspecified
 func ToNullableBool(b *bool) nullable.Nullable[bool] {
-	if b == nil {
-		return nullable.Nullable[bool]{}
-	}
-	return nullable.NewNullableWithValue(*b)
+	return ToNullable(b)
 }
 
 // FromNullableBool converts nullable.Nullable[bool] to *bool.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 //
 // Example:
 //
 //	n := nullable.NewNullableWithValue(false)
 //	b := FromNullableBool(n) // *bool pointing to false
 func FromNullableBool(n nullable.Nullable[bool]) *bool {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// BoolValue returns the bool value or the provided default if unspecified.
+// BoolValue returns the bool value or the provided default if unspecified or null.
 //
 // Example:
 //
 //	n := nullable.Nullable[bool]{}
 //	b := BoolValue(n, false) // Returns false
 func BoolValue(n nullable.Nullable[bool], defaultVal bool) bool {
-	if !n.IsSpecified() {
-		return defaultVal
-	}
-	return n.MustGet()
+	return ValueOr(n, defaultVal)
 }
 
 // FromNullableInt converts nullable.Nullable[int] to *int.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 //
 // Example:
 //
 //	n := nullable.NewNullableWithValue(42)
 //	i := FromNullableInt(n) // *int pointing to 42
 func FromNullableInt(n nullable.Nullable[int]) *int {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// IntValue returns the int value or the provided default if unspecified.
+// IntValue returns the int value or the provided default if unspecified or null.
 //
 // Example:
 //
 //	n := nullable.Nullable[int]{}
 //	i := IntValue(n, 0) // Returns 0
 func IntValue(n nullable.Nullable[int], defaultVal int) int {
-	if !n.IsSpecified() {
-		return defaultVal
-	}
-	return n.MustGet()
+	return ValueOr(n, defaultVal)
+}
+
+// IntOrZero returns the int value or 0 if unspecified or null.
+func IntOrZero(n nullable.Nullable[int]) int {
+	return IntValue(n, 0)
 }
 
 // ToNullableInt32 converts a *int32 to nullable.Nullable[int32].
 func ToNullableInt32(i *int32) nullable.Nullable[int32] {
-	if i == nil {
-		return nullable.Nullable[int32]{}
-	}
-	return nullable.NewNullableWithValue(*i)
+	return ToNullable(i)
 }
 
 // FromNullableInt32 converts nullable.Nullable[int32] to *int32.
 func FromNullableInt32(n nullable.Nullable[int32]) *int32 {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
+}
+
+// Int32Value returns the int32 value or the provided default if unspecified or null.
+func Int32Value(n nullable.Nullable[int32], defaultVal int32) int32 {
+	return ValueOr(n, defaultVal)
 }
 
 // ToNullableInt64 converts a *int64 to nullable.Nullable[int64].
 func ToNullableInt64(i *int64) nullable.Nullable[int64] {
-	if i == nil {
-		return nullable.Nullable[int64]{}
-	}
-	return nullable.NewNullableWithValue(*i)
+	return ToNullable(i)
 }
 
 // FromNullableInt64 converts nullable.Nullable[int64] to *int64.
 func FromNullableInt64(n nullable.Nullable[int64]) *int64 {
-	if !n.IsSpecified() {
-		return nil
+	return FromNullable(n)
+}
+
+// Int64Value returns the int64 value or the provided default if unspecified or null.
+func Int64Value(n nullable.Nullable[int64], defaultVal int64) int64 {
+	return ValueOr(n, defaultVal)
+}
+
+// Int64FromInt32 converts nullable.Nullable[int32] to nullable.Nullable[int64],
+// keeping an unspecified or null Nullable as it is.
+func Int64FromInt32(n nullable.Nullable[int32]) nullable.Nullable[int64] {
+	return MapSpecified(n, func(v int32) int64 { return int64(v) })
+}
+
+// Int32FromInt64 converts nullable.Nullable[int64] to nullable.Nullable[int32],
+// keeping an unspecified or null Nullable as it is. Returns an error wrapping
+// ErrOverflow if the value does not fit in an int32.
+//
+// Example:
+//
+//	n, err := Int32FromInt64(nullable.NewNullableWithValue[int64](42)) // Specified with value 42
+//	_, err = Int32FromInt64(nullable.NewNullableWithValue[int64](math.MaxInt64)) // ErrOverflow
+func Int32FromInt64(n nullable.Nullable[int64]) (nullable.Nullable[int32], error) {
+	if v, err := n.Get(); err == nil && (v < math.MinInt32 || v > math.MaxInt32) {
+		return nullable.Nullable[int32]{}, fmt.Errorf("%w: %d does not fit in int32", ErrOverflow, v)
 	}
-	val := n.MustGet()
-	return &val
+	return MapSpecified(n, func(v int64) int32 { return int32(v) }), nil
 }
 
 // Float conversions
 
 // ToNullableFloat32 converts a *float32 to nullable.Nullable[float32].
 func ToNullableFloat32(f *float32) nullable.Nullable[float32] {
-	if f == nil {
-		return nullable.Nullable[float32]{}
-	}
-	return nullable.NewNullableWithValue(*f)
+	return ToNullable(f)
 }
 
 // FromNullableFloat32 converts nullable.Nullable[float32] to *float32.
 func FromNullableFloat32(n nullable.Nullable[float32]) *float32 {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
+}
+
+// Float32Value returns the float32 value or the provided default if unspecified or null.
+func Float32Value(n nullable.Nullable[float32], defaultVal float32) float32 {
+	return ValueOr(n, defaultVal)
 }
 
 // ToNullableFloat64 converts a *float64 to nullable.Nullable[float64].
@@ -267,38 +255,28 @@ func FromNullableFloat32(n nullable.Nullable[float32]) *float32 {
 //	n := ToNullableFloat64(&price) // Specified with value 99.99
 //	n = ToNullableFloat64(nil)     // Unspecified
 func ToNullableFloat64(f *float64) nullable.Nullable[float64] {
-	if f == nil {
-		return nullable.Nullable[float64]{}
-	}
-	return nullable.NewNullableWithValue(*f)
+	return ToNullable(f)
 }
 
 // FromNullableFloat64 converts nullable.Nullable[float64] to *float64.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 //
 // Example:
 //
 //	n := nullable.NewNullableWithValue(3.14)
 //	f := FromNullableFloat64(n) // *float64 pointing to 3.14
 func FromNullableFloat64(n nullable.Nullable[float64]) *float64 {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// Float64Value returns the float64 value or the provided default if unspecified.
+// Float64Value returns the float64 value or the provided default if unspecified or null.
 //
 // Example:
 //
 //	n := nullable.Nullable[float64]{}
 //	f := Float64Value(n, 0.0) // Returns 0.0
 func Float64Value(n nullable.Nullable[float64], defaultVal float64) float64 {
-	if !n.IsSpecified() {
-		return defaultVal
-	}
-	return n.MustGet()
+	return ValueOr(n, defaultVal)
 }
 
 // Time conversions
@@ -312,38 +290,102 @@ func Float64Value(n nullable.Nullable[float64], defaultVal float64) float64 {
 //	n := ToNullableTime(&now) // Specified with current time
 //	n = ToNullableTime(nil)   // Unspecified
 func ToNullableTime(t *time.Time) nullable.Nullable[time.Time] {
-	if t == nil {
-		return nullable.Nullable[time.Time]{}
-	}
-	return nullable.NewNullableWithValue(*t)
+	return ToNullable(t)
 }
 
 // FromNullableTime converts nullable.Nullable[time.Time] to *time.Time.
-// Returns nil if the Nullable is unspecified.
+// Returns nil if the Nullable is unspecified or null.
 //
 // Example:
 //
 //	n := nullable.NewNullableWithValue(time.Now())
 //	t := FromNullableTime(n) // *time.Time
 func FromNullableTime(n nullable.Nullable[time.Time]) *time.Time {
-	if !n.IsSpecified() {
-		return nil
-	}
-	val := n.MustGet()
-	return &val
+	return FromNullable(n)
 }
 
-// TimeValue returns the time.Time value or the provided default if unspecified.
+// TimeValue returns the time.Time value or the provided default if unspecified or null.
 //
 // Example:
 //
 //	n := nullable.Nullable[time.Time]{}
 //	t := TimeValue(n, time.Now()) // Returns current time
 func TimeValue(n nullable.Nullable[time.Time], defaultVal time.Time) time.Time {
-	if !n.IsSpecified() {
-		return defaultVal
+	return ValueOr(n, defaultVal)
+}
+
+// FormatTime formats the time.Time value of n with layout, keeping an
+// unspecified or null Nullable as it is.
+//
+// Example:
+//
+//	n := nullable.NewNullableWithValue(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+//	s := FormatTime(n, time.DateOnly) // Specified with value "2024-05-01"
+func FormatTime(n nullable.Nullable[time.Time], layout string) nullable.Nullable[string] {
+	return MapSpecified(n, func(t time.Time) string { return t.Format(layout) })
+}
+
+// ParseTime parses the string value of n with layout, keeping an unspecified
+// or null Nullable as it is. Returns the error of time.Parse if the value
+// does not match layout.
+//
+// Example:
+//
+//	t, err := ParseTime(req.DueDate, time.DateOnly)
+func ParseTime(n nullable.Nullable[string], layout string) (nullable.Nullable[time.Time], error) {
+	switch {
+	case !n.IsSpecified():
+		return nullable.Nullable[time.Time]{}, nil
+	case n.IsNull():
+		return SetNull[time.Time](), nil
+	}
+	t, err := time.Parse(layout, n.MustGet())
+	if err != nil {
+		return nullable.Nullable[time.Time]{}, err
 	}
-	return n.MustGet()
+	return nullable.NewNullableWithValue(t), nil
+}
+
+// FormatRFC3339 formats the time.Time value of n as RFC 3339, with
+// fractional seconds when present.
+func FormatRFC3339(n nullable.Nullable[time.Time]) nullable.Nullable[string] {
+	return FormatTime(n, time.RFC3339Nano)
+}
+
+// ParseRFC3339 parses the string value of n as RFC 3339, with or without
+// fractional seconds.
+func ParseRFC3339(n nullable.Nullable[string]) (nullable.Nullable[time.Time], error) {
+	return ParseTime(n, time.RFC3339Nano)
+}
+
+// FormatDate formats the time.Time value of n as a date, e.g. "2024-05-01".
+func FormatDate(n nullable.Nullable[time.Time]) nullable.Nullable[string] {
+	return FormatTime(n, time.DateOnly)
+}
+
+// ParseDate parses the string value of n as a date, e.g. "2024-05-01", at
+// midnight UTC.
+func ParseDate(n nullable.Nullable[string]) (nullable.Nullable[time.Time], error) {
+	return ParseTime(n, time.DateOnly)
+}
+
+// UUID conversions
+
+// ToNullableUUID converts a *uuid.UUID to nullable.Nullable[uuid.UUID].
+// Returns an unspecified Nullable if the pointer is nil.
+func ToNullableUUID(id *uuid.UUID) nullable.Nullable[uuid.UUID] {
+	return ToNullable(id)
+}
+
+// FromNullableUUID converts nullable.Nullable[uuid.UUID] to *uuid.UUID.
+// Returns nil if the Nullable is unspecified or null.
+func FromNullableUUID(n nullable.Nullable[uuid.UUID]) *uuid.UUID {
+	return FromNullable(n)
+}
+
+// UUIDValue returns the uuid.UUID value or the provided default if unspecified or null.
+func UUIDValue(n nullable.Nullable[uuid.UUID], defaultVal uuid.UUID) uuid.UUID {
+	return ValueOr(n, defaultVal)
 }
 
 // Generic helpers
@@ -361,7 +403,88 @@ func IsSpecified[T any](n nullable.Nullable[T]) bool {
 	return n.IsSpecified()
 }
 
+// IsNull returns true if the Nullable was explicitly set to null.
+//
+// Example:
+//
+//	// {"nickname": null}
+//	if IsNull(req.Nickname) {
+//	    // Clear the nickname
+//	}
+func IsNull[T any](n nullable.Nullable[T]) bool {
+	return n.IsNull()
+}
+
+// IsUnset returns true if the Nullable is unspecified, e.g. a field absent
+// from a PATCH request body.
+//
+// Example:
+//
+//	if IsUnset(req.Nickname) {
+//	    // Keep the current nickname
+//	}
+func IsUnset[T any](n nullable.Nullable[T]) bool {
+	return !n.IsSpecified()
+}
+
+// SetNull returns a Nullable explicitly set to null, e.g. to clear a field in
+// a response or request.
+//
+// Example:
+//
+//	resp.DeletedAt = SetNull[time.Time]()
+func SetNull[T any]() nullable.Nullable[T] {
+	return nullable.NewNullNullable[T]()
+}
+
+// Apply applies n to current with PATCH semantics: an unspecified Nullable
+// keeps current, null clears it to nil, and a value replaces it.
+//
+// Example:
+//
+//	user.Nickname = Apply(req.Nickname, user.Nickname)
+//	user.Age = Apply(req.Age, user.Age)
+func Apply[T any](n nullable.Nullable[T], current *T) *T {
+	if !n.IsSpecified() {
+		return current
+	}
+	return FromNullable(n)
+}
+
+// ToNullable converts a *T to nullable.Nullable[T].
+// Returns an unspecified Nullable if the pointer is nil.
+// The type-specific ToNullable functions delegate to it.
+//
+// Example:
+//
+//	id := uuid.New()
+//	n := ToNullable(&id)           // Specified with value id
+//	n = ToNullable[uuid.UUID](nil) // Unspecified
+func ToNullable[T any](v *T) nullable.Nullable[T] {
+	if v == nil {
+		return nullable.Nullable[T]{}
+	}
+	return nullable.NewNullableWithValue(*v)
+}
+
+// FromNullable converts nullable.Nullable[T] to *T.
+// Returns nil if the Nullable is unspecified or null.
+// The type-specific FromNullable functions delegate to it.
+//
+// Example:
+//
+//	n := nullable.NewNullableWithValue(uuid.New())
+//	id := FromNullable(n) // *uuid.UUID
+func FromNullable[T any](n nullable.Nullable[T]) *T {
+	val, err := n.Get()
+	if err != nil {
+		return nil
+	}
+	return &val
+}
+
 // ValueOr returns the value if specified, otherwise returns the default.
+// A null value also returns the default.
 // This is a generic version of the type-specific value functions.
 //
 // Example:
@@ -369,10 +492,11 @@ func IsSpecified[T any](n nullable.Nullable[T]) bool {
 //	n := nullable.Nullable[string]{}
 //	s := ValueOr(n, "default") // Returns "default"
 func ValueOr[T any](n nullable.Nullable[T], defaultVal T) T {
-	if !n.IsSpecified() {
+	val, err := n.Get()
+	if err != nil {
 		return defaultVal
 	}
-	return n.MustGet()
+	return val
 }
 
 // Ptr is a helper function that returns a pointer to the given value.
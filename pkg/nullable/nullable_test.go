@@ -0,0 +1,206 @@
+package nullable
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oapi-codegen/nullable"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToNullable(t *testing.T) {
+	n := ToNullable(Ptr(42))
+	assert.True(t, n.IsSpecified())
+	assert.Equal(t, 42, n.MustGet())
+
+	n = ToNullable[int](nil)
+	assert.False(t, n.IsSpecified())
+}
+
+func TestFromNullable(t *testing.T) {
+	t.Run("value", func(t *testing.T) {
+		v := FromNullable(nullable.NewNullableWithValue("hello"))
+		require.NotNil(t, v)
+		assert.Equal(t, "hello", *v)
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		v := FromNullable(nullable.NewNullableWithValue(0))
+		require.NotNil(t, v)
+		assert.Equal(t, 0, *v)
+	})
+
+	t.Run("unspecified", func(t *testing.T) {
+		assert.Nil(t, FromNullable(nullable.Nullable[string]{}))
+	})
+
+	t.Run("null", func(t *testing.T) {
+		assert.Nil(t, FromNullable(nullable.NewNullNullable[string]()))
+	})
+}
+
+func TestValueOr(t *testing.T) {
+	assert.Equal(t, "x", ValueOr(nullable.NewNullableWithValue("x"), "default"))
+	assert.Equal(t, "default", ValueOr(nullable.Nullable[string]{}, "default"))
+	assert.Equal(t, "default", ValueOr(nullable.NewNullNullable[string](), "default"))
+}
+
+func TestTypeWrappers(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		assert.Equal(t, "a", *FromNullableString(ToNullableString(Ptr("a"))))
+		assert.Nil(t, FromNullableString(nullable.NewNullNullable[string]()))
+		assert.Equal(t, "", StringOrEmpty(nullable.NewNullNullable[string]()))
+	})
+
+	t.Run("int", func(t *testing.T) {
+		assert.Equal(t, 7, *FromNullableInt(ToNullableInt(Ptr(7))))
+		assert.Equal(t, 3, IntValue(nullable.NewNullNullable[int](), 3))
+		assert.Equal(t, int32(7), *FromNullableInt32(ToNullableInt32(Ptr[int32](7))))
+		assert.Equal(t, int64(7), *FromNullableInt64(ToNullableInt64(Ptr[int64](7))))
+		assert.Equal(t, 0, IntOrZero(nullable.Nullable[int]{}))
+		assert.Equal(t, 7, IntOrZero(nullable.NewNullableWithValue(7)))
+		assert.Equal(t, int32(3), Int32Value(nullable.NewNullNullable[int32](), 3))
+		assert.Equal(t, int64(7), Int64Value(nullable.NewNullableWithValue[int64](7), 3))
+	})
+
+	t.Run("float", func(t *testing.T) {
+		assert.Equal(t, float32(1.5), *FromNullableFloat32(ToNullableFloat32(Ptr[float32](1.5))))
+		assert.Equal(t, float32(2), Float32Value(nullable.Nullable[float32]{}, 2))
+		assert.Equal(t, 2.5, Float64Value(nullable.NewNullableWithValue(2.5), 0))
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		assert.False(t, *FromNullableBool(ToNullableBool(Ptr(false))))
+		assert.True(t, BoolValue(nullable.Nullable[bool]{}, true))
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		id := uuid.New()
+		n := ToNullableUUID(&id)
+		assert.Equal(t, id, *FromNullableUUID(n))
+		assert.False(t, ToNullableUUID(nil).IsSpecified())
+		assert.Nil(t, FromNullableUUID(nullable.NewNullNullable[uuid.UUID]()))
+		assert.Equal(t, uuid.Nil, UUIDValue(nullable.Nullable[uuid.UUID]{}, uuid.Nil))
+		assert.Equal(t, id, UUIDValue(n, uuid.Nil))
+	})
+
+	t.Run("decimal", func(t *testing.T) {
+		d := decimal.RequireFromString("12.50")
+		assert.True(t, d.Equal(*FromNullableDecimal(ToNullableDecimal(&d))))
+		assert.Nil(t, FromNullableDecimal(nullable.NewNullNullable[decimal.Decimal]()))
+		assert.True(t, decimal.Zero.Equal(DecimalValue(nullable.NewNullNullable[decimal.Decimal](), decimal.Zero)))
+	})
+}
+
+func TestIntCoercions(t *testing.T) {
+	t.Run("int32 to int64", func(t *testing.T) {
+		assert.Equal(t, int64(math.MinInt32), Int64FromInt32(nullable.NewNullableWithValue[int32](math.MinInt32)).MustGet())
+		assert.True(t, Int64FromInt32(nullable.NewNullNullable[int32]()).IsNull())
+		assert.False(t, Int64FromInt32(nullable.Nullable[int32]{}).IsSpecified())
+	})
+
+	t.Run("int64 to int32", func(t *testing.T) {
+		n, err := Int32FromInt64(nullable.NewNullableWithValue[int64](math.MaxInt32))
+		require.NoError(t, err)
+		assert.Equal(t, int32(math.MaxInt32), n.MustGet())
+
+		n, err = Int32FromInt64(nullable.NewNullNullable[int64]())
+		require.NoError(t, err)
+		assert.True(t, n.IsNull())
+
+		n, err = Int32FromInt64(nullable.Nullable[int64]{})
+		require.NoError(t, err)
+		assert.False(t, n.IsSpecified())
+
+		for _, v := range []int64{math.MaxInt32 + 1, math.MinInt32 - 1, math.MaxInt64} {
+			_, err = Int32FromInt64(nullable.NewNullableWithValue(v))
+			require.ErrorIs(t, err, ErrOverflow, v)
+		}
+	})
+}
+
+func TestTimeFormatting(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 13, 4, 5, 500, time.UTC)
+
+	t.Run("format", func(t *testing.T) {
+		n := nullable.NewNullableWithValue(ts)
+		assert.Equal(t, "01/05/2024", FormatTime(n, "02/01/2006").MustGet())
+		assert.Equal(t, "2024-05-01T13:04:05.0000005Z", FormatRFC3339(n).MustGet())
+		assert.Equal(t, "2024-05-01", FormatDate(n).MustGet())
+		assert.True(t, FormatRFC3339(nullable.NewNullNullable[time.Time]()).IsNull())
+		assert.False(t, FormatDate(nullable.Nullable[time.Time]{}).IsSpecified())
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		n, err := ParseRFC3339(nullable.NewNullableWithValue("2024-05-01T13:04:05.0000005Z"))
+		require.NoError(t, err)
+		assert.Equal(t, ts, n.MustGet())
+
+		n, err = ParseRFC3339(nullable.NewNullableWithValue("2024-05-01T16:04:05+03:00"))
+		require.NoError(t, err)
+		assert.True(t, ts.Truncate(time.Second).Equal(n.MustGet()))
+
+		n, err = ParseDate(nullable.NewNullableWithValue("2024-05-01"))
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), n.MustGet())
+
+		n, err = ParseTime(nullable.NewNullNullable[string](), time.Kitchen)
+		require.NoError(t, err)
+		assert.True(t, n.IsNull())
+
+		n, err = ParseDate(nullable.Nullable[string]{})
+		require.NoError(t, err)
+		assert.False(t, n.IsSpecified())
+
+		_, err = ParseDate(nullable.NewNullableWithValue("01/05/2024"))
+		require.Error(t, err)
+	})
+}
+
+func TestTriState(t *testing.T) {
+	unset := nullable.Nullable[string]{}
+	null := SetNull[string]()
+	value := nullable.NewNullableWithValue("")
+
+	assert.True(t, IsUnset(unset))
+	assert.False(t, IsNull(unset))
+
+	assert.False(t, IsUnset(null))
+	assert.True(t, IsNull(null))
+	assert.True(t, IsSpecified(null))
+
+	assert.False(t, IsUnset(value))
+	assert.False(t, IsNull(value))
+
+	data, err := null.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+}
+
+func TestApply(t *testing.T) {
+	current := Ptr("old")
+
+	t.Run("unspecified keeps the current value", func(t *testing.T) {
+		assert.Same(t, current, Apply(nullable.Nullable[string]{}, current))
+		assert.Nil(t, Apply(nullable.Nullable[string]{}, nil))
+	})
+
+	t.Run("null clears it", func(t *testing.T) {
+		assert.Nil(t, Apply(SetNull[string](), current))
+	})
+
+	t.Run("value replaces it", func(t *testing.T) {
+		got := Apply(nullable.NewNullableWithValue("new"), current)
+		require.NotNil(t, got)
+		assert.Equal(t, "new", *got)
+		assert.Equal(t, "old", *current)
+
+		got = Apply(nullable.NewNullableWithValue(""), nil)
+		require.NotNil(t, got)
+		assert.Equal(t, "", *got)
+	})
+}
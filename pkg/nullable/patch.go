@@ -0,0 +1,182 @@
+package nullable
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// nullChecker is implemented by nullable.Nullable[T] of any T.
+type nullChecker interface {
+	specifiedChecker
+	IsNull() bool
+}
+
+// ApplyPatch applies the specified fields of the patch struct, e.g. a decoded
+// PATCH request body, onto the struct dst points to, with the semantics of
+// Apply. Fields are matched by their JSON names, honoring json tags, and
+// embedded structs are walked on both sides.
+//
+//   - A nullable.Nullable[T] field that is unspecified is skipped, null sets
+//     the field of dst to nil, and a value sets it.
+//   - A pointer field that is nil is skipped, and a value sets the field.
+//   - Other fields of patch are ignored.
+//
+// The field of dst may be of the value type, a pointer to it, or a type of
+// the same kind it converts to, e.g. a string-typed enum. ApplyPatch returns
+// an error for patch fields without a field in dst, for values that do not
+// convert, and for null on fields of dst that cannot be nil; dst may be
+// partly patched then.
+//
+// Example:
+//
+//	type UpdateUserRequest struct {
+//	    Nickname nullable.Nullable[string] `json:"nickname"`
+//	    Email    *string                   `json:"email"`
+//	}
+//
+//	if err := ApplyPatch(&user, req); err != nil {
+//	    return err
+//	}
+func ApplyPatch(dst any, patch any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("nullable: ApplyPatch destination %T: want a non-nil pointer to a struct", dst)
+	}
+	pv, err := patchStruct("ApplyPatch", patch)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]reflect.Value)
+	indexFields(dv.Elem(), fields)
+	return applyFields(pv, fields)
+}
+
+// indexFields adds the settable fields of struct rv to fields by JSON name.
+func indexFields(rv reflect.Value, fields map[string]reflect.Value) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			indexFields(rv.Field(i), fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name := jsonName(field); name != "-" {
+			fields[name] = rv.Field(i)
+		}
+	}
+}
+
+// applyFields applies the fields of patch struct pv onto fields.
+func applyFields(pv reflect.Value, fields map[string]reflect.Value) error {
+	return walkPatch(pv, func(name string, source, value reflect.Value) error {
+		target, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("nullable: ApplyPatch field %q: no such field in destination", name)
+		}
+		if err := setField(target, source, value); err != nil {
+			return fmt.Errorf("nullable: ApplyPatch field %q: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// patchStruct returns the struct patch is or points to; fn names the caller
+// in the error otherwise.
+func patchStruct(fn string, patch any) (reflect.Value, error) {
+	pv := reflect.ValueOf(patch)
+	for pv.Kind() == reflect.Pointer && !pv.IsNil() {
+		pv = pv.Elem()
+	}
+	if pv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("nullable: %s patch %T: want a struct", fn, patch)
+	}
+	return pv, nil
+}
+
+// walkPatch calls fn with the JSON name, the field, and the value of every
+// specified field of patch struct pv, walking embedded structs. The value is
+// invalid when the field is null. Nullable fields that are unspecified, nil
+// pointers, and fields of other types are skipped.
+func walkPatch(pv reflect.Value, fn func(name string, source, value reflect.Value) error) error {
+	pt := pv.Type()
+	for i := range pt.NumField() {
+		field := pt.Field(i)
+		fv := pv.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := walkPatch(fv, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		var value reflect.Value
+		switch n := fv.Interface().(type) {
+		case nullChecker:
+			if !n.IsSpecified() {
+				continue
+			}
+			if !n.IsNull() {
+				value = fv.MethodByName("MustGet").Call(nil)[0]
+			}
+		default:
+			if fv.Kind() != reflect.Pointer {
+				continue
+			}
+			if fv.IsNil() {
+				continue
+			}
+			value = fv.Elem()
+		}
+
+		if err := fn(name, fv, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField sets target from the patch field source: to value, or to nil
+// when value is invalid, i.e. null.
+func setField(target, source, value reflect.Value) error {
+	tt := target.Type()
+	switch {
+	case source.Type() == tt && source.Kind() == reflect.Map:
+		// A Nullable copied as it is.
+		target.Set(source)
+		return nil
+	case !value.IsValid():
+		switch tt.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			target.SetZero()
+			return nil
+		}
+		return fmt.Errorf("cannot set %s to null", tt)
+	case tt.Kind() == reflect.Pointer && convertible(value.Type(), tt.Elem()):
+		ptr := reflect.New(tt.Elem())
+		ptr.Elem().Set(value.Convert(tt.Elem()))
+		target.Set(ptr)
+		return nil
+	case convertible(value.Type(), tt):
+		target.Set(value.Convert(tt))
+		return nil
+	}
+	return fmt.Errorf("cannot set %s from %s", tt, value.Type())
+}
+
+// convertible reports whether values of from may be set on fields of to:
+// when assignable, or convertible between types of the same kind, e.g. a
+// string to a string-typed enum, but not an int to a string.
+func convertible(from, to reflect.Type) bool {
+	return from.AssignableTo(to) || (from.Kind() == to.Kind() && from.ConvertibleTo(to))
+}
@@ -0,0 +1,137 @@
+package nullable
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchStatus string
+
+type patchTimestamps struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type patchUser struct {
+	patchTimestamps
+	Name     string                    `json:"name"`
+	Nickname *string                   `json:"nickname"`
+	Status   patchStatus               `json:"status"`
+	Tags     []string                  `json:"tags"`
+	Bio      nullable.Nullable[string] `json:"bio"`
+	Age      int                       `json:"age"`
+	Secret   string                    `json:"-"`
+}
+
+type updateUserPatch struct {
+	Name      nullable.Nullable[string]    `json:"name"`
+	Nickname  nullable.Nullable[string]    `json:"nickname"`
+	Status    *string                      `json:"status"`
+	Tags      nullable.Nullable[[]string]  `json:"tags"`
+	Bio       nullable.Nullable[string]    `json:"bio"`
+	UpdatedAt nullable.Nullable[time.Time] `json:"updatedAt"`
+	Note      string                       `json:"note"`
+}
+
+func decodePatch(t *testing.T, body string) updateUserPatch {
+	t.Helper()
+	var patch updateUserPatch
+	require.NoError(t, json.Unmarshal([]byte(body), &patch))
+	return patch
+}
+
+func TestApplyPatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	newUser := func() patchUser {
+		return patchUser{
+			Name:     "Ann",
+			Nickname: Ptr("annie"),
+			Status:   "active",
+			Tags:     []string{"a"},
+			Bio:      nullable.NewNullableWithValue("hi"),
+			Age:      30,
+		}
+	}
+
+	t.Run("unspecified fields keep their values", func(t *testing.T) {
+		user := newUser()
+		require.NoError(t, ApplyPatch(&user, decodePatch(t, `{}`)))
+		assert.Equal(t, newUser(), user)
+	})
+
+	t.Run("values set fields", func(t *testing.T) {
+		user := newUser()
+		patch := decodePatch(t, `{"name":"Bo","nickname":"bobby","status":"banned","tags":[],"bio":"yo","updatedAt":"2024-05-01T00:00:00Z","note":"x"}`)
+		require.NoError(t, ApplyPatch(&user, &patch))
+
+		assert.Equal(t, "Bo", user.Name)
+		assert.Equal(t, "bobby", *user.Nickname)
+		assert.Equal(t, patchStatus("banned"), user.Status)
+		assert.Equal(t, []string{}, user.Tags)
+		assert.Equal(t, "yo", user.Bio.MustGet())
+		assert.Equal(t, now, user.UpdatedAt)
+		assert.Equal(t, 30, user.Age)
+	})
+
+	t.Run("nulls clear fields", func(t *testing.T) {
+		user := newUser()
+		require.NoError(t, ApplyPatch(&user, decodePatch(t, `{"nickname":null,"tags":null,"bio":null}`)))
+
+		assert.Nil(t, user.Nickname)
+		assert.Nil(t, user.Tags)
+		assert.True(t, user.Bio.IsNull())
+		assert.Equal(t, "Ann", user.Name)
+	})
+
+	t.Run("null on a non-nullable field", func(t *testing.T) {
+		user := newUser()
+		err := ApplyPatch(&user, decodePatch(t, `{"name":null}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"name"`)
+	})
+
+	t.Run("does not alias patch pointers", func(t *testing.T) {
+		user := newUser()
+		status := "banned"
+		require.NoError(t, ApplyPatch(&user, struct {
+			Nickname *string `json:"nickname"`
+		}{Nickname: &status}))
+
+		status = "changed"
+		assert.Equal(t, "banned", *user.Nickname)
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		user := newUser()
+		err := ApplyPatch(&user, struct {
+			Email nullable.Nullable[string] `json:"email"`
+		}{Email: nullable.NewNullableWithValue("a@example.com")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no such field")
+
+		err = ApplyPatch(&user, struct {
+			Secret *string `json:"Secret"`
+		}{Secret: Ptr("x")})
+		require.Error(t, err, "fields tagged json:\"-\" cannot be patched")
+	})
+
+	t.Run("values that do not convert", func(t *testing.T) {
+		user := newUser()
+		err := ApplyPatch(&user, struct {
+			Name *int `json:"name"`
+		}{Name: Ptr(65)})
+		require.Error(t, err)
+		assert.Equal(t, "Ann", user.Name)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		user := newUser()
+		require.Error(t, ApplyPatch(user, updateUserPatch{}))
+		require.Error(t, ApplyPatch((*patchUser)(nil), updateUserPatch{}))
+		require.Error(t, ApplyPatch(&user, "patch"))
+	})
+}
@@ -0,0 +1,292 @@
+package nullable
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/oapi-codegen/nullable"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Protobuf conversions
+//
+// Protobuf wrapper messages cannot tell null from unspecified: both are a
+// nil message. gRPC update endpoints pair them with a FieldMask instead, a
+// field in the mask with a nil message being cleared. FieldMask derives the
+// mask of a request from its Nullable fields, and Masked restores null from
+// a mask.
+
+// specifiedChecker is implemented by nullable.Nullable[T] of any T.
+type specifiedChecker interface {
+	IsSpecified() bool
+}
+
+// toWrapper returns the value of n wrapped by wrap, or nil when it is
+// unspecified or null.
+func toWrapper[T any, W any](n nullable.Nullable[T], wrap func(T) W) W {
+	v, err := n.Get()
+	if err != nil {
+		var zero W
+		return zero
+	}
+	return wrap(v)
+}
+
+// fromWrapper returns the value of w as a Nullable, unspecified when w is
+// nil.
+func fromWrapper[T any, W interface {
+	comparable
+	GetValue() T
+}](w W) nullable.Nullable[T] {
+	var zero W
+	if w == zero {
+		return nullable.Nullable[T]{}
+	}
+	return nullable.NewNullableWithValue(w.GetValue())
+}
+
+// ToStringValue converts nullable.Nullable[string] to *wrapperspb.StringValue.
+// Returns nil if the Nullable is unspecified or null.
+func ToStringValue(n nullable.Nullable[string]) *wrapperspb.StringValue {
+	return toWrapper(n, wrapperspb.String)
+}
+
+// FromStringValue converts *wrapperspb.StringValue to nullable.Nullable[string].
+// Returns an unspecified Nullable for nil; see Masked.
+func FromStringValue(v *wrapperspb.StringValue) nullable.Nullable[string] {
+	return fromWrapper(v)
+}
+
+// ToBoolValue converts nullable.Nullable[bool] to *wrapperspb.BoolValue.
+// Returns nil if the Nullable is unspecified or null.
+func ToBoolValue(n nullable.Nullable[bool]) *wrapperspb.BoolValue {
+	return toWrapper(n, wrapperspb.Bool)
+}
+
+// FromBoolValue converts *wrapperspb.BoolValue to nullable.Nullable[bool].
+// Returns an unspecified Nullable for nil.
+func FromBoolValue(v *wrapperspb.BoolValue) nullable.Nullable[bool] {
+	return fromWrapper(v)
+}
+
+// ToInt32Value converts nullable.Nullable[int32] to *wrapperspb.Int32Value.
+// Returns nil if the Nullable is unspecified or null.
+func ToInt32Value(n nullable.Nullable[int32]) *wrapperspb.Int32Value {
+	return toWrapper(n, wrapperspb.Int32)
+}
+
+// FromInt32Value converts *wrapperspb.Int32Value to nullable.Nullable[int32].
+// Returns an unspecified Nullable for nil.
+func FromInt32Value(v *wrapperspb.Int32Value) nullable.Nullable[int32] {
+	return fromWrapper(v)
+}
+
+// ToInt64Value converts nullable.Nullable[int64] to *wrapperspb.Int64Value.
+// Returns nil if the Nullable is unspecified or null.
+func ToInt64Value(n nullable.Nullable[int64]) *wrapperspb.Int64Value {
+	return toWrapper(n, wrapperspb.Int64)
+}
+
+// FromInt64Value converts *wrapperspb.Int64Value to nullable.Nullable[int64].
+// Returns an unspecified Nullable for nil.
+func FromInt64Value(v *wrapperspb.Int64Value) nullable.Nullable[int64] {
+	return fromWrapper(v)
+}
+
+// ToUInt32Value converts nullable.Nullable[uint32] to *wrapperspb.UInt32Value.
+// Returns nil if the Nullable is unspecified or null.
+func ToUInt32Value(n nullable.Nullable[uint32]) *wrapperspb.UInt32Value {
+	return toWrapper(n, wrapperspb.UInt32)
+}
+
+// FromUInt32Value converts *wrapperspb.UInt32Value to nullable.Nullable[uint32].
+// Returns an unspecified Nullable for nil.
+func FromUInt32Value(v *wrapperspb.UInt32Value) nullable.Nullable[uint32] {
+	return fromWrapper(v)
+}
+
+// ToUInt64Value converts nullable.Nullable[uint64] to *wrapperspb.UInt64Value.
+// Returns nil if the Nullable is unspecified or null.
+func ToUInt64Value(n nullable.Nullable[uint64]) *wrapperspb.UInt64Value {
+	return toWrapper(n, wrapperspb.UInt64)
+}
+
+// FromUInt64Value converts *wrapperspb.UInt64Value to nullable.Nullable[uint64].
+// Returns an unspecified Nullable for nil.
+func FromUInt64Value(v *wrapperspb.UInt64Value) nullable.Nullable[uint64] {
+	return fromWrapper(v)
+}
+
+// ToFloatValue converts nullable.Nullable[float32] to *wrapperspb.FloatValue.
+// Returns nil if the Nullable is unspecified or null.
+func ToFloatValue(n nullable.Nullable[float32]) *wrapperspb.FloatValue {
+	return toWrapper(n, wrapperspb.Float)
+}
+
+// FromFloatValue converts *wrapperspb.FloatValue to nullable.Nullable[float32].
+// Returns an unspecified Nullable for nil.
+func FromFloatValue(v *wrapperspb.FloatValue) nullable.Nullable[float32] {
+	return fromWrapper(v)
+}
+
+// ToDoubleValue converts nullable.Nullable[float64] to *wrapperspb.DoubleValue.
+// Returns nil if the Nullable is unspecified or null.
+func ToDoubleValue(n nullable.Nullable[float64]) *wrapperspb.DoubleValue {
+	return toWrapper(n, wrapperspb.Double)
+}
+
+// FromDoubleValue converts *wrapperspb.DoubleValue to nullable.Nullable[float64].
+// Returns an unspecified Nullable for nil.
+func FromDoubleValue(v *wrapperspb.DoubleValue) nullable.Nullable[float64] {
+	return fromWrapper(v)
+}
+
+// ToBytesValue converts nullable.Nullable[[]byte] to *wrapperspb.BytesValue.
+// Returns nil if the Nullable is unspecified or null.
+func ToBytesValue(n nullable.Nullable[[]byte]) *wrapperspb.BytesValue {
+	return toWrapper(n, wrapperspb.Bytes)
+}
+
+// FromBytesValue converts *wrapperspb.BytesValue to nullable.Nullable[[]byte].
+// Returns an unspecified Nullable for nil.
+func FromBytesValue(v *wrapperspb.BytesValue) nullable.Nullable[[]byte] {
+	return fromWrapper(v)
+}
+
+// ToTimestamp converts nullable.Nullable[time.Time] to *timestamppb.Timestamp.
+// Returns nil if the Nullable is unspecified or null.
+func ToTimestamp(n nullable.Nullable[time.Time]) *timestamppb.Timestamp {
+	return toWrapper(n, timestamppb.New)
+}
+
+// FromTimestamp converts *timestamppb.Timestamp to nullable.Nullable[time.Time].
+// Returns an unspecified Nullable for nil.
+func FromTimestamp(v *timestamppb.Timestamp) nullable.Nullable[time.Time] {
+	if v == nil {
+		return nullable.Nullable[time.Time]{}
+	}
+	return nullable.NewNullableWithValue(v.AsTime())
+}
+
+// Masked applies mask to n, converted from the field at path of a gRPC
+// update request: outside the mask it is unspecified, and in the mask an
+// unspecified value, i.e. a nil message, is null.
+//
+// Example:
+//
+//	patch.Nickname = Masked(FromStringValue(req.GetNickname()), req.GetUpdateMask(), "nickname")
+func Masked[T any](n nullable.Nullable[T], mask *fieldmaskpb.FieldMask, path string) nullable.Nullable[T] {
+	if !slices.Contains(mask.GetPaths(), path) {
+		return nullable.Nullable[T]{}
+	}
+	if !n.IsSpecified() {
+		return SetNull[T]()
+	}
+	return n
+}
+
+// FieldMask returns the FieldMask of the specified Nullable fields of the
+// struct v points to or is, e.g. a PATCH request to forward to a gRPC update
+// endpoint. The path of a field is the name of its protobuf tag, as on the
+// fields of generated messages, or else its json tag name or Go name in
+// snake_case; fields tagged json:"-" are skipped and embedded structs are
+// walked.
+//
+// Example:
+//
+//	type UpdateUserRequest struct {
+//	    Nickname  nullable.Nullable[string]    `json:"nickname"`
+//	    BirthDate nullable.Nullable[time.Time] `json:"birthDate"`
+//	}
+//
+//	mask, err := FieldMask(req) // paths: ["birth_date"] when only birthDate was sent
+func FieldMask(v any) (*fieldmaskpb.FieldMask, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("nullable: FieldMask of nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nullable: FieldMask of %s: want a struct", rv.Type())
+	}
+
+	mask := &fieldmaskpb.FieldMask{}
+	appendSpecifiedPaths(rv, &mask.Paths)
+	return mask, nil
+}
+
+// appendSpecifiedPaths appends the paths of the specified Nullable fields of
+// struct rv to paths.
+func appendSpecifiedPaths(rv reflect.Value, paths *[]string) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			appendSpecifiedPaths(fv, paths)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		path := fieldPath(field)
+		if path == "" {
+			continue
+		}
+		if checker, ok := fv.Interface().(specifiedChecker); ok && checker.IsSpecified() {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// fieldPath returns the FieldMask path of field, or "" when it is tagged
+// json:"-".
+func fieldPath(field reflect.StructField) string {
+	// protobuf:"bytes,1,opt,name=birth_date,json=birthDate,proto3"
+	for opt := range strings.SplitSeq(field.Tag.Get("protobuf"), ",") {
+		if name, ok := strings.CutPrefix(opt, "name="); ok {
+			return name
+		}
+	}
+	name := jsonName(field)
+	if name == "-" {
+		return ""
+	}
+	return snakeCase(name)
+}
+
+// snakeCase converts a camelCase or PascalCase name to snake_case, keeping
+// acronyms together, e.g. "birthDate" to "birth_date" and "UserID" to
+// "user_id".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := rune(name[i-1])
+			next := i+1 < len(name) && unicode.IsLower(rune(name[i+1]))
+			if prev != '_' && (unicode.IsLower(prev) || unicode.IsDigit(prev) || next) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// jsonName returns the name of field in JSON: its json tag name, or its Go
+// name.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,125 @@
+package nullable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestWrapperConversions(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		assert.Equal(t, "a", ToStringValue(nullable.NewNullableWithValue("a")).GetValue())
+		assert.Nil(t, ToStringValue(SetNull[string]()))
+		assert.Nil(t, ToStringValue(nullable.Nullable[string]{}))
+
+		assert.Equal(t, "", FromStringValue(wrapperspb.String("")).MustGet())
+		assert.False(t, FromStringValue(nil).IsSpecified())
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		assert.Equal(t, int32(1), FromInt32Value(ToInt32Value(nullable.NewNullableWithValue[int32](1))).MustGet())
+		assert.Equal(t, int64(1), FromInt64Value(ToInt64Value(nullable.NewNullableWithValue[int64](1))).MustGet())
+		assert.Equal(t, uint32(1), FromUInt32Value(ToUInt32Value(nullable.NewNullableWithValue[uint32](1))).MustGet())
+		assert.Equal(t, uint64(1), FromUInt64Value(ToUInt64Value(nullable.NewNullableWithValue[uint64](1))).MustGet())
+		assert.InDelta(t, float32(1.5), FromFloatValue(ToFloatValue(nullable.NewNullableWithValue[float32](1.5))).MustGet(), 0)
+		assert.InDelta(t, 1.5, FromDoubleValue(ToDoubleValue(nullable.NewNullableWithValue(1.5))).MustGet(), 0)
+		assert.False(t, FromInt64Value(nil).IsSpecified())
+	})
+
+	t.Run("bool and bytes", func(t *testing.T) {
+		assert.False(t, FromBoolValue(ToBoolValue(nullable.NewNullableWithValue(false))).MustGet())
+		assert.Equal(t, []byte("x"), FromBytesValue(ToBytesValue(nullable.NewNullableWithValue([]byte("x")))).MustGet())
+		assert.Nil(t, ToBoolValue(SetNull[bool]()))
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		now := time.Now().UTC()
+		ts := ToTimestamp(nullable.NewNullableWithValue(now))
+		assert.True(t, now.Equal(ts.AsTime()))
+		assert.True(t, now.Equal(FromTimestamp(ts).MustGet()))
+		assert.Nil(t, ToTimestamp(SetNull[time.Time]()))
+		assert.False(t, FromTimestamp((*timestamppb.Timestamp)(nil)).IsSpecified())
+	})
+}
+
+func TestMasked(t *testing.T) {
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"nickname"}}
+
+	assert.Equal(t, "a", Masked(FromStringValue(wrapperspb.String("a")), mask, "nickname").MustGet())
+	assert.True(t, Masked(FromStringValue(nil), mask, "nickname").IsNull())
+	assert.False(t, Masked(FromStringValue(wrapperspb.String("a")), mask, "bio").IsSpecified())
+	assert.False(t, Masked(FromStringValue(wrapperspb.String("a")), nil, "nickname").IsSpecified())
+}
+
+func TestFieldMask(t *testing.T) {
+	type Audit struct {
+		Reason nullable.Nullable[string] `json:"reason"`
+	}
+	type updateUserRequest struct {
+		Audit
+		Nickname  nullable.Nullable[string]    `json:"nickname"`
+		BirthDate nullable.Nullable[time.Time] `json:"birthDate,omitempty"`
+		Bio       nullable.Nullable[string]
+		Internal  nullable.Nullable[string] `json:"-"`
+		Name      string                    `json:"name"`
+		hidden    nullable.Nullable[string] //nolint:unused // Unexported fields are skipped.
+	}
+
+	req := updateUserRequest{
+		Audit:     Audit{Reason: nullable.NewNullableWithValue("typo")},
+		BirthDate: SetNull[time.Time](),
+		Bio:       nullable.NewNullableWithValue(""),
+		Internal:  nullable.NewNullableWithValue("x"),
+		Name:      "ignored",
+	}
+
+	mask, err := FieldMask(&req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reason", "birth_date", "bio"}, mask.GetPaths())
+
+	mask, err = FieldMask(updateUserRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, mask.GetPaths())
+
+	_, err = FieldMask((*updateUserRequest)(nil))
+	require.Error(t, err)
+	_, err = FieldMask("not a struct")
+	require.Error(t, err)
+}
+
+func TestFieldMask_protobufNames(t *testing.T) {
+	// As generated by protoc-gen-go, with the mask paths in the protobuf tags
+	type updateUserRequest struct {
+		DisplayName nullable.Nullable[string] `protobuf:"bytes,1,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+		ExternalURL nullable.Nullable[string] `protobuf:"bytes,2,opt,name=external_url2,json=externalUrl2,proto3" json:"external_url2,omitempty"`
+		UserID      nullable.Nullable[string]
+	}
+
+	mask, err := FieldMask(updateUserRequest{
+		DisplayName: nullable.NewNullableWithValue("Ann"),
+		ExternalURL: SetNull[string](),
+		UserID:      nullable.NewNullableWithValue("u1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"display_name", "external_url2", "user_id"}, mask.GetPaths())
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"nickname":    "nickname",
+		"birthDate":   "birth_date",
+		"BirthDate":   "birth_date",
+		"UserID":      "user_id",
+		"HTTPServer":  "http_server",
+		"address2Zip": "address2_zip",
+		"snake_case":  "snake_case",
+	} {
+		assert.Equal(t, want, snakeCase(name), name)
+	}
+}
@@ -0,0 +1,168 @@
+package pgxutil
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Array conversions
+// These functions convert between Go slices and one-dimensional pgtype.Array
+// values of nullable elements, for array columns whose elements may be NULL.
+// A nil slice converts to a NULL array and a NULL array to a nil slice; the
+// pointer variants map NULL elements to nil pointers, the others to zero
+// values.
+
+// arrayFromSlice builds the one-dimensional array of vals, converting each
+// with elem, or a NULL array when vals is nil.
+func arrayFromSlice[T, E any](vals []T, elem func(T) E) pgtype.Array[E] {
+	if vals == nil {
+		return pgtype.Array[E]{}
+	}
+	elements := make([]E, len(vals))
+	for i, v := range vals {
+		elements[i] = elem(v)
+	}
+	return pgtype.Array[E]{
+		Elements: elements,
+		Dims:     []pgtype.ArrayDimension{{Length: int32(len(vals)), LowerBound: 1}},
+		Valid:    true,
+	}
+}
+
+// sliceFromArray returns the elements of arr converted with val, or nil when
+// arr is NULL. Multi-dimensional arrays are flattened in row-major order.
+func sliceFromArray[E, T any](arr pgtype.Array[E], val func(E) T) []T {
+	if !arr.Valid {
+		return nil
+	}
+	vals := make([]T, len(arr.Elements))
+	for i, e := range arr.Elements {
+		vals[i] = val(e)
+	}
+	return vals
+}
+
+// TextArrayFromStrings converts []string to pgtype.Array[pgtype.Text] for
+// TEXT[] columns, treating a nil slice as NULL.
+//
+// Example:
+//
+//	params := db.UpdatePostParams{
+//	    Tags: pgxutil.TextArrayFromStrings([]string{"go", "postgres"}),
+//	}
+func TextArrayFromStrings(vals []string) pgtype.Array[pgtype.Text] {
+	return arrayFromSlice(vals, func(s string) pgtype.Text {
+		return pgtype.Text{String: s, Valid: true}
+	})
+}
+
+// TextArrayFromStringPtrs converts []*string to pgtype.Array[pgtype.Text],
+// writing nil elements as NULL.
+func TextArrayFromStringPtrs(vals []*string) pgtype.Array[pgtype.Text] {
+	return arrayFromSlice(vals, TextFromStringPtr)
+}
+
+// StringsFromTextArray converts pgtype.Array[pgtype.Text] to []string,
+// returning nil for a NULL array and "" for NULL elements.
+//
+// Example:
+//
+//	tags := pgxutil.StringsFromTextArray(row.Tags)
+func StringsFromTextArray(arr pgtype.Array[pgtype.Text]) []string {
+	return sliceFromArray(arr, PgxTextToString)
+}
+
+// StringPtrsFromTextArray converts pgtype.Array[pgtype.Text] to []*string,
+// returning nil for a NULL array and nil elements for NULL elements.
+func StringPtrsFromTextArray(arr pgtype.Array[pgtype.Text]) []*string {
+	return sliceFromArray(arr, StringFromText)
+}
+
+// UUIDArrayFromUUIDs converts []uuid.UUID to pgtype.Array[pgtype.UUID] for
+// UUID[] columns, treating a nil slice as NULL.
+//
+// Example:
+//
+//	params := db.ListUsersByIDsParams{
+//	    Ids: pgxutil.UUIDArrayFromUUIDs(ids),
+//	}
+func UUIDArrayFromUUIDs(vals []uuid.UUID) pgtype.Array[pgtype.UUID] {
+	return arrayFromSlice(vals, UUIDToPgUUID)
+}
+
+// UUIDArrayFromUUIDPtrs converts []*uuid.UUID to pgtype.Array[pgtype.UUID],
+// writing nil elements as NULL.
+func UUIDArrayFromUUIDPtrs(vals []*uuid.UUID) pgtype.Array[pgtype.UUID] {
+	return arrayFromSlice(vals, UUIDToPgUUIDPtr)
+}
+
+// UUIDsFromUUIDArray converts pgtype.Array[pgtype.UUID] to []uuid.UUID,
+// returning nil for a NULL array and uuid.Nil for NULL elements.
+func UUIDsFromUUIDArray(arr pgtype.Array[pgtype.UUID]) []uuid.UUID {
+	return sliceFromArray(arr, PgUUIDToUUID)
+}
+
+// UUIDPtrsFromUUIDArray converts pgtype.Array[pgtype.UUID] to []*uuid.UUID,
+// returning nil for a NULL array and nil elements for NULL elements.
+func UUIDPtrsFromUUIDArray(arr pgtype.Array[pgtype.UUID]) []*uuid.UUID {
+	return sliceFromArray(arr, PgUUIDToUUIDPtr)
+}
+
+// Int4ArrayFromInt32s converts []int32 to pgtype.Array[pgtype.Int4] for
+// INTEGER[] columns, treating a nil slice as NULL.
+func Int4ArrayFromInt32s(vals []int32) pgtype.Array[pgtype.Int4] {
+	return arrayFromSlice(vals, Int32ToPgxInt4)
+}
+
+// Int4ArrayFromInt32Ptrs converts []*int32 to pgtype.Array[pgtype.Int4],
+// writing nil elements as NULL.
+func Int4ArrayFromInt32Ptrs(vals []*int32) pgtype.Array[pgtype.Int4] {
+	return arrayFromSlice(vals, func(i *int32) pgtype.Int4 {
+		if i == nil {
+			return pgtype.Int4{Valid: false}
+		}
+		return pgtype.Int4{Int32: *i, Valid: true}
+	})
+}
+
+// Int32sFromInt4Array converts pgtype.Array[pgtype.Int4] to []int32,
+// returning nil for a NULL array and 0 for NULL elements.
+func Int32sFromInt4Array(arr pgtype.Array[pgtype.Int4]) []int32 {
+	return sliceFromArray(arr, PgxInt4ToInt32)
+}
+
+// Int32PtrsFromInt4Array converts pgtype.Array[pgtype.Int4] to []*int32,
+// returning nil for a NULL array and nil elements for NULL elements.
+func Int32PtrsFromInt4Array(arr pgtype.Array[pgtype.Int4]) []*int32 {
+	return sliceFromArray(arr, func(v pgtype.Int4) *int32 {
+		return ToPointer(v.Valid, v.Int32)
+	})
+}
+
+// Int8ArrayFromInt64s converts []int64 to pgtype.Array[pgtype.Int8] for
+// BIGINT[] columns, treating a nil slice as NULL.
+func Int8ArrayFromInt64s(vals []int64) pgtype.Array[pgtype.Int8] {
+	return arrayFromSlice(vals, func(i int64) pgtype.Int8 {
+		return pgtype.Int8{Int64: i, Valid: true}
+	})
+}
+
+// Int8ArrayFromInt64Ptrs converts []*int64 to pgtype.Array[pgtype.Int8],
+// writing nil elements as NULL.
+func Int8ArrayFromInt64Ptrs(vals []*int64) pgtype.Array[pgtype.Int8] {
+	return arrayFromSlice(vals, Int8FromInt64)
+}
+
+// Int64sFromInt8Array converts pgtype.Array[pgtype.Int8] to []int64,
+// returning nil for a NULL array and 0 for NULL elements.
+func Int64sFromInt8Array(arr pgtype.Array[pgtype.Int8]) []int64 {
+	return sliceFromArray(arr, func(v pgtype.Int8) int64 {
+		return ToValue(v.Valid, v.Int64)
+	})
+}
+
+// Int64PtrsFromInt8Array converts pgtype.Array[pgtype.Int8] to []*int64,
+// returning nil for a NULL array and nil elements for NULL elements.
+func Int64PtrsFromInt8Array(arr pgtype.Array[pgtype.Int8]) []*int64 {
+	return sliceFromArray(arr, Int64FromInt8)
+}
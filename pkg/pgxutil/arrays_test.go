@@ -0,0 +1,88 @@
+package pgxutil
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextArrayConversions(t *testing.T) {
+	t.Run("nil slice is NULL", func(t *testing.T) {
+		assert.False(t, TextArrayFromStrings(nil).Valid)
+		assert.Nil(t, StringsFromTextArray(pgtype.Array[pgtype.Text]{}))
+	})
+
+	t.Run("empty slice is an empty array", func(t *testing.T) {
+		arr := TextArrayFromStrings([]string{})
+		assert.True(t, arr.Valid)
+		assert.Equal(t, []string{}, StringsFromTextArray(arr))
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+		arr := TextArrayFromStrings([]string{"go", "postgres"})
+		assert.Equal(t, []pgtype.ArrayDimension{{Length: 2, LowerBound: 1}}, arr.Dims)
+		assert.Equal(t, []string{"go", "postgres"}, StringsFromTextArray(arr))
+	})
+
+	t.Run("NULL elements", func(t *testing.T) {
+		s := "go"
+		arr := TextArrayFromStringPtrs([]*string{&s, nil})
+		assert.Equal(t, []string{"go", ""}, StringsFromTextArray(arr))
+		ptrs := StringPtrsFromTextArray(arr)
+		require.Len(t, ptrs, 2)
+		assert.Equal(t, "go", *ptrs[0])
+		assert.Nil(t, ptrs[1])
+	})
+
+	t.Run("encodes as a PostgreSQL array", func(t *testing.T) {
+		s := "go"
+		buf, err := pgtype.NewMap().Encode(pgtype.TextArrayOID, pgtype.TextFormatCode, TextArrayFromStringPtrs([]*string{&s, nil}), nil)
+		require.NoError(t, err)
+		assert.Equal(t, `{go,NULL}`, string(buf))
+	})
+}
+
+func TestUUIDArrayConversions(t *testing.T) {
+	id := uuid.New()
+
+	arr := UUIDArrayFromUUIDs([]uuid.UUID{id})
+	assert.Equal(t, []uuid.UUID{id}, UUIDsFromUUIDArray(arr))
+
+	arr = UUIDArrayFromUUIDPtrs([]*uuid.UUID{nil, &id})
+	assert.Equal(t, []uuid.UUID{uuid.Nil, id}, UUIDsFromUUIDArray(arr))
+	ptrs := UUIDPtrsFromUUIDArray(arr)
+	require.Len(t, ptrs, 2)
+	assert.Nil(t, ptrs[0])
+	assert.Equal(t, id, *ptrs[1])
+
+	assert.Nil(t, UUIDPtrsFromUUIDArray(UUIDArrayFromUUIDs(nil)))
+}
+
+func TestIntArrayConversions(t *testing.T) {
+	t.Run("int4", func(t *testing.T) {
+		assert.Equal(t, []int32{1, 2, 3}, Int32sFromInt4Array(Int4ArrayFromInt32s([]int32{1, 2, 3})))
+
+		n := int32(7)
+		arr := Int4ArrayFromInt32Ptrs([]*int32{&n, nil})
+		assert.Equal(t, []int32{7, 0}, Int32sFromInt4Array(arr))
+		ptrs := Int32PtrsFromInt4Array(arr)
+		require.Len(t, ptrs, 2)
+		assert.Equal(t, int32(7), *ptrs[0])
+		assert.Nil(t, ptrs[1])
+	})
+
+	t.Run("int8", func(t *testing.T) {
+		assert.Equal(t, []int64{1 << 40}, Int64sFromInt8Array(Int8ArrayFromInt64s([]int64{1 << 40})))
+
+		n := int64(7)
+		arr := Int8ArrayFromInt64Ptrs([]*int64{nil, &n})
+		assert.Equal(t, []int64{0, 7}, Int64sFromInt8Array(arr))
+		ptrs := Int64PtrsFromInt8Array(arr)
+		require.Len(t, ptrs, 2)
+		assert.Nil(t, ptrs[0])
+		assert.Equal(t, int64(7), *ptrs[1])
+	})
+}
@@ -0,0 +1,44 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RegisterCompositeTypes loads composite types by name, with the types they
+// depend on, and registers them with the type map of conn. Rows of a
+// registered composite type then scan into, and encode from, structs whose
+// exported fields match its attributes in order; name the array type too,
+// e.g. "_address", to scan arrays into slices of structs. The types must
+// exist when a connection is made. WithCompositeTypes registers them on
+// every connection of a pool.
+//
+// Example:
+//
+//	// CREATE TYPE address AS (street text, city text);
+//	type Address struct {
+//	    Street string
+//	    City   string
+//	}
+//
+//	err := pgxutil.RegisterCompositeTypes(ctx, conn, "address", "_address")
+//	var home Address
+//	err = conn.QueryRow(ctx, "SELECT home FROM users WHERE id = $1", id).Scan(&home)
+func RegisterCompositeTypes(ctx context.Context, conn *pgx.Conn, names ...string) error {
+	types, err := conn.LoadTypes(ctx, names)
+	if err != nil {
+		return fmt.Errorf("pgxutil: load types %v: %w", names, err)
+	}
+	conn.TypeMap().RegisterTypes(types)
+	return nil
+}
+
+// WithCompositeTypes registers the composite types with
+// RegisterCompositeTypes on every new connection.
+func WithCompositeTypes(names ...string) PoolOption {
+	return WithAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		return RegisterCompositeTypes(ctx, conn, names...)
+	})
+}
@@ -0,0 +1,42 @@
+package pgxutil
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompositeStructScanning shows what RegisterCompositeTypes enables: once
+// a composite type is registered, structs encode to and scan from it.
+func TestCompositeStructScanning(t *testing.T) {
+	type address struct {
+		Street string
+		City   *string
+	}
+
+	m := pgtype.NewMap()
+	textType, _ := m.TypeForName("text")
+	const addressOID = 100000
+	m.RegisterType(&pgtype.Type{
+		Name: "address",
+		OID:  addressOID,
+		Codec: &pgtype.CompositeCodec{Fields: []pgtype.CompositeCodecField{
+			{Name: "street", Type: textType},
+			{Name: "city", Type: textType},
+		}},
+	})
+
+	city := "Nairobi"
+	for _, format := range []int16{pgtype.BinaryFormatCode, pgtype.TextFormatCode} {
+		buf, err := m.Encode(addressOID, format, address{Street: "Moi Avenue", City: &city}, nil)
+		require.NoError(t, err)
+
+		var got address
+		require.NoError(t, m.Scan(addressOID, format, buf, &got))
+		assert.Equal(t, "Moi Avenue", got.Street)
+		require.NotNil(t, got.City)
+		assert.Equal(t, city, *got.City)
+	}
+}
@@ -502,7 +502,7 @@ func UUIDToPgUUID(id uuid.UUID) pgtype.UUID {
 //
 //	userID := pgxutil.ToValue(row.UserID.Valid, row.UserID.Bytes)
 func PgUUIDToUUID(pgUUID pgtype.UUID) uuid.UUID {
-	return ToValue(pgUUID.Valid, pgUUID.Bytes)
+	return ToValue(pgUUID.Valid, uuid.UUID(pgUUID.Bytes))
 }
 
 // StringToPgText converts a Go string to pgtype.Text, treating empty strings as NULL.
@@ -624,28 +624,116 @@ func PgUUIDToUUIDPtr(pgUUID pgtype.UUID) *uuid.UUID {
 	return ToPointer(pgUUID.Valid, uuid.UUID(pgUUID.Bytes))
 }
 
-// DecimalFromNumeric converts pgtype.Numeric to decimal.Decimal, returning
-// decimal.Zero for NULL values.
+// UUIDsToPgUUIDs converts []uuid.UUID to []pgtype.UUID, e.g. for
+// "WHERE id = ANY($1)" queries. A nil slice stays nil.
+//
+// Example:
+//
+//	rows, err := pool.Query(ctx, "SELECT * FROM users WHERE id = ANY($1)", pgxutil.UUIDsToPgUUIDs(ids))
+func UUIDsToPgUUIDs(ids []uuid.UUID) []pgtype.UUID {
+	if ids == nil {
+		return nil
+	}
+	pgUUIDs := make([]pgtype.UUID, len(ids))
+	for i, id := range ids {
+		pgUUIDs[i] = UUIDToPgUUID(id)
+	}
+	return pgUUIDs
+}
+
+// PgUUIDsToUUIDs converts []pgtype.UUID to []uuid.UUID, returning uuid.Nil
+// for NULL elements. A nil slice stays nil.
+func PgUUIDsToUUIDs(pgUUIDs []pgtype.UUID) []uuid.UUID {
+	if pgUUIDs == nil {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(pgUUIDs))
+	for i, pgUUID := range pgUUIDs {
+		ids[i] = PgUUIDToUUID(pgUUID)
+	}
+	return ids
+}
+
+// PgUUIDsToStrings converts []pgtype.UUID to their canonical string forms,
+// returning "" for NULL elements. A nil slice stays nil.
+//
+// Example:
+//
+//	resp.MemberIDs = pgxutil.PgUUIDsToStrings(row.MemberIds)
+func PgUUIDsToStrings(pgUUIDs []pgtype.UUID) []string {
+	if pgUUIDs == nil {
+		return nil
+	}
+	strs := make([]string, len(pgUUIDs))
+	for i, pgUUID := range pgUUIDs {
+		if pgUUID.Valid {
+			strs[i] = uuid.UUID(pgUUID.Bytes).String()
+		}
+	}
+	return strs
+}
+
+// PgUUIDMapToUUIDMap converts a map keyed by pgtype.UUID to one keyed by
+// uuid.UUID, dropping entries with NULL keys. A nil map stays nil.
+//
+// Example:
+//
+//	countsByUser := pgxutil.PgUUIDMapToUUIDMap(counts)
+func PgUUIDMapToUUIDMap[V any](m map[pgtype.UUID]V) map[uuid.UUID]V {
+	if m == nil {
+		return nil
+	}
+	out := make(map[uuid.UUID]V, len(m))
+	for k, v := range m {
+		if k.Valid {
+			out[uuid.UUID(k.Bytes)] = v
+		}
+	}
+	return out
+}
+
+// DecimalFromNumeric converts pgtype.Numeric to decimal.Decimal exactly,
+// returning decimal.Zero for NULL values and for NaN and infinity, which
+// decimal.Decimal cannot represent. For error-aware conversion use
+// DecimalFromNumericE.
 //
 // Example:
 //
 //	price := pgxutil.DecimalFromNumeric(row.Price)
 func DecimalFromNumeric(n pgtype.Numeric) decimal.Decimal {
+	d, _ := DecimalFromNumericE(n)
+	return d
+}
+
+// DecimalFromNumericE converts pgtype.Numeric to decimal.Decimal exactly,
+// from its unscaled integer and exponent, whatever its precision and scale.
+// It returns an error for NaN and infinity. Returns (decimal.Zero, nil) for
+// NULL values.
+//
+// Example:
+//
+//	price, err := pgxutil.DecimalFromNumericE(row.Price)
+//	if err != nil {
+//	    return fmt.Errorf("cannot convert price: %w", err)
+//	}
+func DecimalFromNumericE(n pgtype.Numeric) (decimal.Decimal, error) {
 	if !n.Valid {
-		return decimal.Zero
+		return decimal.Zero, nil
 	}
-	d, err := n.Value()
-	if err != nil || d == nil {
-		return decimal.Zero
+	if n.NaN {
+		return decimal.Zero, fmt.Errorf("decimalFromNumeric: NaN cannot be represented as decimal.Decimal")
 	}
-	result, err := decimal.NewFromString(fmt.Sprintf("%v", d))
-	if err != nil {
-		return decimal.Zero
+	if n.InfinityModifier != pgtype.Finite {
+		return decimal.Zero, fmt.Errorf("decimalFromNumeric: %s cannot be represented as decimal.Decimal", n.InfinityModifier)
+	}
+	if n.Int == nil {
+		return decimal.Zero, nil
 	}
-	return result
+	return decimal.NewFromBigInt(n.Int, n.Exp), nil
 }
 
-// NumericFromDecimal converts decimal.Decimal to pgtype.Numeric.
+// NumericFromDecimal converts decimal.Decimal to pgtype.Numeric exactly,
+// from its coefficient and exponent.
 // This is useful when inserting/updating NUMERIC/DECIMAL columns.
 //
 // Example:
@@ -654,11 +742,7 @@ func DecimalFromNumeric(n pgtype.Numeric) decimal.Decimal {
 //	    Price: pgxutil.NumericFromDecimal(price),
 //	}
 func NumericFromDecimal(d decimal.Decimal) pgtype.Numeric {
-	n := pgtype.Numeric{}
-	if err := n.Scan(d.String()); err != nil {
-		return pgtype.Numeric{Valid: false}
-	}
-	return n
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
 }
 
 // NumericFromDecimalPtr converts *decimal.Decimal to pgtype.Numeric,
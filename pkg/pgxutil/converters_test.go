@@ -172,6 +172,57 @@ func TestUUIDConversions(t *testing.T) {
 		result := PgUUIDToUUIDPtr(pg)
 		assert.Nil(t, result)
 	})
+
+	t.Run("PgUUIDToUUIDPtr round-trip", func(t *testing.T) {
+		result := PgUUIDToUUIDPtr(UUIDToPgUUIDPtr(&id))
+		require.NotNil(t, result)
+		assert.Equal(t, id, *result)
+		assert.NotSame(t, &id, result)
+
+		assert.Nil(t, PgUUIDToUUIDPtr(UUIDToPgUUIDPtr(nil)))
+	})
+
+	t.Run("PgUUIDToUUID null returns uuid.Nil", func(t *testing.T) {
+		assert.Equal(t, uuid.Nil, PgUUIDToUUID(pgtype.UUID{Bytes: id, Valid: false}))
+	})
+}
+
+func TestUUIDSliceConversions(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	t.Run("UUIDsToPgUUIDs", func(t *testing.T) {
+		pgUUIDs := UUIDsToPgUUIDs([]uuid.UUID{a, b})
+		assert.Equal(t, []pgtype.UUID{{Bytes: a, Valid: true}, {Bytes: b, Valid: true}}, pgUUIDs)
+
+		assert.Nil(t, UUIDsToPgUUIDs(nil))
+		assert.Equal(t, []pgtype.UUID{}, UUIDsToPgUUIDs([]uuid.UUID{}))
+	})
+
+	t.Run("PgUUIDsToUUIDs", func(t *testing.T) {
+		ids := PgUUIDsToUUIDs([]pgtype.UUID{{Bytes: a, Valid: true}, {Valid: false}})
+		assert.Equal(t, []uuid.UUID{a, uuid.Nil}, ids)
+
+		assert.Nil(t, PgUUIDsToUUIDs(nil))
+		assert.Equal(t, []uuid.UUID{a, b}, PgUUIDsToUUIDs(UUIDsToPgUUIDs([]uuid.UUID{a, b})))
+	})
+
+	t.Run("PgUUIDsToStrings", func(t *testing.T) {
+		strs := PgUUIDsToStrings([]pgtype.UUID{{Bytes: a, Valid: true}, {Valid: false}})
+		assert.Equal(t, []string{a.String(), ""}, strs)
+
+		assert.Nil(t, PgUUIDsToStrings(nil))
+	})
+
+	t.Run("PgUUIDMapToUUIDMap", func(t *testing.T) {
+		m := PgUUIDMapToUUIDMap(map[pgtype.UUID]int{
+			{Bytes: a, Valid: true}: 1,
+			{Bytes: b, Valid: true}: 2,
+			{Valid: false}:          3,
+		})
+		assert.Equal(t, map[uuid.UUID]int{a: 1, b: 2}, m)
+
+		assert.Nil(t, PgUUIDMapToUUIDMap[int](nil))
+	})
 }
 
 func TestDecimalConversions(t *testing.T) {
@@ -200,6 +251,54 @@ func TestDecimalConversions(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.True(t, d.Equal(*result))
 	})
+
+	t.Run("exact for large precision and scale", func(t *testing.T) {
+		values := []string{
+			"0",
+			"-0.000000000000000000000000000001",
+			"123456789012345678901234567890.123456789012345678901234567890",
+			"-98765432109876543210987654321098765432109876543210",
+			"10000000000000000000000000000000000000000",
+			"0.1",
+		}
+		for _, v := range values {
+			n := pgtype.Numeric{}
+			require.NoError(t, n.Scan(v))
+			got, err := DecimalFromNumericE(n)
+			require.NoError(t, err)
+			want := decimal.RequireFromString(v)
+			assert.True(t, want.Equal(got), "%s: got %s", v, got)
+
+			back := NumericFromDecimal(got)
+			text, err := back.Value()
+			require.NoError(t, err)
+			assert.True(t, want.Equal(decimal.RequireFromString(text.(string))), "%s: round-tripped to %s", v, text)
+		}
+	})
+
+	t.Run("NumericFromDecimal with positive exponent", func(t *testing.T) {
+		text, err := NumericFromDecimal(decimal.New(12, 30)).Value()
+		require.NoError(t, err)
+		assert.Equal(t, "12000000000000000000000000000000", text)
+	})
+
+	t.Run("NaN and infinity", func(t *testing.T) {
+		for _, n := range []pgtype.Numeric{
+			{NaN: true, Valid: true},
+			{InfinityModifier: pgtype.Infinity, Valid: true},
+			{InfinityModifier: pgtype.NegativeInfinity, Valid: true},
+		} {
+			_, err := DecimalFromNumericE(n)
+			assert.Error(t, err)
+			assert.True(t, DecimalFromNumeric(n).IsZero())
+		}
+	})
+
+	t.Run("null returns zero with no error", func(t *testing.T) {
+		result, err := DecimalFromNumericE(pgtype.Numeric{Valid: false})
+		assert.NoError(t, err)
+		assert.True(t, result.IsZero())
+	})
 }
 
 func TestIntervalConversions(t *testing.T) {
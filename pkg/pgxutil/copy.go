@@ -0,0 +1,145 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const defaultCopyBatchSize = 5000
+
+// Copier executes a COPY FROM; *pgx.Conn, pgx.Tx and *pgxpool.Pool implement
+// it.
+type Copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// CopyError reports the batch a bulk copy failed in.
+type CopyError struct {
+	Batch  int // Index of the failed batch
+	Offset int // Index of the first row of the batch in the slice
+	Rows   int // Number of rows in the batch
+	Err    error
+}
+
+func (e *CopyError) Error() string {
+	return fmt.Sprintf("pgxutil: copy batch %d (rows %d-%d): %v", e.Batch, e.Offset, e.Offset+e.Rows-1, e.Err)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// copyOptions holds the configuration of a bulk copy.
+type copyOptions struct {
+	batchSize int
+}
+
+// CopyOption is a functional option for configuring CopyFromSlice and
+// CopyFromStructs.
+type CopyOption func(*copyOptions)
+
+// WithBatchSize copies at most n rows per COPY; non-positive values copy all
+// rows at once. Default: 5000.
+func WithBatchSize(n int) CopyOption {
+	return func(o *copyOptions) {
+		o.batchSize = n
+	}
+}
+
+// CopyFromSlice bulk inserts rows into table with COPY, mapping every row to
+// the values of columns with mapRow, in batches of WithBatchSize rows. It
+// returns the number of rows copied; when a batch fails it stops, returning
+// the rows copied by the previous batches and a *CopyError. Batches are not
+// atomic together unless db is a transaction.
+//
+// Example:
+//
+//	n, err := pgxutil.CopyFromSlice(ctx, pool, pgx.Identifier{"users"},
+//	    []string{"id", "email"}, users,
+//	    func(u User) []any { return []any{u.ID, u.Email} },
+//	)
+func CopyFromSlice[T any](ctx context.Context, db Copier, table pgx.Identifier, columns []string, rows []T, mapRow func(T) []any, opts ...CopyOption) (int64, error) {
+	o := &copyOptions{batchSize: defaultCopyBatchSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	batchSize := o.batchSize
+	if batchSize <= 0 {
+		batchSize = len(rows)
+	}
+
+	var copied int64
+	for batch, offset := 0, 0; offset < len(rows); batch, offset = batch+1, offset+batchSize {
+		chunk := rows[offset:min(offset+batchSize, len(rows))]
+		n, err := db.CopyFrom(ctx, table, columns, pgx.CopyFromSlice(len(chunk), func(i int) ([]any, error) {
+			values := mapRow(chunk[i])
+			if len(values) != len(columns) {
+				return nil, fmt.Errorf("row %d: got %d values for %d columns", offset+i, len(values), len(columns))
+			}
+			return values, nil
+		}))
+		copied += n
+		if err != nil {
+			return copied, &CopyError{Batch: batch, Offset: offset, Rows: len(chunk), Err: err}
+		}
+	}
+	return copied, nil
+}
+
+// CopyFromStructs bulk inserts rows into table like CopyFromSlice, copying
+// the fields of T tagged with a db tag into the columns the tags name.
+// Fields without a db tag, or tagged "-", are skipped.
+//
+// Example:
+//
+//	type User struct {
+//	    ID    uuid.UUID `db:"id"`
+//	    Email string    `db:"email"`
+//	}
+//
+//	n, err := pgxutil.CopyFromStructs(ctx, pool, pgx.Identifier{"users"}, users)
+func CopyFromStructs[T any](ctx context.Context, db Copier, table pgx.Identifier, rows []T, opts ...CopyOption) (int64, error) {
+	columns, fields, err := structColumns(reflect.TypeFor[T]())
+	if err != nil {
+		return 0, err
+	}
+	return CopyFromSlice(ctx, db, table, columns, rows, func(row T) []any {
+		v := reflect.ValueOf(row)
+		values := make([]any, len(fields))
+		for i, field := range fields {
+			values[i] = v.Field(field).Interface()
+		}
+		return values
+	}, opts...)
+}
+
+// structColumns returns the columns of the db tags of the exported fields of
+// struct type t, and the indexes of their fields.
+func structColumns(t reflect.Type) ([]string, []int, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("pgxutil: %s is not a struct", t)
+	}
+
+	var columns []string
+	var fields []int
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column, _, _ := strings.Cut(field.Tag.Get("db"), ",")
+		if column == "" || column == "-" {
+			continue
+		}
+		columns = append(columns, column)
+		fields = append(fields, i)
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("pgxutil: %s has no fields with a db tag", t)
+	}
+	return columns, fields, nil
+}
@@ -0,0 +1,127 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCopier records the rows of every CopyFrom, failing the batch failBatch.
+type fakeCopier struct {
+	table     pgx.Identifier
+	columns   []string
+	batches   [][][]any
+	failBatch int
+}
+
+func (c *fakeCopier) CopyFrom(_ context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	c.table, c.columns = table, columns
+	batch := len(c.batches)
+	var rows [][]any
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, values)
+	}
+	c.batches = append(c.batches, rows)
+	if batch == c.failBatch {
+		return 0, errors.New("duplicate key")
+	}
+	return int64(len(rows)), nil
+}
+
+type copyUser struct {
+	ID       int    `db:"id"`
+	Email    string `db:"email,omitempty"`
+	Ignored  string `db:"-"`
+	Untagged string
+	secret   string `db:"secret"` //nolint:unused // Unexported fields are skipped.
+}
+
+func TestCopyFromSlice(t *testing.T) {
+	users := []copyUser{{ID: 1, Email: "a@example.com"}, {ID: 2, Email: "b@example.com"}, {ID: 3, Email: "c@example.com"}}
+	mapUser := func(u copyUser) []any { return []any{u.ID, u.Email} }
+
+	t.Run("copies in batches", func(t *testing.T) {
+		db := &fakeCopier{failBatch: -1}
+		n, err := CopyFromSlice(context.Background(), db, pgx.Identifier{"users"}, []string{"id", "email"}, users, mapUser, WithBatchSize(2))
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, pgx.Identifier{"users"}, db.table)
+		assert.Equal(t, [][][]any{
+			{{1, "a@example.com"}, {2, "b@example.com"}},
+			{{3, "c@example.com"}},
+		}, db.batches)
+	})
+
+	t.Run("copies at once without a batch size", func(t *testing.T) {
+		db := &fakeCopier{failBatch: -1}
+		n, err := CopyFromSlice(context.Background(), db, pgx.Identifier{"users"}, []string{"id", "email"}, users, mapUser, WithBatchSize(0))
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(3), n)
+		assert.Len(t, db.batches, 1)
+	})
+
+	t.Run("copies nothing for no rows", func(t *testing.T) {
+		db := &fakeCopier{failBatch: -1}
+		n, err := CopyFromSlice(context.Background(), db, pgx.Identifier{"users"}, []string{"id", "email"}, nil, mapUser)
+		require.NoError(t, err)
+
+		assert.Zero(t, n)
+		assert.Empty(t, db.batches)
+	})
+
+	t.Run("reports the failed batch", func(t *testing.T) {
+		db := &fakeCopier{failBatch: 1}
+		n, err := CopyFromSlice(context.Background(), db, pgx.Identifier{"users"}, []string{"id", "email"}, users, mapUser, WithBatchSize(2))
+
+		assert.Equal(t, int64(2), n)
+		var copyErr *CopyError
+		require.ErrorAs(t, err, &copyErr)
+		assert.Equal(t, 1, copyErr.Batch)
+		assert.Equal(t, 2, copyErr.Offset)
+		assert.Equal(t, 1, copyErr.Rows)
+		assert.EqualError(t, err, "pgxutil: copy batch 1 (rows 2-2): duplicate key")
+	})
+
+	t.Run("rejects rows not matching the columns", func(t *testing.T) {
+		db := &fakeCopier{failBatch: -1}
+		_, err := CopyFromSlice(context.Background(), db, pgx.Identifier{"users"}, []string{"id", "email"}, users,
+			func(u copyUser) []any { return []any{u.ID} })
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "row 0: got 1 values for 2 columns")
+	})
+}
+
+func TestCopyFromStructs(t *testing.T) {
+	t.Run("copies tagged fields", func(t *testing.T) {
+		db := &fakeCopier{failBatch: -1}
+		n, err := CopyFromStructs(context.Background(), db, pgx.Identifier{"app", "users"}, []copyUser{
+			{ID: 1, Email: "a@example.com", Ignored: "x", Untagged: "y"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), n)
+		assert.Equal(t, []string{"id", "email"}, db.columns)
+		assert.Equal(t, [][][]any{{{1, "a@example.com"}}}, db.batches)
+	})
+
+	t.Run("rejects non-struct types", func(t *testing.T) {
+		_, err := CopyFromStructs(context.Background(), &fakeCopier{}, pgx.Identifier{"users"}, []int{1})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects structs without db tags", func(t *testing.T) {
+		_, err := CopyFromStructs(context.Background(), &fakeCopier{}, pgx.Identifier{"users"}, []struct{ ID int }{{1}})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package pgxutil
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidEnum is returned by EnumText conversions of values outside the
+// allowed set.
+var ErrInvalidEnum = errors.New("pgxutil: invalid enum value")
+
+// EnumText converts a Go string-typed enum T to and from pgtype.Text,
+// validating values against an allowed set, for enum and constrained TEXT
+// columns. NULL maps to a nil *T.
+//
+// Example:
+//
+//	type Status string
+//
+//	const (
+//	    StatusPending Status = "pending"
+//	    StatusActive  Status = "active"
+//	)
+//
+//	var statusText = pgxutil.NewEnumText(StatusPending, StatusActive)
+//
+//	status, err := statusText.FromText(row.Status) // *Status, nil for NULL
+//	params.Status, err = statusText.Text(StatusActive)
+type EnumText[T ~string] struct {
+	allowed []T
+}
+
+// NewEnumText creates a converter accepting the allowed values.
+func NewEnumText[T ~string](allowed ...T) EnumText[T] {
+	return EnumText[T]{allowed: slices.Clone(allowed)}
+}
+
+// Values returns the allowed values, in the order given to NewEnumText.
+func (e EnumText[T]) Values() []T {
+	return slices.Clone(e.allowed)
+}
+
+// Valid reports whether v is an allowed value.
+func (e EnumText[T]) Valid(v T) bool {
+	return slices.Contains(e.allowed, v)
+}
+
+// Text converts v to pgtype.Text, returning ErrInvalidEnum when it is not an
+// allowed value.
+func (e EnumText[T]) Text(v T) (pgtype.Text, error) {
+	if !e.Valid(v) {
+		return pgtype.Text{}, fmt.Errorf("%w: %q", ErrInvalidEnum, v)
+	}
+	return pgtype.Text{String: string(v), Valid: true}, nil
+}
+
+// TextFromPtr converts v to pgtype.Text like Text, returning NULL for nil.
+func (e EnumText[T]) TextFromPtr(v *T) (pgtype.Text, error) {
+	if v == nil {
+		return pgtype.Text{Valid: false}, nil
+	}
+	return e.Text(*v)
+}
+
+// FromText converts pgtype.Text to *T, returning nil for NULL values and
+// ErrInvalidEnum for values that are not allowed, e.g. after a value was
+// added to the database but not the code.
+func (e EnumText[T]) FromText(t pgtype.Text) (*T, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	v := T(t.String)
+	if !e.Valid(v) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEnum, t.String)
+	}
+	return &v, nil
+}
@@ -0,0 +1,70 @@
+package pgxutil
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStatus string
+
+const (
+	testStatusPending testStatus = "pending"
+	testStatusActive  testStatus = "active"
+)
+
+func TestEnumText(t *testing.T) {
+	statusText := NewEnumText(testStatusPending, testStatusActive)
+
+	t.Run("Values", func(t *testing.T) {
+		values := statusText.Values()
+		assert.Equal(t, []testStatus{testStatusPending, testStatusActive}, values)
+
+		values[0] = "changed"
+		assert.True(t, statusText.Valid(testStatusPending))
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		text, err := statusText.Text(testStatusActive)
+		require.NoError(t, err)
+		assert.Equal(t, pgtype.Text{String: "active", Valid: true}, text)
+
+		_, err = statusText.Text("deleted")
+		assert.ErrorIs(t, err, ErrInvalidEnum)
+
+		_, err = statusText.Text("")
+		assert.ErrorIs(t, err, ErrInvalidEnum)
+	})
+
+	t.Run("TextFromPtr", func(t *testing.T) {
+		text, err := statusText.TextFromPtr(nil)
+		require.NoError(t, err)
+		assert.False(t, text.Valid)
+
+		status := testStatusPending
+		text, err = statusText.TextFromPtr(&status)
+		require.NoError(t, err)
+		assert.Equal(t, pgtype.Text{String: "pending", Valid: true}, text)
+
+		invalid := testStatus("deleted")
+		_, err = statusText.TextFromPtr(&invalid)
+		assert.ErrorIs(t, err, ErrInvalidEnum)
+	})
+
+	t.Run("FromText", func(t *testing.T) {
+		status, err := statusText.FromText(pgtype.Text{String: "active", Valid: true})
+		require.NoError(t, err)
+		require.NotNil(t, status)
+		assert.Equal(t, testStatusActive, *status)
+
+		status, err = statusText.FromText(pgtype.Text{Valid: false})
+		require.NoError(t, err)
+		assert.Nil(t, status)
+
+		_, err = statusText.FromText(pgtype.Text{String: "deleted", Valid: true})
+		assert.ErrorIs(t, err, ErrInvalidEnum)
+		assert.Contains(t, err.Error(), `"deleted"`)
+	})
+}
@@ -0,0 +1,301 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultListenerMinBackoff = time.Second
+	defaultListenerMaxBackoff = 30 * time.Second
+	defaultListenerBufferSize = 64
+	listenerCloseTimeout      = 5 * time.Second
+)
+
+// ErrListenerStopped is returned by Listen and Run once Run of the Listener
+// has returned.
+var ErrListenerStopped = errors.New("pgxutil: listener stopped")
+
+// Notification is a notification of a PostgreSQL channel, as sent by NOTIFY
+// or pg_notify.
+type Notification struct {
+	Channel string
+	Payload string
+	PID     uint32 // Backend process ID of the notifying session
+}
+
+// Listener receives PostgreSQL notifications on a dedicated connection and
+// delivers them on a Go channel per subscription, e.g. for cache
+// invalidation. It reconnects with exponential backoff when the connection
+// is lost, listening to its channels again; notifications sent while it is
+// disconnected are lost.
+type Listener struct {
+	config     *pgx.ConnConfig
+	logger     *slog.Logger
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	bufferSize int
+
+	mu          sync.Mutex
+	subscribers map[string][]chan Notification
+	interrupt   context.CancelFunc
+	running     bool
+	stopped     bool
+}
+
+// ListenerOption is a functional option for configuring the Listener.
+type ListenerOption func(*Listener)
+
+// WithListenerLogger sets a custom logger.
+func WithListenerLogger(logger *slog.Logger) ListenerOption {
+	return func(l *Listener) {
+		l.logger = logger
+	}
+}
+
+// WithReconnectBackoff sets the delay before the first reconnect attempt,
+// doubled after every failed attempt up to maxDelay.
+func WithReconnectBackoff(minDelay, maxDelay time.Duration) ListenerOption {
+	return func(l *Listener) {
+		l.minBackoff = minDelay
+		l.maxBackoff = maxDelay
+	}
+}
+
+// WithBufferSize sets how many notifications a subscription buffers. When
+// its buffer is full, further notifications are dropped, so a slow
+// subscriber never blocks the others.
+func WithBufferSize(n int) ListenerOption {
+	return func(l *Listener) {
+		l.bufferSize = n
+	}
+}
+
+// NewListener creates a listener connecting to dsn. It does not connect
+// until Run is called.
+// Default configuration:
+//   - Reconnect backoff: 1s doubling up to 30s
+//   - Buffer size: 64 notifications per subscription
+//   - Logger: slog.Default()
+//
+// Example:
+//
+//	listener, err := pgxutil.NewListener(os.Getenv("DATABASE_URL"))
+//	if err != nil {
+//	    return err
+//	}
+//	invalidations, err := listener.Listen("cache_invalidation")
+//	if err != nil {
+//	    return err
+//	}
+//	go listener.Run(ctx)
+//	for n := range invalidations {
+//	    cache.Delete(n.Payload)
+//	}
+func NewListener(dsn string, opts ...ListenerOption) (*Listener, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("pgxutil: parse DSN: %w", err)
+	}
+
+	l := &Listener{
+		config:      config,
+		logger:      slog.Default(),
+		minBackoff:  defaultListenerMinBackoff,
+		maxBackoff:  defaultListenerMaxBackoff,
+		bufferSize:  defaultListenerBufferSize,
+		subscribers: make(map[string][]chan Notification),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if l.minBackoff <= 0 {
+		l.logger.Warn("invalid reconnect backoff, using default", "backoff", l.minBackoff)
+		l.minBackoff = defaultListenerMinBackoff
+	}
+	if l.maxBackoff < l.minBackoff {
+		l.logger.Warn("invalid maximum reconnect backoff, using minimum", "backoff", l.maxBackoff)
+		l.maxBackoff = l.minBackoff
+	}
+	if l.bufferSize < 0 {
+		l.logger.Warn("invalid buffer size, using default", "size", l.bufferSize)
+		l.bufferSize = defaultListenerBufferSize
+	}
+
+	return l, nil
+}
+
+// Listen subscribes to notifications of channel, listening to it on the
+// connection from then on. The returned Go channel is closed when Run
+// returns. Listen may be called before or while Run runs.
+func (l *Listener) Listen(channel string) (<-chan Notification, error) {
+	if channel == "" {
+		return nil, errors.New("pgxutil: channel name is required")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stopped {
+		return nil, ErrListenerStopped
+	}
+	ch := make(chan Notification, l.bufferSize)
+	l.subscribers[channel] = append(l.subscribers[channel], ch)
+	if l.interrupt != nil {
+		// Wake Run to LISTEN the new channel.
+		l.interrupt()
+	}
+	return ch, nil
+}
+
+// ListenJSON subscribes to notifications of channel like Listen, decoding
+// their payloads as JSON into T. Payloads that do not decode are logged and
+// dropped.
+func ListenJSON[T any](l *Listener, channel string) (<-chan T, error) {
+	notifications, err := l.Listen(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan T, l.bufferSize)
+	go func() {
+		defer close(out)
+		for n := range notifications {
+			var v T
+			if err := json.Unmarshal([]byte(n.Payload), &v); err != nil {
+				l.logger.Warn("dropping undecodable notification", "channel", n.Channel, "error", err)
+				continue
+			}
+			out <- v
+		}
+	}()
+	return out, nil
+}
+
+// Run connects and delivers notifications until ctx is canceled (blocking),
+// reconnecting whenever the connection is lost. It returns nil when ctx is
+// canceled and closes the channels of all subscriptions. A Listener runs
+// once.
+func (l *Listener) Run(ctx context.Context) error {
+	l.mu.Lock()
+	if l.running || l.stopped {
+		l.mu.Unlock()
+		return ErrListenerStopped
+	}
+	l.running = true
+	l.mu.Unlock()
+	defer l.stop()
+
+	backoff := l.minBackoff
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = l.minBackoff
+		}
+		l.logger.Warn("listener connection lost, reconnecting", "error", err, "backoff", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// listen connects and delivers notifications until ctx is canceled or the
+// connection fails, reporting whether it connected.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, l.config)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), listenerCloseTimeout)
+		defer cancel()
+		_ = conn.Close(closeCtx)
+	}()
+	l.logger.Info("listener connected")
+
+	listening := make(map[string]bool)
+	for {
+		waitCtx, cancel := context.WithCancel(ctx)
+		for _, channel := range l.pending(listening, cancel) {
+			if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+				cancel()
+				return true, fmt.Errorf("listen %s: %w", channel, err)
+			}
+		}
+
+		n, err := conn.WaitForNotification(waitCtx)
+		interrupted := waitCtx.Err() != nil
+		cancel()
+		if err != nil {
+			if interrupted && ctx.Err() == nil {
+				continue
+			}
+			return true, err
+		}
+		l.deliver(Notification{Channel: n.Channel, Payload: n.Payload, PID: n.PID})
+	}
+}
+
+// pending returns the subscribed channels not yet in listening, adding them,
+// and makes Listen call interrupt for channels subscribed afterwards.
+func (l *Listener) pending(listening map[string]bool, interrupt context.CancelFunc) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var channels []string
+	for channel := range l.subscribers {
+		if !listening[channel] {
+			listening[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	l.interrupt = interrupt
+	return channels
+}
+
+// deliver sends n to the subscribers of its channel, dropping it for those
+// whose buffer is full.
+func (l *Listener) deliver(n Notification) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, ch := range l.subscribers[n.Channel] {
+		select {
+		case ch <- n:
+		default:
+			l.logger.Warn("dropping notification, subscriber buffer full", "channel", n.Channel)
+		}
+	}
+}
+
+// stop closes the channels of all subscriptions.
+func (l *Listener) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopped = true
+	l.interrupt = nil
+	for channel, subscribers := range l.subscribers {
+		for _, ch := range subscribers {
+			close(ch)
+		}
+		delete(l.subscribers, channel)
+	}
+}
@@ -0,0 +1,142 @@
+package pgxutil
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableDSN points at a port nothing listens on, so connecting fails
+// fast.
+const unreachableDSN = "postgres://user@127.0.0.1:1/app?connect_timeout=1"
+
+func newTestListener(t *testing.T, opts ...ListenerOption) *Listener {
+	t.Helper()
+	l, err := NewListener(unreachableDSN, append([]ListenerOption{WithListenerLogger(slog.New(slog.DiscardHandler))}, opts...)...)
+	require.NoError(t, err)
+	return l
+}
+
+func TestNewListener(t *testing.T) {
+	t.Run("rejects an invalid DSN", func(t *testing.T) {
+		_, err := NewListener("postgres://user@localhost:5432/app?connect_timeout=x")
+		require.Error(t, err)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		l := newTestListener(t)
+
+		assert.Equal(t, defaultListenerMinBackoff, l.minBackoff)
+		assert.Equal(t, defaultListenerMaxBackoff, l.maxBackoff)
+		assert.Equal(t, defaultListenerBufferSize, l.bufferSize)
+	})
+
+	t.Run("corrects invalid options", func(t *testing.T) {
+		var buf bytes.Buffer
+		l, err := NewListener(unreachableDSN,
+			WithListenerLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+			WithReconnectBackoff(0, -time.Second),
+			WithBufferSize(-1),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, defaultListenerMinBackoff, l.minBackoff)
+		assert.Equal(t, defaultListenerMinBackoff, l.maxBackoff)
+		assert.Equal(t, defaultListenerBufferSize, l.bufferSize)
+		assert.Contains(t, buf.String(), "invalid reconnect backoff")
+		assert.Contains(t, buf.String(), "invalid buffer size")
+	})
+}
+
+func TestListener_Listen(t *testing.T) {
+	t.Run("requires a channel name", func(t *testing.T) {
+		_, err := newTestListener(t).Listen("")
+		require.Error(t, err)
+	})
+
+	t.Run("delivers to every subscriber of the channel", func(t *testing.T) {
+		l := newTestListener(t)
+		first, err := l.Listen("orders")
+		require.NoError(t, err)
+		second, err := l.Listen("orders")
+		require.NoError(t, err)
+		other, err := l.Listen("users")
+		require.NoError(t, err)
+
+		l.deliver(Notification{Channel: "orders", Payload: "42", PID: 7})
+
+		want := Notification{Channel: "orders", Payload: "42", PID: 7}
+		assert.Equal(t, want, <-first)
+		assert.Equal(t, want, <-second)
+		assert.Empty(t, other)
+	})
+
+	t.Run("drops notifications when the buffer is full", func(t *testing.T) {
+		l := newTestListener(t, WithBufferSize(1))
+		ch, err := l.Listen("orders")
+		require.NoError(t, err)
+
+		l.deliver(Notification{Channel: "orders", Payload: "1"})
+		l.deliver(Notification{Channel: "orders", Payload: "2"})
+
+		assert.Equal(t, "1", (<-ch).Payload)
+		assert.Empty(t, ch)
+	})
+}
+
+func TestListenJSON(t *testing.T) {
+	type invalidation struct {
+		Key string `json:"key"`
+	}
+
+	l := newTestListener(t)
+	ch, err := ListenJSON[invalidation](l, "cache")
+	require.NoError(t, err)
+
+	l.deliver(Notification{Channel: "cache", Payload: "not json"})
+	l.deliver(Notification{Channel: "cache", Payload: `{"key":"user:42"}`})
+
+	select {
+	case v := <-ch:
+		assert.Equal(t, invalidation{Key: "user:42"}, v)
+	case <-time.After(time.Second):
+		t.Fatal("no notification decoded")
+	}
+
+	l.stop()
+	_, ok := <-ch
+	assert.False(t, ok, "channel should be closed when the listener stops")
+}
+
+func TestListener_Run(t *testing.T) {
+	l := newTestListener(t, WithReconnectBackoff(10*time.Millisecond, 20*time.Millisecond))
+	ch, err := l.Listen("orders")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Run(ctx) }()
+
+	// Let it fail to connect and back off a few times.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	_, ok := <-ch
+	assert.False(t, ok, "channel should be closed when Run returns")
+
+	_, err = l.Listen("orders")
+	require.ErrorIs(t, err, ErrListenerStopped)
+	require.ErrorIs(t, l.Run(context.Background()), ErrListenerStopped)
+}
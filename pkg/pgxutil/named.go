@@ -0,0 +1,224 @@
+package pgxutil
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Named rewrites the :name placeholders of sql into positional $N
+// parameters, returning the rewritten SQL and the arguments in parameter
+// order. A name used several times binds one parameter. Placeholders inside
+// string literals, including E'...' strings with backslash escapes, quoted
+// identifiers, dollar-quoted strings and comments, and :: casts, are left
+// alone. Inside [...], a : following a name, number or closing bracket
+// separates the bounds of an array slice, e.g. arr[lo:hi], while one
+// following [ or another : starts a placeholder, e.g. arr[:idx]; write an
+// upper-bound-only slice as arr[1:hi]. Names missing from args, and args no
+// placeholder uses, e.g. because one of them is misspelled, are an error;
+// NamedLenient binds missing names to NULL instead.
+//
+// Example:
+//
+//	sql, args, err := pgxutil.Named(
+//	    "SELECT * FROM orders WHERE customer_id = :customer AND created_at >= :since::date",
+//	    map[string]any{"customer": customerID, "since": since},
+//	)
+//	if err != nil {
+//	    return fmt.Errorf("cannot build query: %w", err)
+//	}
+//	rows, err := pool.Query(ctx, sql, args...)
+func Named(sql string, args map[string]any) (string, []any, error) {
+	query, values, params, missing := rewriteNamed(sql, args)
+
+	var unknown []string
+	for name := range args {
+		if _, ok := params[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+
+	var errs []error
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("pgxutil: missing named arguments: %s", strings.Join(missing, ", ")))
+	}
+	if len(unknown) > 0 {
+		errs = append(errs, fmt.Errorf("pgxutil: unknown named arguments: %s", strings.Join(unknown, ", ")))
+	}
+	if len(errs) > 0 {
+		return query, values, errors.Join(errs...)
+	}
+	return query, values, nil
+}
+
+// NamedLenient rewrites the :name placeholders of sql like Named, binding
+// names missing from args to NULL and ignoring args no placeholder uses.
+//
+// Example:
+//
+//	sql, args := pgxutil.NamedLenient("UPDATE notes SET body = :body WHERE id = :id", params)
+func NamedLenient(sql string, args map[string]any) (string, []any) {
+	query, values, _, _ := rewriteNamed(sql, args)
+	return query, values
+}
+
+// rewriteNamed rewrites the :name placeholders of sql, returning the
+// rewritten SQL, the arguments in parameter order, the parameter number of
+// every name, and the names missing from args in order of first use.
+func rewriteNamed(sql string, args map[string]any) (string, []any, map[string]int, []string) {
+	var (
+		b       strings.Builder
+		values  []any
+		params  = make(map[string]int)
+		missing []string
+		depth   int // of [...] subscripts
+	)
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case (c == 'E' || c == 'e') && i+1 < len(sql) && sql[i+1] == '\'' && (i == 0 || !isNamePart(sql[i-1])):
+			end := escapeStringEnd(sql, i+1)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == '\'' || c == '"':
+			end := quotedEnd(sql, i, c)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			b.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(sql[i : i+end])
+			i += end
+		case c == '$':
+			end := dollarQuotedEnd(sql, i)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == ':' && strings.HasPrefix(sql[i:], "::"):
+			b.WriteString("::")
+			i += 2
+		case c == '[' || c == ']':
+			if c == '[' {
+				depth++
+			} else if depth > 0 {
+				depth--
+			}
+			b.WriteByte(c)
+			i++
+		case c == ':' && i+1 < len(sql) && isNameStart(sql[i+1]) && !(depth > 0 && isSliceBound(sql, i)):
+			end := i + 2
+			for end < len(sql) && isNamePart(sql[end]) {
+				end++
+			}
+			name := sql[i+1 : end]
+			n, ok := params[name]
+			if !ok {
+				value, found := args[name]
+				if !found {
+					missing = append(missing, name)
+				}
+				values = append(values, value)
+				n = len(values)
+				params[name] = n
+			}
+			b.WriteString("$" + strconv.Itoa(n))
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), values, params, missing
+}
+
+// quotedEnd returns the index after the string literal or quoted identifier
+// starting with quote at start, where a doubled quote is escaped.
+func quotedEnd(sql string, start int, quote byte) int {
+	for i := start + 1; i < len(sql); i++ {
+		if sql[i] != quote {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(sql)
+}
+
+// escapeStringEnd returns the index after the E'...' string whose quote is
+// at start, where a backslash or a doubled quote escapes the next quote.
+func escapeStringEnd(sql string, start int) int {
+	for i := start + 1; i < len(sql); i++ {
+		switch {
+		case sql[i] == '\\':
+			i++
+		case sql[i] != '\'':
+		case i+1 < len(sql) && sql[i+1] == '\'':
+			i++
+		default:
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// isSliceBound reports whether the : at i inside [...] separates the bounds
+// of an array slice, i.e. follows a name, number or closing bracket rather
+// than [ or another :.
+func isSliceBound(sql string, i int) bool {
+	for i--; i >= 0; i-- {
+		switch c := sql[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case isNamePart(c) || c == ')' || c == ']' || c == '"':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// dollarQuotedEnd returns the index after the dollar-quoted string starting
+// at start, e.g. $$...$$ or $tag$...$tag$, or start+1 for any other $, e.g. of
+// a positional parameter.
+func dollarQuotedEnd(sql string, start int) int {
+	i := start + 1
+	for i < len(sql) && isNamePart(sql[i]) {
+		i++
+	}
+	if i >= len(sql) || sql[i] != '$' || (i > start+1 && !isNameStart(sql[start+1])) {
+		return start + 1
+	}
+	tag := sql[start : i+1]
+	end := strings.Index(sql[i+1:], tag)
+	if end < 0 {
+		return len(sql)
+	}
+	return i + 1 + end + len(tag)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
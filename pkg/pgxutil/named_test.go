@@ -0,0 +1,119 @@
+package pgxutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     map[string]any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "positional parameters in order of first use",
+			sql:      "SELECT * FROM users WHERE org_id = :org AND status = :status",
+			args:     map[string]any{"status": "active", "org": 7},
+			wantSQL:  "SELECT * FROM users WHERE org_id = $1 AND status = $2",
+			wantArgs: []any{7, "active"},
+		},
+		{
+			name:     "repeated names bind one parameter",
+			sql:      "SELECT * FROM users WHERE :q = '' OR name ILIKE :q OR email ILIKE :q",
+			args:     map[string]any{"q": "%ann%"},
+			wantSQL:  "SELECT * FROM users WHERE $1 = '' OR name ILIKE $1 OR email ILIKE $1",
+			wantArgs: []any{"%ann%"},
+		},
+		{
+			name:     "casts",
+			sql:      "SELECT :since::date, id::text FROM t",
+			args:     map[string]any{"since": "2024-01-01"},
+			wantSQL:  "SELECT $1::date, id::text FROM t",
+			wantArgs: []any{"2024-01-01"},
+		},
+		{
+			name:     "string literals and quoted identifiers",
+			sql:      `SELECT 'it''s :not', "col:umn" FROM t WHERE a = :a`,
+			args:     map[string]any{"a": 1},
+			wantSQL:  `SELECT 'it''s :not', "col:umn" FROM t WHERE a = $1`,
+			wantArgs: []any{1},
+		},
+		{
+			name:     "comments",
+			sql:      "SELECT 1 -- :line\n/* :block */ WHERE a = :a",
+			args:     map[string]any{"a": 1},
+			wantSQL:  "SELECT 1 -- :line\n/* :block */ WHERE a = $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "escape strings",
+			sql:      `SELECT E'it\'s :not', e'\\', :a, name' :b'`,
+			args:     map[string]any{"a": 1},
+			wantSQL:  `SELECT E'it\'s :not', e'\\', $1, name' :b'`,
+			wantArgs: []any{1},
+		},
+		{
+			name:     "dollar-quoted strings",
+			sql:      "SELECT $$:a$$, $fn$ :b $fn$, :c",
+			args:     map[string]any{"c": 3},
+			wantSQL:  "SELECT $$:a$$, $fn$ :b $fn$, $1",
+			wantArgs: []any{3},
+		},
+		{
+			name:     "array slices and no placeholders",
+			sql:      "SELECT tags[1:2] FROM t",
+			args:     nil,
+			wantSQL:  "SELECT tags[1:2] FROM t",
+			wantArgs: nil,
+		},
+		{
+			name:     "array slices by column bounds",
+			sql:      "SELECT arr[lo:hi], arr[lo : hi], grid[1:2][lo:hi] FROM t WHERE a = :a",
+			args:     map[string]any{"a": 1},
+			wantSQL:  "SELECT arr[lo:hi], arr[lo : hi], grid[1:2][lo:hi] FROM t WHERE a = $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "placeholders in subscripts",
+			sql:      "SELECT arr[:idx], arr[:lo : :hi], arr[lo:hi + :n] FROM t",
+			args:     map[string]any{"idx": 1, "lo": 2, "hi": 3, "n": 4},
+			wantSQL:  "SELECT arr[$1], arr[$2 : $3], arr[lo:hi + $4] FROM t",
+			wantArgs: []any{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := Named(tt.sql, tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, sql)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestNamed_missingAndUnknownNames(t *testing.T) {
+	_, _, err := Named("SELECT :a, :b, :c, :b", map[string]any{"a": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing named arguments: b, c")
+
+	_, _, err = Named("SELECT * FROM t WHERE org_id = :org", map[string]any{"org": 1, "orgg": 2, "extra": 3})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown named arguments: extra, orgg")
+
+	_, _, err = Named("SELECT :org_id", map[string]any{"orgid": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing named arguments: org_id")
+	assert.Contains(t, err.Error(), "unknown named arguments: orgid")
+}
+
+func TestNamedLenient(t *testing.T) {
+	sql, args := NamedLenient("UPDATE t SET note = :note, body = :body", map[string]any{"body": "b", "unused": 1})
+	assert.Equal(t, "UPDATE t SET note = $1, body = $2", sql)
+	assert.Equal(t, []any{nil, "b"}, args)
+}
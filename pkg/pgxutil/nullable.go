@@ -0,0 +1,165 @@
+package pgxutil
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oapi-codegen/nullable"
+	"github.com/shopspring/decimal"
+)
+
+// These functions convert directly between pgtype values and
+// nullable.Nullable[T], keeping the three states of a JSON field apart: a
+// NULL column is an explicit null, not an unspecified value, and an
+// unspecified PATCH field is reported as not set instead of becoming NULL.
+
+// ToNullable converts a nullable database value to nullable.Nullable[T],
+// returning an explicit null for NULL values.
+//
+// Example:
+//
+//	resp.Nickname = pgxutil.ToNullable(row.Nickname.Valid, row.Nickname.String)
+func ToNullable[T any](valid bool, value T) nullable.Nullable[T] {
+	if !valid {
+		return nullable.NewNullNullable[T]()
+	}
+	return nullable.NewNullableWithValue(value)
+}
+
+// fromNullable returns the value of n, whether it is a value rather than
+// null, and whether it is specified at all.
+func fromNullable[T any](n nullable.Nullable[T]) (value T, valid, set bool) {
+	if !n.IsSpecified() {
+		return value, false, false
+	}
+	if n.IsNull() {
+		return value, false, true
+	}
+	return n.MustGet(), true, true
+}
+
+// NullableFromText converts pgtype.Text to nullable.Nullable[string],
+// returning an explicit null for NULL values.
+//
+// Example:
+//
+//	resp.Nickname = pgxutil.NullableFromText(row.Nickname)
+func NullableFromText(t pgtype.Text) nullable.Nullable[string] {
+	return ToNullable(t.Valid, t.String)
+}
+
+// TextFromNullable converts nullable.Nullable[string] to pgtype.Text for a
+// PATCH update, reporting whether the field was specified; an explicit null
+// is a NULL pgtype.Text that is set.
+//
+// Example:
+//
+//	// UPDATE users SET nickname = CASE WHEN @nickname_set::bool THEN @nickname ELSE nickname END
+//	params.Nickname, params.NicknameSet = pgxutil.TextFromNullable(req.Nickname)
+func TextFromNullable(n nullable.Nullable[string]) (pgtype.Text, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Text{String: v, Valid: valid}, set
+}
+
+// NullableFromInt4 converts pgtype.Int4 to nullable.Nullable[int32],
+// returning an explicit null for NULL values.
+func NullableFromInt4(val pgtype.Int4) nullable.Nullable[int32] {
+	return ToNullable(val.Valid, val.Int32)
+}
+
+// Int4FromNullable converts nullable.Nullable[int32] to pgtype.Int4,
+// reporting whether the field was specified.
+func Int4FromNullable(n nullable.Nullable[int32]) (pgtype.Int4, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Int4{Int32: v, Valid: valid}, set
+}
+
+// NullableFromInt8 converts pgtype.Int8 to nullable.Nullable[int64],
+// returning an explicit null for NULL values.
+func NullableFromInt8(val pgtype.Int8) nullable.Nullable[int64] {
+	return ToNullable(val.Valid, val.Int64)
+}
+
+// Int8FromNullable converts nullable.Nullable[int64] to pgtype.Int8,
+// reporting whether the field was specified.
+func Int8FromNullable(n nullable.Nullable[int64]) (pgtype.Int8, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Int8{Int64: v, Valid: valid}, set
+}
+
+// NullableFromBool converts pgtype.Bool to nullable.Nullable[bool],
+// returning an explicit null for NULL values.
+func NullableFromBool(val pgtype.Bool) nullable.Nullable[bool] {
+	return ToNullable(val.Valid, val.Bool)
+}
+
+// BoolFromNullable converts nullable.Nullable[bool] to pgtype.Bool,
+// reporting whether the field was specified.
+func BoolFromNullable(n nullable.Nullable[bool]) (pgtype.Bool, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Bool{Bool: v, Valid: valid}, set
+}
+
+// NullableFromFloat8 converts pgtype.Float8 to nullable.Nullable[float64],
+// returning an explicit null for NULL values.
+func NullableFromFloat8(val pgtype.Float8) nullable.Nullable[float64] {
+	return ToNullable(val.Valid, val.Float64)
+}
+
+// Float8FromNullable converts nullable.Nullable[float64] to pgtype.Float8,
+// reporting whether the field was specified.
+func Float8FromNullable(n nullable.Nullable[float64]) (pgtype.Float8, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Float8{Float64: v, Valid: valid}, set
+}
+
+// NullableFromTimestamptz converts pgtype.Timestamptz to
+// nullable.Nullable[time.Time], returning an explicit null for NULL values.
+func NullableFromTimestamptz(t pgtype.Timestamptz) nullable.Nullable[time.Time] {
+	return ToNullable(t.Valid, t.Time)
+}
+
+// TimestamptzFromNullable converts nullable.Nullable[time.Time] to
+// pgtype.Timestamptz, reporting whether the field was specified.
+func TimestamptzFromNullable(n nullable.Nullable[time.Time]) (pgtype.Timestamptz, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.Timestamptz{Time: v, Valid: valid}, set
+}
+
+// NullableFromUUID converts pgtype.UUID to nullable.Nullable[uuid.UUID],
+// returning an explicit null for NULL values.
+func NullableFromUUID(pgUUID pgtype.UUID) nullable.Nullable[uuid.UUID] {
+	return ToNullable(pgUUID.Valid, uuid.UUID(pgUUID.Bytes))
+}
+
+// UUIDFromNullable converts nullable.Nullable[uuid.UUID] to pgtype.UUID,
+// reporting whether the field was specified.
+func UUIDFromNullable(n nullable.Nullable[uuid.UUID]) (pgtype.UUID, bool) {
+	v, valid, set := fromNullable(n)
+	return pgtype.UUID{Bytes: v, Valid: valid}, set
+}
+
+// NullableFromNumeric converts pgtype.Numeric to
+// nullable.Nullable[decimal.Decimal] exactly, returning an explicit null for
+// NULL values and an error for NaN and infinity.
+func NullableFromNumeric(n pgtype.Numeric) (nullable.Nullable[decimal.Decimal], error) {
+	if !n.Valid {
+		return nullable.NewNullNullable[decimal.Decimal](), nil
+	}
+	d, err := DecimalFromNumericE(n)
+	if err != nil {
+		return nil, err
+	}
+	return nullable.NewNullableWithValue(d), nil
+}
+
+// NumericFromNullable converts nullable.Nullable[decimal.Decimal] to
+// pgtype.Numeric exactly, reporting whether the field was specified.
+func NumericFromNullable(n nullable.Nullable[decimal.Decimal]) (pgtype.Numeric, bool) {
+	v, valid, set := fromNullable(n)
+	if !valid {
+		return pgtype.Numeric{Valid: false}, set
+	}
+	return NumericFromDecimal(v), set
+}
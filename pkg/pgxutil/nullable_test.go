@@ -0,0 +1,132 @@
+package pgxutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oapi-codegen/nullable"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToNullable(t *testing.T) {
+	n := ToNullable(true, "x")
+	assert.Equal(t, "x", n.MustGet())
+
+	n = ToNullable(false, "x")
+	assert.True(t, n.IsSpecified())
+	assert.True(t, n.IsNull())
+}
+
+func TestTextNullable(t *testing.T) {
+	t.Run("from pgtype", func(t *testing.T) {
+		assert.Equal(t, "hello", NullableFromText(pgtype.Text{String: "hello", Valid: true}).MustGet())
+
+		n := NullableFromText(pgtype.Text{Valid: false})
+		assert.True(t, n.IsNull(), "NULL should be an explicit null, not unspecified")
+
+		data, err := n.MarshalJSON()
+		require.NoError(t, err)
+		assert.Equal(t, "null", string(data))
+	})
+
+	t.Run("to pgtype", func(t *testing.T) {
+		text, set := TextFromNullable(nullable.NewNullableWithValue("hello"))
+		assert.True(t, set)
+		assert.Equal(t, pgtype.Text{String: "hello", Valid: true}, text)
+
+		text, set = TextFromNullable(nullable.NewNullNullable[string]())
+		assert.True(t, set, "an explicit null should be set")
+		assert.False(t, text.Valid)
+
+		text, set = TextFromNullable(nullable.Nullable[string]{})
+		assert.False(t, set, "an unspecified value should not be set")
+		assert.False(t, text.Valid)
+	})
+}
+
+func TestNullableConversions(t *testing.T) {
+	t.Run("Int4", func(t *testing.T) {
+		assert.Equal(t, int32(7), NullableFromInt4(pgtype.Int4{Int32: 7, Valid: true}).MustGet())
+		assert.True(t, NullableFromInt4(pgtype.Int4{}).IsNull())
+
+		v, set := Int4FromNullable(nullable.NewNullableWithValue[int32](7))
+		assert.True(t, set)
+		assert.Equal(t, pgtype.Int4{Int32: 7, Valid: true}, v)
+	})
+
+	t.Run("Int8", func(t *testing.T) {
+		assert.Equal(t, int64(7), NullableFromInt8(pgtype.Int8{Int64: 7, Valid: true}).MustGet())
+
+		v, set := Int8FromNullable(nullable.NewNullNullable[int64]())
+		assert.True(t, set)
+		assert.False(t, v.Valid)
+	})
+
+	t.Run("Bool", func(t *testing.T) {
+		// false is a value, not a null.
+		n := NullableFromBool(pgtype.Bool{Bool: false, Valid: true})
+		assert.False(t, n.IsNull())
+		assert.False(t, n.MustGet())
+
+		v, set := BoolFromNullable(nullable.NewNullableWithValue(false))
+		assert.True(t, set)
+		assert.Equal(t, pgtype.Bool{Bool: false, Valid: true}, v)
+	})
+
+	t.Run("Float8", func(t *testing.T) {
+		assert.InDelta(t, 1.5, NullableFromFloat8(pgtype.Float8{Float64: 1.5, Valid: true}).MustGet(), 0)
+
+		_, set := Float8FromNullable(nullable.Nullable[float64]{})
+		assert.False(t, set)
+	})
+
+	t.Run("Timestamptz", func(t *testing.T) {
+		now := time.Now().UTC()
+		assert.Equal(t, now, NullableFromTimestamptz(pgtype.Timestamptz{Time: now, Valid: true}).MustGet())
+
+		v, set := TimestamptzFromNullable(nullable.NewNullableWithValue(now))
+		assert.True(t, set)
+		assert.Equal(t, pgtype.Timestamptz{Time: now, Valid: true}, v)
+	})
+
+	t.Run("UUID", func(t *testing.T) {
+		id := uuid.New()
+		assert.Equal(t, id, NullableFromUUID(pgtype.UUID{Bytes: id, Valid: true}).MustGet())
+		assert.True(t, NullableFromUUID(pgtype.UUID{}).IsNull())
+
+		v, set := UUIDFromNullable(nullable.NewNullableWithValue(id))
+		assert.True(t, set)
+		assert.Equal(t, pgtype.UUID{Bytes: id, Valid: true}, v)
+	})
+
+	t.Run("Numeric", func(t *testing.T) {
+		d := decimal.RequireFromString("12345678901234567890.123456789")
+		n, err := NullableFromNumeric(NumericFromDecimal(d))
+		require.NoError(t, err)
+		assert.True(t, d.Equal(n.MustGet()))
+
+		n, err = NullableFromNumeric(pgtype.Numeric{})
+		require.NoError(t, err)
+		assert.True(t, n.IsNull())
+
+		_, err = NullableFromNumeric(pgtype.Numeric{NaN: true, Valid: true})
+		assert.Error(t, err)
+		_, err = NullableFromNumeric(pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true})
+		assert.Error(t, err)
+
+		v, set := NumericFromNullable(nullable.NewNullableWithValue(d))
+		assert.True(t, set)
+		assert.True(t, d.Equal(DecimalFromNumeric(v)))
+
+		v, set = NumericFromNullable(nullable.NewNullNullable[decimal.Decimal]())
+		assert.True(t, set)
+		assert.False(t, v.Valid)
+
+		_, set = NumericFromNullable(nullable.Nullable[decimal.Decimal]{})
+		assert.False(t, set)
+	})
+}
@@ -0,0 +1,153 @@
+package pgxutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned by DecodeCursor for cursors it did not
+// encode.
+var ErrInvalidCursor = errors.New("pgxutil: invalid cursor")
+
+// Direction is the direction a cursor pages in.
+type Direction int
+
+const (
+	// Forward pages to the rows after the key of the cursor.
+	Forward Direction = iota
+	// Backward pages to the rows before the key of the cursor.
+	Backward
+)
+
+// Cursor is a decoded keyset pagination cursor: the sort key of the row a
+// page starts after (Forward) or ends before (Backward).
+type Cursor[K any] struct {
+	Key       K
+	Direction Direction
+}
+
+// cursorPayload is the JSON of an encoded cursor.
+type cursorPayload[K any] struct {
+	Key      K    `json:"k"`
+	Backward bool `json:"b,omitempty"`
+}
+
+// EncodeCursor encodes the sort key of a row, e.g. a struct of its creation
+// time and ID, into an opaque URL-safe cursor paging in dir.
+func EncodeCursor[K any](key K, dir Direction) (string, error) {
+	data, err := json.Marshal(cursorPayload[K]{Key: key, Backward: dir == Backward})
+	if err != nil {
+		return "", fmt.Errorf("pgxutil: encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor of EncodeCursor, returning ErrInvalidCursor
+// when it is malformed.
+func DecodeCursor[K any](cursor string) (Cursor[K], error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor[K]{}, ErrInvalidCursor
+	}
+	var payload cursorPayload[K]
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return Cursor[K]{}, ErrInvalidCursor
+	}
+	dir := Forward
+	if payload.Backward {
+		dir = Backward
+	}
+	return Cursor[K]{Key: payload.Key, Direction: dir}, nil
+}
+
+// KeysetWhere returns the condition selecting the rows after (Forward) or
+// before (Backward) a key in the order of columns, comparing them as a row
+// with the parameters $firstParam onwards, e.g. "(created_at, id) > ($1, $2)".
+// All columns are sorted in the same direction, descending or not.
+//
+// Example:
+//
+//	where := pgxutil.KeysetWhere([]string{"created_at", "id"}, true, cursor.Direction, 2)
+//	query := "SELECT * FROM orders WHERE customer_id = $1 AND " + where +
+//	    " ORDER BY " + pgxutil.KeysetOrderBy([]string{"created_at", "id"}, true, cursor.Direction) +
+//	    " LIMIT $4"
+//	rows, err := pool.Query(ctx, query, customerID, cursor.Key.CreatedAt, cursor.Key.ID, limit+1)
+func KeysetWhere(columns []string, descending bool, dir Direction, firstParam int) string {
+	op := ">"
+	if descending != (dir == Backward) {
+		op = "<"
+	}
+	params := make([]string, len(columns))
+	for i := range columns {
+		params[i] = "$" + strconv.Itoa(firstParam+i)
+	}
+	return "(" + strings.Join(columns, ", ") + ") " + op + " (" + strings.Join(params, ", ") + ")"
+}
+
+// KeysetOrderBy returns the ORDER BY list of columns to page in dir, e.g.
+// "created_at DESC, id DESC". Backward pages are queried in reverse order,
+// which NewPage restores.
+func KeysetOrderBy(columns []string, descending bool, dir Direction) string {
+	order := " ASC"
+	if descending != (dir == Backward) {
+		order = " DESC"
+	}
+	terms := make([]string, len(columns))
+	for i, column := range columns {
+		terms[i] = column + order
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Page is a page of keyset-paginated rows with the cursors of the pages
+// around it; a cursor is empty when there is no such page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// NewPage builds the page of rows queried with KeysetWhere and KeysetOrderBy
+// in dir, and a LIMIT of limit+1 so the extra row tells whether a further
+// page exists. hasCursor is whether the page was requested with a cursor,
+// i.e. is not the first page; key returns the sort key of a row.
+//
+// Example:
+//
+//	page, err := pgxutil.NewPage(orders, limit, cursor.Direction, cursorParam != "",
+//	    func(o Order) OrderKey { return OrderKey{CreatedAt: o.CreatedAt, ID: o.ID} },
+//	)
+func NewPage[T, K any](rows []T, limit int, dir Direction, hasCursor bool, key func(T) K) (Page[T], error) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	rows = append(make([]T, 0, len(rows)), rows...)
+	hasNext, hasPrev := more, hasCursor
+	if dir == Backward {
+		slices.Reverse(rows)
+		hasNext, hasPrev = hasCursor, more
+	}
+
+	page := Page[T]{Items: rows}
+	if len(rows) == 0 {
+		return page, nil
+	}
+	var err error
+	if hasNext {
+		if page.NextCursor, err = EncodeCursor(key(rows[len(rows)-1]), Forward); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = EncodeCursor(key(rows[0]), Backward); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,121 @@
+package pgxutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderKey struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("round-trips typed keys", func(t *testing.T) {
+		key := orderKey{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC), ID: uuid.New()}
+		for _, dir := range []Direction{Forward, Backward} {
+			cursor, err := EncodeCursor(key, dir)
+			require.NoError(t, err)
+			assert.NotContains(t, cursor, "=")
+
+			got, err := DecodeCursor[orderKey](cursor)
+			require.NoError(t, err)
+			assert.Equal(t, Cursor[orderKey]{Key: key, Direction: dir}, got)
+		}
+	})
+
+	t.Run("rejects malformed cursors", func(t *testing.T) {
+		for _, cursor := range []string{"", "not base64!", "bm90IGpzb24", "eyJrIjoiYWJjIn0"} {
+			_, err := DecodeCursor[orderKey](cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+		}
+	})
+}
+
+func TestKeysetWhere(t *testing.T) {
+	columns := []string{"created_at", "id"}
+
+	assert.Equal(t, "(created_at, id) > ($1, $2)", KeysetWhere(columns, false, Forward, 1))
+	assert.Equal(t, "(created_at, id) < ($3, $4)", KeysetWhere(columns, false, Backward, 3))
+	assert.Equal(t, "(created_at, id) < ($1, $2)", KeysetWhere(columns, true, Forward, 1))
+	assert.Equal(t, "(created_at, id) > ($1, $2)", KeysetWhere(columns, true, Backward, 1))
+	assert.Equal(t, "(id) > ($2)", KeysetWhere([]string{"id"}, false, Forward, 2))
+}
+
+func TestKeysetOrderBy(t *testing.T) {
+	columns := []string{"created_at", "id"}
+
+	assert.Equal(t, "created_at ASC, id ASC", KeysetOrderBy(columns, false, Forward))
+	assert.Equal(t, "created_at DESC, id DESC", KeysetOrderBy(columns, false, Backward))
+	assert.Equal(t, "created_at DESC, id DESC", KeysetOrderBy(columns, true, Forward))
+	assert.Equal(t, "created_at ASC, id ASC", KeysetOrderBy(columns, true, Backward))
+}
+
+func TestNewPage(t *testing.T) {
+	key := func(n int) int { return n }
+	decode := func(t *testing.T, cursor string) Cursor[int] {
+		t.Helper()
+		c, err := DecodeCursor[int](cursor)
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("first page with more rows", func(t *testing.T) {
+		page, err := NewPage([]int{1, 2, 3}, 2, Forward, false, key)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{1, 2}, page.Items)
+		assert.Equal(t, Cursor[int]{Key: 2, Direction: Forward}, decode(t, page.NextCursor))
+		assert.Empty(t, page.PrevCursor)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		page, err := NewPage([]int{3, 4}, 2, Forward, true, key)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{3, 4}, page.Items)
+		assert.Empty(t, page.NextCursor)
+		assert.Equal(t, Cursor[int]{Key: 3, Direction: Backward}, decode(t, page.PrevCursor))
+	})
+
+	t.Run("backward page restores order", func(t *testing.T) {
+		// Rows before 5, queried in reverse order.
+		page, err := NewPage([]int{4, 3, 2}, 2, Backward, true, key)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{3, 4}, page.Items)
+		assert.Equal(t, Cursor[int]{Key: 4, Direction: Forward}, decode(t, page.NextCursor))
+		assert.Equal(t, Cursor[int]{Key: 3, Direction: Backward}, decode(t, page.PrevCursor))
+	})
+
+	t.Run("backward page reaching the start", func(t *testing.T) {
+		page, err := NewPage([]int{2, 1}, 2, Backward, true, key)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{1, 2}, page.Items)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+	})
+
+	t.Run("empty page", func(t *testing.T) {
+		page, err := NewPage[int](nil, 2, Forward, true, key)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(page)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":[]}`, string(data))
+	})
+
+	t.Run("does not modify rows", func(t *testing.T) {
+		rows := []int{3, 2, 1}
+		_, err := NewPage(rows, 2, Backward, true, key)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{3, 2, 1}, rows)
+	})
+}
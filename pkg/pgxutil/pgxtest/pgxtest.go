@@ -0,0 +1,282 @@
+// Package pgxtest provides isolated PostgreSQL databases for tests.
+//
+// NewTestPool creates a fresh database for every test on a shared server,
+// applies migrations to it, and drops it when the test ends, so tests can run
+// in parallel without seeing each other's data. The server is the one of the
+// PGXTEST_DATABASE_URL environment variable when it is set, e.g. a CI
+// service container; otherwise an embedded PostgreSQL is downloaded and
+// started on first use. Call Main from TestMain to stop it when the tests
+// finish.
+//
+// Example:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	func TestMain(m *testing.M) {
+//	    os.Exit(pgxtest.Main(m))
+//	}
+//
+//	func TestUserRepository(t *testing.T) {
+//	    sub, _ := fs.Sub(migrations, "migrations")
+//	    pool := pgxtest.NewTestPool(t, pgxtest.WithMigrations(sub))
+//	    repo := adapters.NewUserPostgresRepository(pool)
+//	    // ...
+//	}
+package pgxtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ianmuhia/kit/pkg/pgxutil"
+)
+
+// EnvDatabaseURL names the environment variable of the postgres:// URL of
+// an existing server to create test databases on, as a user allowed to
+// create databases.
+const EnvDatabaseURL = "PGXTEST_DATABASE_URL"
+
+const (
+	maxDatabaseNameLen = 63
+	dropTimeout        = 30 * time.Second
+)
+
+var (
+	serverOnce sync.Once
+	serverURL  string
+	serverErr  error
+	embedded   *embeddedpostgres.EmbeddedPostgres
+	runtimeDir string
+)
+
+// options holds the configuration of a test pool.
+type options struct {
+	migrations  fs.FS
+	poolOptions []pgxutil.PoolOption
+}
+
+// Option is a functional option for configuring NewTestPool.
+type Option func(*options)
+
+// WithMigrations applies the *.sql files at the root of fsys to the test
+// database, in name order, each in one go. Files ending in .down.sql are
+// skipped.
+func WithMigrations(fsys fs.FS) Option {
+	return func(o *options) {
+		o.migrations = fsys
+	}
+}
+
+// WithPoolOptions configures the pool with opts, e.g.
+// pgxutil.WithCompositeTypes; the DSN is set by NewTestPool.
+func WithPoolOptions(opts ...pgxutil.PoolOption) Option {
+	return func(o *options) {
+		o.poolOptions = append(o.poolOptions, opts...)
+	}
+}
+
+// NewTestPool creates a database for t, applies the migrations and returns a
+// pool connected to it, built with pgxutil.NewPool. The pool is closed and
+// the database dropped when t ends. It fails t when the server cannot be
+// reached or a migration fails.
+func NewTestPool(t testing.TB, opts ...Option) *pgxpool.Pool {
+	t.Helper()
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	ctx := context.Background()
+
+	admin, err := adminURL()
+	if err != nil {
+		t.Fatalf("pgxtest: start postgres: %v", err)
+	}
+	name, err := databaseName(t.Name())
+	if err != nil {
+		t.Fatalf("pgxtest: %v", err)
+	}
+	dsn, err := databaseURL(admin, name)
+	if err != nil {
+		t.Fatalf("pgxtest: %v", err)
+	}
+
+	if err := execAdmin(ctx, admin, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		t.Fatalf("pgxtest: create database %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dropTimeout)
+		defer cancel()
+		if err := execAdmin(ctx, admin, "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)"); err != nil {
+			t.Errorf("pgxtest: drop database %s: %v", name, err)
+		}
+	})
+
+	pool, err := pgxutil.NewPool(ctx, append([]pgxutil.PoolOption{pgxutil.WithDSN(dsn)}, o.poolOptions...)...)
+	if err != nil {
+		t.Fatalf("pgxtest: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if o.migrations != nil {
+		if err := migrate(ctx, pool, o.migrations); err != nil {
+			t.Fatalf("pgxtest: %v", err)
+		}
+	}
+	return pool
+}
+
+// Main runs the tests of m and stops the embedded server, if one was
+// started, returning the exit code for os.Exit.
+func Main(m *testing.M) int {
+	code := m.Run()
+	if embedded != nil {
+		if err := embedded.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "pgxtest: stop postgres: %v\n", err)
+			code = 1
+		}
+		_ = os.RemoveAll(runtimeDir)
+	}
+	return code
+}
+
+// adminURL returns the URL of the server, starting the embedded one on first
+// use when EnvDatabaseURL is not set.
+func adminURL() (string, error) {
+	serverOnce.Do(func() {
+		if u := os.Getenv(EnvDatabaseURL); u != "" {
+			serverURL = u
+			return
+		}
+		serverURL, serverErr = startEmbedded()
+	})
+	return serverURL, serverErr
+}
+
+// startEmbedded starts an embedded server on a free port.
+func startEmbedded() (string, error) {
+	port, err := freePort()
+	if err != nil {
+		return "", err
+	}
+	runtimeDir, err = os.MkdirTemp("", "pgxtest-")
+	if err != nil {
+		return "", err
+	}
+
+	config := embeddedpostgres.DefaultConfig().
+		Port(port).
+		RuntimePath(runtimeDir).
+		Logger(io.Discard)
+	embedded = embeddedpostgres.NewDatabase(config)
+	if err := embedded.Start(); err != nil {
+		embedded = nil
+		_ = os.RemoveAll(runtimeDir)
+		return "", err
+	}
+	return config.GetConnectionURL() + "?sslmode=disable", nil
+}
+
+// freePort returns a TCP port nothing listens on.
+func freePort() (uint32, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// execAdmin runs sql on the server at admin.
+func execAdmin(ctx context.Context, admin, sql string) error {
+	conn, err := pgx.Connect(ctx, admin)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, sql)
+	return err
+}
+
+// databaseName returns a unique database name for the test testName, e.g.
+// "test_userrepository_create_3f2a9c1d".
+func databaseName(testName string) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(testName) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '_', r == '/', r == ' ', r == '-':
+			b.WriteByte('_')
+		}
+	}
+	prefix := "test_" + b.String()
+	end := "_" + hex.EncodeToString(suffix)
+	if len(prefix)+len(end) > maxDatabaseNameLen {
+		prefix = prefix[:maxDatabaseNameLen-len(end)]
+	}
+	return prefix + end, nil
+}
+
+// databaseURL returns admin with its database replaced by name.
+func databaseURL(admin, name string) (string, error) {
+	u, err := url.Parse(admin)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return "", fmt.Errorf("invalid %s %q: want a postgres:// URL", EnvDatabaseURL, admin)
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
+
+// migrationFiles returns the migrations of fsys in the order they apply.
+func migrationFiles(fsys fs.FS) ([]string, error) {
+	matches, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	files := matches[:0]
+	for _, name := range matches {
+		if !strings.HasSuffix(name, ".down.sql") {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// migrate applies the migrations of fsys.
+func migrate(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) error {
+	files, err := migrationFiles(fsys)
+	if err != nil {
+		return fmt.Errorf("list migrations: %w", err)
+	}
+	for _, name := range files {
+		sql, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", name, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("apply migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
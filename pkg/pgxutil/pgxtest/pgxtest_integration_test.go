@@ -0,0 +1,46 @@
+//go:build integration
+
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(Main(m))
+}
+
+func TestNewTestPool(t *testing.T) {
+	migrations := fstest.MapFS{
+		"001_users.sql": {Data: []byte("CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL);")},
+		"002_seed.sql":  {Data: []byte("INSERT INTO users (email) VALUES ('a@example.com'); INSERT INTO users (email) VALUES ('b@example.com');")},
+	}
+
+	t.Run("applies migrations", func(t *testing.T) {
+		t.Parallel()
+		pool := NewTestPool(t, WithMigrations(migrations))
+
+		var n int
+		require.NoError(t, pool.QueryRow(context.Background(), "SELECT count(*) FROM users").Scan(&n))
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("isolates databases", func(t *testing.T) {
+		t.Parallel()
+		pool := NewTestPool(t, WithMigrations(migrations))
+
+		_, err := pool.Exec(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+
+		other := NewTestPool(t, WithMigrations(migrations))
+		var n int
+		require.NoError(t, other.QueryRow(context.Background(), "SELECT count(*) FROM users").Scan(&n))
+		assert.Equal(t, 2, n)
+	})
+}
@@ -0,0 +1,53 @@
+package pgxtest
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseName(t *testing.T) {
+	valid := regexp.MustCompile(`^test_[a-z0-9_]+_[0-9a-f]{8}$`)
+
+	name, err := databaseName("TestUserRepository/Create user-2")
+	require.NoError(t, err)
+	assert.Regexp(t, valid, name)
+	assert.True(t, strings.HasPrefix(name, "test_testuserrepository_create_user_2_"), name)
+
+	other, err := databaseName("TestUserRepository/Create user-2")
+	require.NoError(t, err)
+	assert.NotEqual(t, name, other)
+
+	long, err := databaseName(strings.Repeat("TestVeryLongName", 10))
+	require.NoError(t, err)
+	assert.Len(t, long, maxDatabaseNameLen)
+	assert.Regexp(t, valid, long)
+}
+
+func TestDatabaseURL(t *testing.T) {
+	dsn, err := databaseURL("postgres://app:secret@db:5432/postgres?sslmode=disable", "test_x")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:secret@db:5432/test_x?sslmode=disable", dsn)
+
+	_, err = databaseURL("host=db user=app", "test_x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), EnvDatabaseURL)
+}
+
+func TestMigrationFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_orders.sql":     {Data: []byte("CREATE TABLE orders ();")},
+		"001_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"README.md":          {Data: []byte("# Migrations")},
+		"seed/003_seed.sql":  {Data: []byte("INSERT INTO users DEFAULT VALUES;")},
+	}
+
+	files, err := migrationFiles(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"001_users.up.sql", "002_orders.sql"}, files)
+}
@@ -0,0 +1,160 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// poolOptions holds the configuration NewPool builds a pool from.
+type poolOptions struct {
+	dsn              string
+	maxConns         int32
+	tracer           pgx.QueryTracer
+	statementTimeout time.Duration
+	afterConnect     []func(context.Context, *pgx.Conn) error
+}
+
+// PoolOption is a functional option for configuring the pool NewPool builds.
+type PoolOption func(*poolOptions)
+
+// WithDSN sets the connection string, as a URL or keyword/value string.
+// Required.
+func WithDSN(dsn string) PoolOption {
+	return func(o *poolOptions) {
+		o.dsn = dsn
+	}
+}
+
+// WithMaxConns sets the maximum size of the pool, overriding pool_max_conns
+// of the DSN.
+func WithMaxConns(n int32) PoolOption {
+	return func(o *poolOptions) {
+		o.maxConns = n
+	}
+}
+
+// WithLogger logs every query to logger with a Tracer of default
+// configuration. Use WithTracer to configure it.
+func WithLogger(logger *slog.Logger) PoolOption {
+	return func(o *poolOptions) {
+		o.tracer = NewTracer(logger)
+	}
+}
+
+// WithTracer traces every query with tracer, e.g. a Tracer from NewTracer.
+func WithTracer(tracer pgx.QueryTracer) PoolOption {
+	return func(o *poolOptions) {
+		o.tracer = tracer
+	}
+}
+
+// WithStatementTimeout sets the statement_timeout of every connection, so the
+// server aborts statements running longer than d.
+func WithStatementTimeout(d time.Duration) PoolOption {
+	return func(o *poolOptions) {
+		o.statementTimeout = d
+	}
+}
+
+// WithAfterConnect runs hook on every new connection, after the types of
+// RegisterTypes are registered. Repeat it to run several hooks, in the order
+// given; an error of any closes the connection.
+func WithAfterConnect(hook func(ctx context.Context, conn *pgx.Conn) error) PoolOption {
+	return func(o *poolOptions) {
+		o.afterConnect = append(o.afterConnect, hook)
+	}
+}
+
+// NewPool creates a connection pool and pings the database, failing fast
+// when it is unreachable. Every connection has uuid.UUID and decimal.Decimal
+// registered with RegisterTypes.
+//
+// Example:
+//
+//	pool, err := pgxutil.NewPool(ctx,
+//	    pgxutil.WithDSN(os.Getenv("DATABASE_URL")),
+//	    pgxutil.WithMaxConns(20),
+//	    pgxutil.WithStatementTimeout(30*time.Second),
+//	    pgxutil.WithLogger(logger),
+//	)
+//	if err != nil {
+//	    return err
+//	}
+//	defer pool.Close()
+func NewPool(ctx context.Context, opts ...PoolOption) (*pgxpool.Pool, error) {
+	config, err := newPoolConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("pgxutil: create pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pgxutil: ping database: %w", err)
+	}
+	return pool, nil
+}
+
+// newPoolConfig builds the pgxpool configuration from opts.
+func newPoolConfig(opts ...PoolOption) (*pgxpool.Config, error) {
+	o := &poolOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.dsn == "" {
+		return nil, errors.New("pgxutil: DSN is required")
+	}
+
+	config, err := pgxpool.ParseConfig(o.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("pgxutil: parse DSN: %w", err)
+	}
+	if o.maxConns > 0 {
+		config.MaxConns = o.maxConns
+	}
+	if o.statementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(o.statementTimeout.Milliseconds(), 10)
+	}
+	if o.tracer != nil {
+		config.ConnConfig.Tracer = o.tracer
+	}
+
+	hooks := o.afterConnect
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		RegisterTypes(conn.TypeMap())
+		for _, hook := range hooks {
+			if err := hook(ctx, conn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return config, nil
+}
+
+// ReadinessCheck returns a readiness check that pings the database through
+// pool, failing when it does not answer within timeout, e.g. for the
+// AddReadinessCheck of a generated service.
+//
+// Example:
+//
+//	srv.AddReadinessCheck("postgres", pgxutil.ReadinessCheck(pool, 2*time.Second))
+func ReadinessCheck(pool *pgxpool.Pool, timeout time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := pool.Ping(ctx); err != nil {
+			return fmt.Errorf("pgxutil: ping database: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package pgxutil
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HalfOpen is the bound kinds of a range including its lower and excluding
+// its upper bound, "[)", the canonical form of discrete ranges.
+var HalfOpen = [2]pgtype.BoundType{pgtype.Inclusive, pgtype.Exclusive}
+
+// rangeFromBounds builds a range of bounds with kinds. Bounds of kind
+// Unbounded or Empty are ignored.
+func rangeFromBounds[T, V any](bounds [2]V, kinds [2]pgtype.BoundType, wrap func(V) T) pgtype.Range[T] {
+	r := pgtype.Range[T]{LowerType: kinds[0], UpperType: kinds[1], Valid: true}
+	if isFinite(kinds[0]) {
+		r.Lower = wrap(bounds[0])
+	}
+	if isFinite(kinds[1]) {
+		r.Upper = wrap(bounds[1])
+	}
+	return r
+}
+
+// boundsFromRange returns the bounds of r with their kinds, and false for
+// NULL. Bounds of kind Unbounded or Empty are zero.
+func boundsFromRange[T, V any](r pgtype.Range[T], unwrap func(T) V) ([2]V, [2]pgtype.BoundType, bool) {
+	var bounds [2]V
+	if !r.Valid {
+		return bounds, [2]pgtype.BoundType{}, false
+	}
+	if isFinite(r.LowerType) {
+		bounds[0] = unwrap(r.Lower)
+	}
+	if isFinite(r.UpperType) {
+		bounds[1] = unwrap(r.Upper)
+	}
+	return bounds, [2]pgtype.BoundType{r.LowerType, r.UpperType}, true
+}
+
+// isFinite reports whether a bound of kind t has a value.
+func isFinite(t pgtype.BoundType) bool {
+	return t == pgtype.Inclusive || t == pgtype.Exclusive
+}
+
+// TstzrangeFromTimes converts lower and upper bounds with their kinds to a
+// TSTZRANGE. Use pgtype.Unbounded for an open end, whose time is ignored, and
+// pgtype.Empty for both kinds for the empty range.
+//
+// Example:
+//
+//	period := pgxutil.TstzrangeFromTimes([2]time.Time{start, end}, pgxutil.HalfOpen)
+//	since := pgxutil.TstzrangeFromTimes([2]time.Time{start}, [2]pgtype.BoundType{pgtype.Inclusive, pgtype.Unbounded})
+func TstzrangeFromTimes(bounds [2]time.Time, kinds [2]pgtype.BoundType) pgtype.Range[pgtype.Timestamptz] {
+	return rangeFromBounds(bounds, kinds, func(t time.Time) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: t, Valid: true}
+	})
+}
+
+// TimesFromTstzrange converts a TSTZRANGE to its lower and upper bounds with
+// their kinds, returning false for NULL. Unbounded ends are zero times.
+//
+// Example:
+//
+//	bounds, kinds, ok := pgxutil.TimesFromTstzrange(row.Period)
+//	if ok && kinds[1] == pgtype.Unbounded {
+//	    fmt.Println("open since", bounds[0])
+//	}
+func TimesFromTstzrange(r pgtype.Range[pgtype.Timestamptz]) ([2]time.Time, [2]pgtype.BoundType, bool) {
+	return boundsFromRange(r, func(t pgtype.Timestamptz) time.Time { return t.Time })
+}
+
+// Int4rangeFromInts converts lower and upper bounds with their kinds to an
+// INT4RANGE. PostgreSQL returns it in the HalfOpen form.
+func Int4rangeFromInts(bounds [2]int32, kinds [2]pgtype.BoundType) pgtype.Range[pgtype.Int4] {
+	return rangeFromBounds(bounds, kinds, func(n int32) pgtype.Int4 {
+		return pgtype.Int4{Int32: n, Valid: true}
+	})
+}
+
+// IntsFromInt4range converts an INT4RANGE to its lower and upper bounds with
+// their kinds, returning false for NULL.
+func IntsFromInt4range(r pgtype.Range[pgtype.Int4]) ([2]int32, [2]pgtype.BoundType, bool) {
+	return boundsFromRange(r, func(n pgtype.Int4) int32 { return n.Int32 })
+}
+
+// Int8rangeFromInts converts lower and upper bounds with their kinds to an
+// INT8RANGE. PostgreSQL returns it in the HalfOpen form.
+func Int8rangeFromInts(bounds [2]int64, kinds [2]pgtype.BoundType) pgtype.Range[pgtype.Int8] {
+	return rangeFromBounds(bounds, kinds, func(n int64) pgtype.Int8 {
+		return pgtype.Int8{Int64: n, Valid: true}
+	})
+}
+
+// IntsFromInt8range converts an INT8RANGE to its lower and upper bounds with
+// their kinds, returning false for NULL.
+func IntsFromInt8range(r pgtype.Range[pgtype.Int8]) ([2]int64, [2]pgtype.BoundType, bool) {
+	return boundsFromRange(r, func(n pgtype.Int8) int64 { return n.Int64 })
+}
@@ -0,0 +1,92 @@
+package pgxutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTstzrangeConversions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("bounded range", func(t *testing.T) {
+		r := TstzrangeFromTimes([2]time.Time{start, end}, HalfOpen)
+
+		assert.True(t, r.Valid)
+		assert.Equal(t, pgtype.Timestamptz{Time: start, Valid: true}, r.Lower)
+		assert.Equal(t, pgtype.Timestamptz{Time: end, Valid: true}, r.Upper)
+
+		bounds, kinds, ok := TimesFromTstzrange(r)
+		require.True(t, ok)
+		assert.Equal(t, [2]time.Time{start, end}, bounds)
+		assert.Equal(t, HalfOpen, kinds)
+	})
+
+	t.Run("unbounded end", func(t *testing.T) {
+		kinds := [2]pgtype.BoundType{pgtype.Exclusive, pgtype.Unbounded}
+		r := TstzrangeFromTimes([2]time.Time{start, end}, kinds)
+
+		assert.False(t, r.Upper.Valid)
+
+		bounds, gotKinds, ok := TimesFromTstzrange(r)
+		require.True(t, ok)
+		assert.Equal(t, [2]time.Time{start, {}}, bounds)
+		assert.Equal(t, kinds, gotKinds)
+	})
+
+	t.Run("NULL", func(t *testing.T) {
+		_, _, ok := TimesFromTstzrange(pgtype.Range[pgtype.Timestamptz]{})
+		assert.False(t, ok)
+	})
+
+	t.Run("encodes", func(t *testing.T) {
+		m := pgtype.NewMap()
+		for _, tc := range []struct {
+			kinds [2]pgtype.BoundType
+			want  string
+		}{
+			{HalfOpen, `[2024-01-01 00:00:00Z,2024-02-01 00:00:00Z)`},
+			{[2]pgtype.BoundType{pgtype.Inclusive, pgtype.Unbounded}, `[2024-01-01 00:00:00Z,)`},
+			{[2]pgtype.BoundType{pgtype.Empty, pgtype.Empty}, `empty`},
+		} {
+			buf, err := m.Encode(pgtype.TstzrangeOID, pgtype.TextFormatCode, TstzrangeFromTimes([2]time.Time{start, end}, tc.kinds), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(buf))
+
+			var got pgtype.Range[pgtype.Timestamptz]
+			require.NoError(t, m.Scan(pgtype.TstzrangeOID, pgtype.TextFormatCode, buf, &got))
+			_, kinds, ok := TimesFromTstzrange(got)
+			require.True(t, ok)
+			assert.Equal(t, tc.kinds, kinds)
+		}
+	})
+}
+
+func TestIntRangeConversions(t *testing.T) {
+	t.Run("int4range", func(t *testing.T) {
+		r := Int4rangeFromInts([2]int32{1, 10}, [2]pgtype.BoundType{pgtype.Inclusive, pgtype.Inclusive})
+		bounds, kinds, ok := IntsFromInt4range(r)
+		require.True(t, ok)
+		assert.Equal(t, [2]int32{1, 10}, bounds)
+		assert.Equal(t, [2]pgtype.BoundType{pgtype.Inclusive, pgtype.Inclusive}, kinds)
+
+		buf, err := pgtype.NewMap().Encode(pgtype.Int4rangeOID, pgtype.TextFormatCode, r, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "[1,10]", string(buf))
+	})
+
+	t.Run("int8range", func(t *testing.T) {
+		r := Int8rangeFromInts([2]int64{0, 1 << 40}, [2]pgtype.BoundType{pgtype.Unbounded, pgtype.Exclusive})
+		bounds, kinds, ok := IntsFromInt8range(r)
+		require.True(t, ok)
+		assert.Equal(t, [2]int64{0, 1 << 40}, bounds)
+		assert.Equal(t, [2]pgtype.BoundType{pgtype.Unbounded, pgtype.Exclusive}, kinds)
+
+		_, _, ok = IntsFromInt8range(pgtype.Range[pgtype.Int8]{})
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,136 @@
+package pgxutil
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// redacted replaces query arguments hidden by RedactAllArgs.
+const redacted = "[REDACTED]"
+
+// ArgRedactor returns the value logged for the query argument at index, e.g.
+// a placeholder for passwords or tokens.
+type ArgRedactor func(index int, arg any) any
+
+// RedactAllArgs is an ArgRedactor hiding every query argument.
+func RedactAllArgs(int, any) any {
+	return redacted
+}
+
+// Tracer is a pgx.QueryTracer logging every query with its arguments,
+// duration and affected row count via slog: at debug level, at warn level
+// when it is slow and at error level when it fails.
+type Tracer struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	redactor      ArgRedactor
+}
+
+// TracerOption is a functional option for configuring the Tracer.
+type TracerOption func(*Tracer)
+
+// WithSlowQueryThreshold logs queries taking d or longer at warn level.
+func WithSlowQueryThreshold(d time.Duration) TracerOption {
+	return func(t *Tracer) {
+		t.slowThreshold = d
+	}
+}
+
+// WithArgRedactor logs query arguments as redactor returns them.
+func WithArgRedactor(redactor ArgRedactor) TracerOption {
+	return func(t *Tracer) {
+		t.redactor = redactor
+	}
+}
+
+// NewTracer creates a tracer logging to logger. Pass it to WithTracer, or
+// set it as the Tracer of a pgx.ConnConfig.
+// Default configuration:
+//   - Slow query threshold: none, only failed queries are logged above debug
+//   - Arguments: logged as given
+//
+// Example:
+//
+//	tracer := pgxutil.NewTracer(logger,
+//	    pgxutil.WithSlowQueryThreshold(500*time.Millisecond),
+//	    pgxutil.WithArgRedactor(pgxutil.RedactAllArgs),
+//	)
+//	pool, err := pgxutil.NewPool(ctx, pgxutil.WithDSN(dsn), pgxutil.WithTracer(tracer))
+func NewTracer(logger *slog.Logger, opts ...TracerOption) *Tracer {
+	t := &Tracer{logger: logger}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.slowThreshold < 0 {
+		logger.Warn("invalid slow query threshold, disabling", "threshold", t.slowThreshold)
+		t.slowThreshold = 0
+	}
+	return t
+}
+
+// traceQueryKey is the context key of the traceQuery of a running query.
+type traceQueryKey struct{}
+
+// traceQuery is a query TraceQueryStart saw, for TraceQueryEnd to log.
+type traceQuery struct {
+	sql   string
+	args  []any
+	start time.Time
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceQueryKey{}, &traceQuery{
+		sql:   data.SQL,
+		args:  data.Args,
+		start: time.Now(),
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(traceQueryKey{}).(*traceQuery)
+	if !ok {
+		return
+	}
+	duration := time.Since(q.start)
+
+	level := slog.LevelDebug
+	msg := "query"
+	switch {
+	case data.Err != nil:
+		level, msg = slog.LevelError, "query failed"
+	case t.slowThreshold > 0 && duration >= t.slowThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !t.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", q.sql),
+		slog.Any("args", t.redactArgs(q.args)),
+		slog.Duration("duration", duration),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	} else {
+		attrs = append(attrs, slog.Int64("rows", data.CommandTag.RowsAffected()))
+	}
+	t.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// redactArgs returns args as the redactor returns them.
+func (t *Tracer) redactArgs(args []any) []any {
+	if t.redactor == nil || len(args) == 0 {
+		return args
+	}
+	out := make([]any, len(args))
+	for i, arg := range args {
+		out[i] = t.redactor(i, arg)
+	}
+	return out
+}
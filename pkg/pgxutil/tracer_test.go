@@ -0,0 +1,108 @@
+package pgxutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTracer(level slog.Level, opts ...TracerOption) (*Tracer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level}))
+	return NewTracer(logger, opts...), &buf
+}
+
+// runTrace runs a query through tracer, taking at least duration.
+func runTrace(tracer *Tracer, duration time.Duration, err error, args ...any) {
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "UPDATE users SET password = $1 WHERE id = $2",
+		Args: args,
+	})
+	time.Sleep(duration)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("UPDATE 3"),
+		Err:        err,
+	})
+}
+
+func TestTracer(t *testing.T) {
+	t.Run("logs queries at debug level", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelDebug)
+		runTrace(tracer, 0, nil, "hunter2", 42)
+
+		out := buf.String()
+		assert.Contains(t, out, "level=DEBUG")
+		assert.Contains(t, out, "msg=query")
+		assert.Contains(t, out, `sql="UPDATE users SET password = $1 WHERE id = $2"`)
+		assert.Contains(t, out, "args=\"[hunter2 42]\"")
+		assert.Contains(t, out, "duration=")
+		assert.Contains(t, out, "rows=3")
+	})
+
+	t.Run("skips disabled levels", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelInfo)
+		runTrace(tracer, 0, nil)
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("logs slow queries at warn level", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelInfo, WithSlowQueryThreshold(time.Millisecond))
+		runTrace(tracer, 2*time.Millisecond, nil)
+
+		assert.Contains(t, buf.String(), "level=WARN")
+		assert.Contains(t, buf.String(), `msg="slow query"`)
+	})
+
+	t.Run("logs failed queries at error level", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelInfo, WithSlowQueryThreshold(time.Hour))
+		runTrace(tracer, 0, errors.New("deadlock detected"))
+
+		out := buf.String()
+		assert.Contains(t, out, "level=ERROR")
+		assert.Contains(t, out, `msg="query failed"`)
+		assert.Contains(t, out, `error="deadlock detected"`)
+		assert.NotContains(t, out, "rows=")
+	})
+
+	t.Run("redacts all arguments", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelDebug, WithArgRedactor(RedactAllArgs))
+		runTrace(tracer, 0, nil, "hunter2", 42)
+
+		assert.NotContains(t, buf.String(), "hunter2")
+		assert.Contains(t, buf.String(), "args=\"[[REDACTED] [REDACTED]]\"")
+	})
+
+	t.Run("redacts selected arguments", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelDebug, WithArgRedactor(func(i int, arg any) any {
+			if i == 0 {
+				return "***"
+			}
+			return arg
+		}))
+		runTrace(tracer, 0, nil, "hunter2", 42)
+
+		assert.Contains(t, buf.String(), "args=\"[*** 42]\"")
+	})
+
+	t.Run("ignores queries it did not start", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelDebug)
+		tracer.TraceQueryEnd(context.Background(), nil, pgx.TraceQueryEndData{})
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("disables a negative threshold", func(t *testing.T) {
+		tracer, buf := newTestTracer(slog.LevelDebug, WithSlowQueryThreshold(-time.Second))
+
+		assert.Zero(t, tracer.slowThreshold)
+		assert.Contains(t, buf.String(), "invalid slow query threshold")
+	})
+}
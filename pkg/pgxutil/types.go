@@ -0,0 +1,83 @@
+package pgxutil
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// RegisterTypes registers uuid.UUID and decimal.Decimal with a connection's
+// type map: both become the default Go types of their PostgreSQL types, so
+// they encode without a known parameter type, and decimal.Decimal values are
+// encoded and scanned in the binary NUMERIC format exactly, with
+// NumericFromDecimal and DecimalFromNumericE, instead of through strings.
+// NewPool registers them on every connection.
+//
+// Example:
+//
+//	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+//	    pgxutil.RegisterTypes(conn.TypeMap())
+//	    return nil
+//	}
+func RegisterTypes(m *pgtype.Map) {
+	m.TryWrapEncodePlanFuncs = append([]pgtype.TryWrapEncodePlanFunc{tryWrapDecimalEncodePlan}, m.TryWrapEncodePlanFuncs...)
+	m.TryWrapScanPlanFuncs = append([]pgtype.TryWrapScanPlanFunc{tryWrapDecimalScanPlan}, m.TryWrapScanPlanFuncs...)
+	m.RegisterDefaultPgType(decimal.Decimal{}, "numeric")
+	m.RegisterDefaultPgType(uuid.UUID{}, "uuid")
+}
+
+// numericDecimal is a decimal.Decimal that pgtype's NumericCodec encodes and
+// scans directly.
+type numericDecimal decimal.Decimal
+
+// NumericValue implements pgtype.NumericValuer.
+func (d numericDecimal) NumericValue() (pgtype.Numeric, error) {
+	return NumericFromDecimal(decimal.Decimal(d)), nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner.
+func (d *numericDecimal) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return fmt.Errorf("cannot scan NULL into *decimal.Decimal")
+	}
+	v, err := DecimalFromNumericE(n)
+	if err != nil {
+		return err
+	}
+	*d = numericDecimal(v)
+	return nil
+}
+
+// tryWrapDecimalEncodePlan encodes decimal.Decimal values as numericDecimal.
+func tryWrapDecimalEncodePlan(value any) (pgtype.WrappedEncodePlanNextSetter, any, bool) {
+	if d, ok := value.(decimal.Decimal); ok {
+		return &decimalEncodePlan{}, numericDecimal(d), true
+	}
+	return nil, nil, false
+}
+
+type decimalEncodePlan struct{ next pgtype.EncodePlan }
+
+func (p *decimalEncodePlan) SetNext(next pgtype.EncodePlan) { p.next = next }
+
+func (p *decimalEncodePlan) Encode(value any, buf []byte) ([]byte, error) {
+	return p.next.Encode(numericDecimal(value.(decimal.Decimal)), buf)
+}
+
+// tryWrapDecimalScanPlan scans into *decimal.Decimal as *numericDecimal.
+func tryWrapDecimalScanPlan(target any) (pgtype.WrappedScanPlanNextSetter, any, bool) {
+	if d, ok := target.(*decimal.Decimal); ok {
+		return &decimalScanPlan{}, (*numericDecimal)(d), true
+	}
+	return nil, nil, false
+}
+
+type decimalScanPlan struct{ next pgtype.ScanPlan }
+
+func (p *decimalScanPlan) SetNext(next pgtype.ScanPlan) { p.next = next }
+
+func (p *decimalScanPlan) Scan(src []byte, dst any) error {
+	return p.next.Scan(src, (*numericDecimal)(dst.(*decimal.Decimal)))
+}